@@ -14,11 +14,16 @@ import (
 	"github.com/joho/godotenv"
 	"golang.org/x/crypto/bcrypt"
 
+	"github.com/subculture-collective/subcult-tv/api/internal/authors"
 	"github.com/subculture-collective/subcult-tv/api/internal/config"
 	"github.com/subculture-collective/subcult-tv/api/internal/database"
 	"github.com/subculture-collective/subcult-tv/api/internal/handlers"
+	"github.com/subculture-collective/subcult-tv/api/internal/jwtkeys"
+	"github.com/subculture-collective/subcult-tv/api/internal/mail"
 	"github.com/subculture-collective/subcult-tv/api/internal/patreon"
+	"github.com/subculture-collective/subcult-tv/api/internal/render"
 	"github.com/subculture-collective/subcult-tv/api/internal/router"
+	"github.com/subculture-collective/subcult-tv/api/internal/scheduler"
 )
 
 func main() {
@@ -51,6 +56,20 @@ func main() {
 	}
 	slog.Info("migrations applied")
 
+	// ── Re-render posts from older rendering rules ───────────
+	if n, err := render.RefreshStale(ctx, pool); err != nil {
+		slog.Warn("render posts", "error", err)
+	} else if n > 0 {
+		slog.Info("posts re-rendered", "count", n, "version", render.Version)
+	}
+
+	// ── Link bylines from before authors to authors ──────────
+	if n, err := authors.Backfill(ctx, pool); err != nil {
+		slog.Warn("link post authors", "error", err)
+	} else if n > 0 {
+		slog.Info("post authors linked", "count", n)
+	}
+
 	// ── Seed admin user if none exists ───────────────────────
 	if err := seedAdminUser(ctx, pool); err != nil {
 		slog.Warn("seed admin", "error", err)
@@ -65,8 +84,53 @@ func main() {
 		slog.Warn("patreon credentials not set, campaign endpoint will return empty data")
 	}
 
+	// ── Mail ────────────────────────────────────────────────
+	var mailer mail.Sender = mail.LogSender{}
+	if cfg.SMTPHost != "" {
+		mailer = &mail.SMTPSender{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.SMTPFrom,
+		}
+		slog.Info("smtp mailer initialized", "host", cfg.SMTPHost, "port", cfg.SMTPPort)
+	} else {
+		slog.Warn("SMTP_HOST not set, outgoing email will only be logged")
+	}
+
+	// ── Signing keys ────────────────────────────────────────
+	keys, err := jwtkeys.Load(cfg.JWTKeysDir, cfg.JWTSigningKID, cfg.JWTSecret)
+	if err != nil {
+		slog.Error("jwt keys", "error", err)
+		os.Exit(1)
+	}
+	if cfg.JWTKeysDir != "" {
+		slog.Info("jwt keys loaded", "dir", cfg.JWTKeysDir, "keys", len(keys.JWKS().Keys))
+	} else {
+		slog.Warn("JWT_KEYS_DIR not set, signing access tokens with JWT_SECRET (HS256)")
+	}
+
+	// Reload keys on SIGHUP so rotation needs no restart.
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		for range hup {
+			if err := keys.Reload(); err != nil {
+				slog.Error("jwt keys reload", "error", err)
+				continue
+			}
+			slog.Info("jwt keys reloaded", "keys", len(keys.JWKS().Keys))
+		}
+	}()
+
+	// ── Scheduler ───────────────────────────────────────────
+	schedCtx, stopScheduler := context.WithCancel(ctx)
+	defer stopScheduler()
+	go scheduler.Run(schedCtx, pool, scheduler.DefaultInterval)
+
 	// ── Router ───────────────────────────────────────────────
-	h := handlers.New(pool, cfg.JWTSecret, patreonClient)
+	h := handlers.New(pool, cfg, keys, patreonClient, mailer)
 	r := router.New(cfg, h)
 
 	srv := &http.Server{
@@ -91,6 +155,7 @@ func main() {
 
 	<-quit
 	slog.Info("shutting down server...")
+	stopScheduler()
 
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
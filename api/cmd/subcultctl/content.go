@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+
+	"github.com/subculture-collective/subcult-tv/api/internal/content"
+)
+
+func contentExport(e *env, args []string) error {
+	fs := newFlags("content export")
+	out := fs.String("o", "", "output file (default stdout)")
+	format := fs.String("format", "", "json, tar.gz, or zip (default from -o, else json)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *format == "" {
+		*format = content.ArchiveFormat(*out)
+	}
+	if *format == "" {
+		*format = "json"
+	}
+	if *format != "json" && *format != content.FormatTarGz && *format != content.FormatZip {
+		return usageError("unknown format %q", *format)
+	}
+
+	db, err := e.db()
+	if err != nil {
+		return err
+	}
+	bundle, err := content.Export(e.ctx, db)
+	if err != nil {
+		return err
+	}
+
+	write := func(w io.Writer) error {
+		if *format == "json" {
+			return printJSON(w, bundle)
+		}
+		return content.WriteArchive(w, *format, bundle)
+	}
+	if *out == "" {
+		return write(e.stdout)
+	}
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func contentImport(e *env, args []string) error {
+	fs := newFlags("content import")
+	in := fs.String("i", "", "input file (default stdin)")
+	dryRun := fs.Bool("dry-run", false, "report changes without writing them")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	var r io.Reader = e.stdin
+	if *in != "" {
+		f, err := os.Open(*in)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	var bundle content.Bundle
+	if err := json.NewDecoder(r).Decode(&bundle); err != nil {
+		return usageError("invalid bundle: %v", err)
+	}
+
+	db, err := e.db()
+	if err != nil {
+		return err
+	}
+	res, err := content.Import(e.ctx, db, &bundle, content.Options{DryRun: *dryRun})
+	if err != nil {
+		return err
+	}
+	return printJSON(e.stdout, res)
+}
+
+func contentImportSource(e *env, args []string) error {
+	fs := newFlags("content import-source")
+	postsDir := fs.String("posts", "../content/posts", "directory of MDX posts (empty to skip)")
+	registry := fs.String("registry", "../src/lib/posts.ts", "post registry with post metadata (empty to skip)")
+	projects := fs.String("projects", "../content/projects.json", "projects JSON file (empty to skip)")
+	archive := fs.String("archive", "", "read a tar.gz or zip from content export instead")
+	dryRun := fs.Bool("dry-run", false, "report changes without writing them")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	var (
+		bundle *content.Bundle
+		err    error
+	)
+	switch {
+	case *archive != "":
+		format := content.ArchiveFormat(*archive)
+		if format == "" {
+			return usageError("archive must end in .tar.gz, .tgz, or .zip")
+		}
+		f, err := os.Open(*archive)
+		if err != nil {
+			return err
+		}
+		bundle, err = content.ReadArchive(f, format)
+		f.Close()
+		if err != nil {
+			return usageError("invalid archive: %v", err)
+		}
+	case *postsDir == "" && *projects == "":
+		return usageError("nothing to import: -posts and -projects are both empty")
+	default:
+		if bundle, err = content.LoadSource(*postsDir, *registry, *projects); err != nil {
+			return usageError("invalid source: %v", err)
+		}
+	}
+
+	db, err := e.db()
+	if err != nil {
+		return err
+	}
+	res, err := content.Import(e.ctx, db, bundle, content.Options{DryRun: *dryRun})
+	if err != nil {
+		return err
+	}
+	return printJSON(e.stdout, res)
+}
@@ -0,0 +1,157 @@
+// Command subcultctl runs one-shot administration tasks against the API's
+// database: managing users, applying or reverting migrations, and moving
+// content in and out. It reads the same environment as the server.
+//
+// Results go to stdout and diagnostics to stderr. The exit status is 0 on
+// success, 1 when the task fails, and 2 on a usage error.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/joho/godotenv"
+
+	"github.com/subculture-collective/subcult-tv/api/internal/config"
+	"github.com/subculture-collective/subcult-tv/api/internal/database"
+)
+
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+// errUsage marks errors caused by bad arguments rather than a failed task.
+var errUsage = errors.New("usage error")
+
+// usageError wraps a message so main exits with exitUsage.
+func usageError(format string, args ...any) error {
+	return fmt.Errorf("%w: %s", errUsage, fmt.Sprintf(format, args...))
+}
+
+// env is what every command runs with. Commands parse their flags before
+// calling db, so usage errors never need a database.
+type env struct {
+	ctx    context.Context
+	stdin  io.Reader
+	stdout io.Writer
+	pool   *pgxpool.Pool
+}
+
+// db loads the configuration and connects on first use.
+func (e *env) db() (*pgxpool.Pool, error) {
+	if e.pool != nil {
+		return e.pool, nil
+	}
+	_ = godotenv.Load("../.env")
+	_ = godotenv.Load(".env")
+
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("config: %w", err)
+	}
+	pool, err := database.Connect(e.ctx, cfg.DatabaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("database: %w", err)
+	}
+	e.pool = pool
+	return pool, nil
+}
+
+type command struct {
+	name    string
+	summary string
+	run     func(e *env, args []string) error
+}
+
+var commands = []command{
+	{"user create", "create a user (-username, -email, -role, -password-stdin)", userCreate},
+	{"user reset-password", "set a new password and revoke sessions (-username, -password-stdin)", userResetPassword},
+	{"user set-role", "change a user's role and revoke sessions (-username, -role)", userSetRole},
+	{"migrate up", "apply pending migrations", migrateUp},
+	{"migrate down", "revert the latest migrations (-steps, default 1)", migrateDown},
+	{"migrate status", "list migrations and when they were applied", migrateStatus},
+	{"content export", "write all posts and projects as JSON, tar.gz, or zip (-o file, -format)", contentExport},
+	{"content import", "upsert posts and projects from JSON (-i file, default stdin; -dry-run)", contentImport},
+	{"content import-source", "upsert MDX posts and projects.json (-posts, -projects, -archive, -dry-run)", contentImportSource},
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: subcultctl <command> <subcommand> [flags]")
+	fmt.Fprintln(w)
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-22s %s\n", c.name, c.summary)
+	}
+}
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+func run(args []string) int {
+	if len(args) < 2 {
+		usage(os.Stderr)
+		return exitUsage
+	}
+	var cmd *command
+	for i := range commands {
+		if commands[i].name == args[0]+" "+args[1] {
+			cmd = &commands[i]
+		}
+	}
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "subcultctl: unknown command %q\n\n", args[0]+" "+args[1])
+		usage(os.Stderr)
+		return exitUsage
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	e := &env{ctx: ctx, stdin: os.Stdin, stdout: os.Stdout}
+	defer func() {
+		if e.pool != nil {
+			e.pool.Close()
+		}
+	}()
+	if err := cmd.run(e, args[2:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitUsage
+		}
+		fmt.Fprintf(os.Stderr, "subcultctl %s: %v\n", cmd.name, err)
+		if errors.Is(err, errUsage) {
+			return exitUsage
+		}
+		return exitError
+	}
+	return exitOK
+}
+
+// newFlags returns a flag set that reports errors instead of exiting.
+func newFlags(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet("subcultctl "+name, flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	return fs
+}
+
+// parseFlags parses args, turning flag errors into usage errors.
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return usageError("%v", err)
+	}
+	if fs.NArg() > 0 {
+		return usageError("unexpected arguments: %v", fs.Args())
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/subculture-collective/subcult-tv/api/internal/database"
+)
+
+func migrateUp(e *env, args []string) error {
+	if err := parseFlags(newFlags("migrate up"), args); err != nil {
+		return err
+	}
+	db, err := e.db()
+	if err != nil {
+		return err
+	}
+	return database.Migrate(e.ctx, db)
+}
+
+func migrateDown(e *env, args []string) error {
+	fs := newFlags("migrate down")
+	steps := fs.Int("steps", 1, "number of migrations to revert")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *steps < 1 {
+		return usageError("-steps must be at least 1")
+	}
+	db, err := e.db()
+	if err != nil {
+		return err
+	}
+	reverted, err := database.MigrateDown(e.ctx, db, *steps)
+	for _, v := range reverted {
+		fmt.Fprintln(e.stdout, v)
+	}
+	return err
+}
+
+// migrateStatus prints one line per migration: version, "applied" or
+// "pending", and the time it was applied.
+func migrateStatus(e *env, args []string) error {
+	fs := newFlags("migrate status")
+	asJSON := fs.Bool("json", false, "print JSON instead of a table")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	db, err := e.db()
+	if err != nil {
+		return err
+	}
+	status, err := database.Status(e.ctx, db)
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		type row struct {
+			Version string     `json:"version"`
+			Applied *time.Time `json:"applied_at"`
+		}
+		rows := make([]row, 0, len(status))
+		for _, s := range status {
+			rows = append(rows, row{s.Version, s.Applied})
+		}
+		return printJSON(e.stdout, rows)
+	}
+
+	tw := tabwriter.NewWriter(e.stdout, 0, 0, 2, ' ', 0)
+	for _, s := range status {
+		if s.Applied != nil {
+			fmt.Fprintf(tw, "%s\tapplied\t%s\n", s.Version, s.Applied.UTC().Format(time.RFC3339))
+		} else {
+			fmt.Fprintf(tw, "%s\tpending\t-\n", s.Version)
+		}
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/subculture-collective/subcult-tv/api/internal/handlers"
+	"github.com/subculture-collective/subcult-tv/api/internal/middleware"
+)
+
+// userResult is printed as JSON by the user commands. Password is only set
+// when it was generated.
+type userResult struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+	Role     string `json:"role"`
+	Password string `json:"password,omitempty"`
+}
+
+// readPassword returns the first line of r.
+func readPassword(r io.Reader) (string, error) {
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// choosePassword reads a password from stdin when fromStdin is set and
+// checks it against the policy, or generates one. generated reports which.
+func choosePassword(e *env, fromStdin bool, username string) (password string, generated bool, err error) {
+	if !fromStdin {
+		password, err = handlers.GeneratePassword()
+		return password, true, err
+	}
+	password, err = readPassword(e.stdin)
+	if err != nil {
+		return "", false, err
+	}
+	if err := handlers.ValidatePassword(password, username); err != nil {
+		return "", false, usageError("%v", err)
+	}
+	return password, false, nil
+}
+
+func printJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func userCreate(e *env, args []string) error {
+	fs := newFlags("user create")
+	username := fs.String("username", "", "login name (required)")
+	email := fs.String("email", "", "email address (required)")
+	role := fs.String("role", middleware.RoleEditor, "admin, editor, or moderator")
+	fromStdin := fs.Bool("password-stdin", false, "read the password from stdin instead of generating one")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *username == "" || *email == "" {
+		return usageError("-username and -email are required")
+	}
+	if !middleware.ValidRole(*role) {
+		return usageError("invalid role %q", *role)
+	}
+
+	password, generated, err := choosePassword(e, *fromStdin, *username)
+	if err != nil {
+		return err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	db, err := e.db()
+	if err != nil {
+		return err
+	}
+	res := userResult{Username: *username, Role: *role}
+	err = db.QueryRow(e.ctx,
+		`INSERT INTO users (username, email, password_hash, role) VALUES ($1, $2, $3, $4) RETURNING id::text`,
+		*username, *email, string(hash), *role,
+	).Scan(&res.ID)
+	if err != nil {
+		return fmt.Errorf("create user: %w", err)
+	}
+	if generated {
+		res.Password = password
+	}
+	return printJSON(e.stdout, res)
+}
+
+func userResetPassword(e *env, args []string) error {
+	fs := newFlags("user reset-password")
+	username := fs.String("username", "", "login name (required)")
+	fromStdin := fs.Bool("password-stdin", false, "read the password from stdin instead of generating one")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *username == "" {
+		return usageError("-username is required")
+	}
+
+	password, generated, err := choosePassword(e, *fromStdin, *username)
+	if err != nil {
+		return err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	db, err := e.db()
+	if err != nil {
+		return err
+	}
+	tx, err := db.Begin(e.ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(e.ctx)
+
+	res := userResult{Username: *username}
+	err = tx.QueryRow(e.ctx,
+		`UPDATE users SET password_hash = $2, updated_at = NOW() WHERE username = $1 RETURNING id::text, role`,
+		*username, string(hash),
+	).Scan(&res.ID, &res.Role)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("user %q not found", *username)
+	}
+	if err != nil {
+		return err
+	}
+	if err := revokeSessions(e.ctx, tx, res.ID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(e.ctx,
+		`DELETE FROM login_failures WHERE username = lower($1)`, *username,
+	); err != nil {
+		return err
+	}
+	if err := tx.Commit(e.ctx); err != nil {
+		return err
+	}
+	if generated {
+		res.Password = password
+	}
+	return printJSON(e.stdout, res)
+}
+
+func userSetRole(e *env, args []string) error {
+	fs := newFlags("user set-role")
+	username := fs.String("username", "", "login name (required)")
+	role := fs.String("role", "", "admin, editor, or moderator (required)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *username == "" || *role == "" {
+		return usageError("-username and -role are required")
+	}
+	if !middleware.ValidRole(*role) {
+		return usageError("invalid role %q", *role)
+	}
+
+	db, err := e.db()
+	if err != nil {
+		return err
+	}
+	tx, err := db.Begin(e.ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(e.ctx)
+
+	res := userResult{Username: *username, Role: *role}
+	var current string
+	err = tx.QueryRow(e.ctx,
+		`SELECT id::text, role FROM users WHERE username = $1 FOR UPDATE`, *username,
+	).Scan(&res.ID, &current)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return fmt.Errorf("user %q not found", *username)
+	}
+	if err != nil {
+		return err
+	}
+	if current == *role {
+		return printJSON(e.stdout, res)
+	}
+
+	if current == middleware.RoleAdmin {
+		var others int
+		if err := tx.QueryRow(e.ctx,
+			`SELECT COUNT(*) FROM (SELECT 1 FROM users
+			   WHERE role = 'admin' AND disabled_at IS NULL AND id <> $1 FOR UPDATE) a`, res.ID,
+		).Scan(&others); err != nil {
+			return err
+		}
+		if others == 0 {
+			return errors.New("cannot demote the last active admin")
+		}
+	}
+
+	if _, err := tx.Exec(e.ctx,
+		`UPDATE users SET role = $2, updated_at = NOW() WHERE id = $1`, res.ID, *role,
+	); err != nil {
+		return err
+	}
+	if err := revokeSessions(e.ctx, tx, res.ID); err != nil {
+		return err
+	}
+	if err := tx.Commit(e.ctx); err != nil {
+		return err
+	}
+	return printJSON(e.stdout, res)
+}
+
+// revokeSessions ends all of a user's sessions so new credentials or roles
+// take effect immediately.
+func revokeSessions(ctx context.Context, tx pgx.Tx, userID string) error {
+	_, err := tx.Exec(ctx,
+		`UPDATE sessions SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL`, userID,
+	)
+	return err
+}
@@ -0,0 +1,320 @@
+// Package authors links posts to author records and keeps each post's
+// byline (posts.author) in step with them. A byline names its authors
+// separated by commas or "&"; names that slug the same are one author.
+package authors
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"golang.org/x/text/unicode/norm"
+
+	"github.com/subculture-collective/subcult-tv/api/internal/models"
+	"github.com/subculture-collective/subcult-tv/api/internal/revisions"
+)
+
+// Errors returned for authors given by a client.
+var (
+	ErrNoSlug        = errors.New("author name has no letters or digits")
+	ErrUnknownAuthor = errors.New("unknown author")
+)
+
+// querier is satisfied by *pgxpool.Pool and pgx.Tx.
+type querier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// transliterations spell out letters that do not decompose into an ASCII
+// letter and accents.
+var transliterations = map[rune]string{
+	'ß': "ss", 'æ': "ae", 'œ': "oe", 'ø': "o", 'đ': "d", 'ð': "d", 'ł': "l", 'þ': "th", 'ı': "i",
+}
+
+// Slugify derives an author slug from a name: lower-case ASCII letters and
+// digits, with accents stripped and every other run of characters turned
+// into one hyphen. Names with letters or digits that have no ASCII
+// spelling, such as "李明", end in a hash of the name instead, so they
+// neither lose their slug nor share it with another name. A name without
+// letters or digits has no slug.
+func Slugify(name string) string {
+	var sb strings.Builder
+	hyphen, lossy := false, false
+	write := func(s string) {
+		if hyphen && sb.Len() > 0 {
+			sb.WriteByte('-')
+		}
+		sb.WriteString(s)
+		hyphen = false
+	}
+	for _, r := range norm.NFKD.String(strings.ToLower(name)) {
+		switch {
+		case r >= 'a' && r <= 'z' || r >= '0' && r <= '9':
+			write(string(r))
+		case unicode.Is(unicode.Mn, r):
+			// An accent on the letter before it.
+		case transliterations[r] != "":
+			write(transliterations[r])
+		default:
+			lossy = lossy || unicode.IsLetter(r) || unicode.IsDigit(r)
+			hyphen = true
+		}
+	}
+	if lossy {
+		sum := sha256.Sum256([]byte(norm.NFC.String(strings.ToLower(strings.Join(strings.Fields(name), " ")))))
+		hyphen = true
+		write(hex.EncodeToString(sum[:4]))
+	}
+	return sb.String()
+}
+
+// SplitByline returns the names in a byline, in order, without blanks or
+// repeats.
+func SplitByline(byline string) []string {
+	var names []string
+	seen := map[string]bool{}
+	for _, name := range strings.FieldsFunc(byline, func(r rune) bool { return r == ',' || r == '&' }) {
+		name = strings.Join(strings.Fields(name), " ")
+		key := Slugify(name)
+		if name == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		names = append(names, name)
+	}
+	return names
+}
+
+// Byline names authors in order: "A", "A & B", or "A, B & C". It returns
+// nil for no authors.
+func Byline(refs []models.AuthorRef) *string {
+	if len(refs) == 0 {
+		return nil
+	}
+	names := make([]string, len(refs))
+	for i, a := range refs {
+		names[i] = a.Name
+	}
+	s := names[len(names)-1]
+	if len(names) > 1 {
+		s = strings.Join(names[:len(names)-1], ", ") + " & " + s
+	}
+	return &s
+}
+
+// Resolve returns the authors named, creating those that do not exist yet.
+func Resolve(ctx context.Context, db querier, names []string) ([]uuid.UUID, error) {
+	ids := make([]uuid.UUID, 0, len(names))
+	for _, name := range names {
+		slug := Slugify(name)
+		if slug == "" {
+			return nil, fmt.Errorf("%w: %q", ErrNoSlug, name)
+		}
+		var id uuid.UUID
+		// The no-op update makes RETURNING yield the existing row too.
+		err := db.QueryRow(ctx,
+			`INSERT INTO authors (slug, name) VALUES ($1, $2)
+			 ON CONFLICT (slug) DO UPDATE SET slug = EXCLUDED.slug
+			 RETURNING id`,
+			slug, name,
+		).Scan(&id)
+		if err != nil {
+			return nil, fmt.Errorf("resolve author %q: %w", name, err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// Set makes ids, in order, the post's authors and rewrites its byline from
+// their names, marking the post updated if the byline changed. It returns
+// the authors as set.
+func Set(ctx context.Context, db querier, postID string, ids []uuid.UUID) ([]models.AuthorRef, error) {
+	var unique []string
+	seen := map[uuid.UUID]bool{}
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id.String())
+		}
+	}
+
+	rows, err := db.Query(ctx,
+		`SELECT a.id, a.slug, a.name, a.avatar_url
+		 FROM unnest($1::uuid[]) WITH ORDINALITY AS t(id, position)
+		 JOIN authors a ON a.id = t.id
+		 ORDER BY t.position`, unique,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("load authors: %w", err)
+	}
+	refs, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.AuthorRef, error) {
+		var a models.AuthorRef
+		err := row.Scan(&a.ID, &a.Slug, &a.Name, &a.AvatarURL)
+		return a, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("load authors: %w", err)
+	}
+	if len(refs) != len(unique) {
+		return nil, ErrUnknownAuthor
+	}
+
+	if _, err := db.Exec(ctx, `DELETE FROM post_authors WHERE post_id = $1`, postID); err != nil {
+		return nil, fmt.Errorf("set post authors: %w", err)
+	}
+	if _, err := db.Exec(ctx,
+		`INSERT INTO post_authors (post_id, author_id, position)
+		 SELECT $1::uuid, id, position FROM unnest($2::uuid[]) WITH ORDINALITY AS t(id, position)`,
+		postID, unique,
+	); err != nil {
+		return nil, fmt.Errorf("set post authors: %w", err)
+	}
+	if _, err := db.Exec(ctx, `UPDATE posts SET author = $2, updated_at = NOW()
+		 WHERE id = $1 AND author IS DISTINCT FROM $2`, postID, Byline(refs)); err != nil {
+		return nil, fmt.Errorf("set post byline: %w", err)
+	}
+	return refs, nil
+}
+
+// Link sets the post's authors from a byline, creating authors as needed.
+func Link(ctx context.Context, db querier, postID string, byline *string) ([]models.AuthorRef, error) {
+	var names []string
+	if byline != nil {
+		names = SplitByline(*byline)
+	}
+	ids, err := Resolve(ctx, db, names)
+	if err != nil {
+		return nil, err
+	}
+	return Set(ctx, db, postID, ids)
+}
+
+// RefreshBylines rewrites the byline of every post by an author, after the
+// author's name changed, and records a revision of each post it changes
+// attributed to editorID. keep is passed on to revisions.Record.
+func RefreshBylines(ctx context.Context, db querier, authorID, editorID string, keep int) error {
+	rows, err := db.Query(ctx,
+		`SELECT post_id::text FROM post_authors WHERE author_id = $1`, authorID)
+	if err != nil {
+		return fmt.Errorf("query author posts: %w", err)
+	}
+	postIDs, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return fmt.Errorf("query author posts: %w", err)
+	}
+
+	for _, postID := range postIDs {
+		rows, err := db.Query(ctx,
+			`SELECT author_id FROM post_authors WHERE post_id = $1 ORDER BY position`, postID)
+		if err != nil {
+			return fmt.Errorf("query post authors: %w", err)
+		}
+		ids, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+		if err != nil {
+			return fmt.Errorf("query post authors: %w", err)
+		}
+		if _, err := Set(ctx, db, postID, ids); err != nil {
+			return err
+		}
+		if err := revisions.Record(ctx, db, postID, editorID, keep); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Backfill links posts that have a byline but no authors, such as posts
+// written before authors existed, and returns how many it linked. Spellings
+// of a name that slug the same become one author, named after the most
+// common spelling. Posts whose byline changes get a revision. Bylines
+// without a usable name are left alone.
+func Backfill(ctx context.Context, pool *pgxpool.Pool) (int, error) {
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx,
+		`SELECT id::text, author FROM posts
+		 WHERE btrim(COALESCE(author, '')) <> ''
+		   AND NOT EXISTS (SELECT 1 FROM post_authors WHERE post_id = posts.id)`)
+	if err != nil {
+		return 0, fmt.Errorf("query unlinked posts: %w", err)
+	}
+	type unlinked struct{ id, byline string }
+	posts, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (unlinked, error) {
+		var p unlinked
+		err := row.Scan(&p.id, &p.byline)
+		return p, err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("query unlinked posts: %w", err)
+	}
+
+	var bylines []string
+	for _, p := range posts {
+		bylines = append(bylines, p.byline)
+	}
+	if _, err := Resolve(ctx, tx, preferredSpellings(bylines)); err != nil {
+		return 0, err
+	}
+
+	linked := 0
+	for _, p := range posts {
+		if _, err := Link(ctx, tx, p.id, &p.byline); errors.Is(err, ErrNoSlug) {
+			continue
+		} else if err != nil {
+			return 0, err
+		}
+		if err := revisions.Record(ctx, tx, p.id, "", 0); err != nil {
+			return 0, err
+		}
+		linked++
+	}
+	return linked, tx.Commit(ctx)
+}
+
+// preferredSpellings returns one name per slug found in bylines: the most
+// common spelling, or the first in sort order on a tie. Names without a
+// slug are left out.
+func preferredSpellings(bylines []string) []string {
+	counts := map[string]map[string]int{}
+	for _, byline := range bylines {
+		for _, name := range SplitByline(byline) {
+			slug := Slugify(name)
+			if slug == "" {
+				continue
+			}
+			if counts[slug] == nil {
+				counts[slug] = map[string]int{}
+			}
+			counts[slug][name]++
+		}
+	}
+
+	var names []string
+	for _, spellings := range counts {
+		best := ""
+		for name, n := range spellings {
+			if best == "" || n > spellings[best] || n == spellings[best] && name < best {
+				best = name
+			}
+		}
+		names = append(names, best)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,117 @@
+package authors
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/subculture-collective/subcult-tv/api/internal/database"
+	"github.com/subculture-collective/subcult-tv/api/internal/dbtest"
+	"github.com/subculture-collective/subcult-tv/api/internal/models"
+)
+
+func TestSlugify(t *testing.T) {
+	for name, want := range map[string]string{
+		"SUBCULT":             "subcult",
+		"  Ada   Lovelace ":   "ada-lovelace",
+		"J. R. \"Bob\" Dobbs": "j-r-bob-dobbs",
+		"Zoë 99":              "zoe-99",
+		"José Núñez":          "jose-nunez",
+		"Straße Øre":          "strasse-ore",
+		"!!!":                 "",
+	} {
+		if got := Slugify(name); got != want {
+			t.Errorf("Slugify(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestSlugifyNonASCII(t *testing.T) {
+	slugs := map[string]string{}
+	for _, name := range []string{"李明", "李华", "Josи", "José", "Jos", "Пётр"} {
+		slug := Slugify(name)
+		if slug == "" || Slugify(slug) != slug {
+			t.Errorf("Slugify(%q) = %q, want a non-empty ASCII slug", name, slug)
+		}
+		if other, dup := slugs[slug]; dup {
+			t.Errorf("Slugify(%q) = Slugify(%q) = %q", name, other, slug)
+		}
+		slugs[slug] = name
+	}
+	if a, b := Slugify("李明"), Slugify(" 李明 "); a != b {
+		t.Errorf("Slugify is not stable: %q, %q", a, b)
+	}
+	if got := Slugify("Josи"); !strings.HasPrefix(got, "jos-") {
+		t.Errorf("Slugify(Josи) = %q, want the ASCII part kept", got)
+	}
+}
+
+func TestSplitByline(t *testing.T) {
+	got := SplitByline(" Ada  Lovelace, Grace Hopper & ada lovelace &  , SUBCULT")
+	if want := "Ada Lovelace|Grace Hopper|SUBCULT"; strings.Join(got, "|") != want {
+		t.Errorf("SplitByline = %q, want %q", got, want)
+	}
+	if got := SplitByline("李明 & 李华, José & Josи"); strings.Join(got, "|") != "李明|李华|José|Josи" {
+		t.Errorf("SplitByline(non-ASCII) = %q", got)
+	}
+	if got := SplitByline("  "); len(got) != 0 {
+		t.Errorf("SplitByline(blank) = %q", got)
+	}
+}
+
+func TestBylineRoundTrip(t *testing.T) {
+	if Byline(nil) != nil {
+		t.Error("Byline(nil) is not nil")
+	}
+	for _, names := range [][]string{{"A"}, {"A", "B"}, {"A", "B", "C"}} {
+		var refs []models.AuthorRef
+		for _, n := range names {
+			refs = append(refs, models.AuthorRef{Name: n})
+		}
+		byline := Byline(refs)
+		if got := SplitByline(*byline); strings.Join(got, "|") != strings.Join(names, "|") {
+			t.Errorf("Byline(%q) = %q, splits to %q", names, *byline, got)
+		}
+	}
+	if got := *Byline([]models.AuthorRef{{Name: "A"}, {Name: "B"}, {Name: "C"}}); got != "A, B & C" {
+		t.Errorf("Byline = %q", got)
+	}
+}
+
+func TestPreferredSpellings(t *testing.T) {
+	got := preferredSpellings([]string{"SUBCULT", "Subcult", "SUBCULT & Ada", "subcult", "ada, Zed", "???"})
+	if want := "Ada|SUBCULT|Zed"; strings.Join(got, "|") != want {
+		t.Errorf("preferredSpellings = %q, want %q", got, want)
+	}
+}
+
+func TestLinkNonASCIIBylines(t *testing.T) {
+	pool := dbtest.Pool(t)
+	ctx := context.Background()
+	if err := database.Migrate(ctx, pool); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+	var postID string
+	if err := pool.QueryRow(ctx,
+		`INSERT INTO posts (slug, title) VALUES ('hello', 'Hello') RETURNING id::text`,
+	).Scan(&postID); err != nil {
+		t.Fatalf("insert post: %v", err)
+	}
+
+	byline := "李明, José & Josи"
+	refs, err := Link(ctx, pool, postID, &byline)
+	if err != nil {
+		t.Fatalf("Link: %v", err)
+	}
+	if len(refs) != 3 {
+		t.Fatalf("Link = %+v, want three authors", refs)
+	}
+	for i, name := range []string{"李明", "José", "Josи"} {
+		if refs[i].Name != name || refs[i].Slug != Slugify(name) {
+			t.Errorf("author %d = %+v, want %q", i, refs[i], name)
+		}
+	}
+	if got := *Byline(refs); got != byline {
+		t.Errorf("Byline = %q, want %q", got, byline)
+	}
+}
@@ -3,7 +3,9 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // Config holds all application configuration.
@@ -11,10 +13,33 @@ type Config struct {
 	Port              string
 	DatabaseURL       string
 	JWTSecret         string
+	JWTKeysDir        string
+	JWTSigningKID     string
+	AccessTokenTTL    time.Duration
+	RefreshTokenTTL   time.Duration
 	CORSOrigins       []string
+	AppURL            string
+	APIURL            string // public base URL of this API, for self links
+	CookieSecure      bool
+	CookieDomain      string
+	SMTPHost          string
+	SMTPPort          string
+	SMTPUsername      string
+	SMTPPassword      string
+	SMTPFrom          string
 	UmamiURL          string
 	PatreonToken      string
 	PatreonCampaignID string
+	PostRevisionsKeep int // revisions kept per post; 0 keeps all
+
+	// OpenID Connect single sign-on; disabled when OIDCIssuer is empty.
+	OIDCIssuer       string
+	OIDCClientID     string
+	OIDCClientSecret string
+	OIDCRedirectURL  string
+	OIDCScopes       []string
+	OIDCGroupsClaim  string
+	OIDCGroupRoles   map[string]string // IdP group → local role
 }
 
 // Load reads configuration from environment variables.
@@ -29,6 +54,16 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("JWT_SECRET is required")
 	}
 
+	accessTTL, err := durationEnv("ACCESS_TOKEN_TTL", 15*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshTTL, err := durationEnv("REFRESH_TOKEN_TTL", 30*24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
@@ -45,13 +80,120 @@ func Load() (*Config, error) {
 		origins = []string{"http://localhost:5175"}
 	}
 
+	appURL := strings.TrimRight(os.Getenv("APP_URL"), "/")
+	if appURL == "" {
+		appURL = origins[0]
+	}
+
+	apiURL := strings.TrimRight(os.Getenv("API_URL"), "/")
+	if apiURL == "" {
+		apiURL = "http://localhost:" + port
+	}
+
+	// Auth cookies are Secure unless explicitly disabled for plain-HTTP dev.
+	cookieSecure := os.Getenv("COOKIE_SECURE") != "false"
+
+	smtpPort := os.Getenv("SMTP_PORT")
+	if smtpPort == "" {
+		smtpPort = "587"
+	}
+
+	smtpFrom := os.Getenv("SMTP_FROM")
+	if smtpFrom == "" {
+		smtpFrom = "SUBCULT <noreply@subcult.tv>"
+	}
+
+	revisionsKeep, err := intEnv("POST_REVISIONS_KEEP", 50)
+	if err != nil {
+		return nil, err
+	}
+
+	oidcIssuer := strings.TrimRight(os.Getenv("OIDC_ISSUER"), "/")
+	oidcClientID := os.Getenv("OIDC_CLIENT_ID")
+	oidcRedirectURL := os.Getenv("OIDC_REDIRECT_URL")
+	if oidcIssuer != "" && (oidcClientID == "" || oidcRedirectURL == "") {
+		return nil, fmt.Errorf("OIDC_CLIENT_ID and OIDC_REDIRECT_URL are required when OIDC_ISSUER is set")
+	}
+
+	// A group listed under several roles maps to the most privileged one.
+	groupRoles := map[string]string{}
+	for _, m := range []struct{ env, role string }{
+		{"OIDC_MODERATOR_GROUPS", "moderator"},
+		{"OIDC_EDITOR_GROUPS", "editor"},
+		{"OIDC_ADMIN_GROUPS", "admin"},
+	} {
+		for _, group := range listEnv(m.env) {
+			groupRoles[group] = m.role
+		}
+	}
+
 	return &Config{
 		Port:              port,
 		DatabaseURL:       dbURL,
 		JWTSecret:         jwtSecret,
+		JWTKeysDir:        os.Getenv("JWT_KEYS_DIR"),
+		JWTSigningKID:     os.Getenv("JWT_SIGNING_KID"),
+		AccessTokenTTL:    accessTTL,
+		RefreshTokenTTL:   refreshTTL,
 		CORSOrigins:       origins,
+		AppURL:            appURL,
+		APIURL:            apiURL,
+		CookieSecure:      cookieSecure,
+		CookieDomain:      os.Getenv("COOKIE_DOMAIN"),
+		SMTPHost:          os.Getenv("SMTP_HOST"),
+		SMTPPort:          smtpPort,
+		SMTPUsername:      os.Getenv("SMTP_USERNAME"),
+		SMTPPassword:      os.Getenv("SMTP_PASSWORD"),
+		SMTPFrom:          smtpFrom,
 		UmamiURL:          os.Getenv("UMAMI_URL"),
 		PatreonToken:      os.Getenv("PATREON_TOKEN"),
 		PatreonCampaignID: os.Getenv("PATREON_CAMPAIGN_ID"),
+		PostRevisionsKeep: revisionsKeep,
+		OIDCIssuer:        oidcIssuer,
+		OIDCClientID:      oidcClientID,
+		OIDCClientSecret:  os.Getenv("OIDC_CLIENT_SECRET"),
+		OIDCRedirectURL:   oidcRedirectURL,
+		OIDCScopes:        strings.Fields(os.Getenv("OIDC_SCOPES")),
+		OIDCGroupsClaim:   os.Getenv("OIDC_GROUPS_CLAIM"),
+		OIDCGroupRoles:    groupRoles,
 	}, nil
 }
+
+// durationEnv parses a Go duration (e.g. "15m", "720h") from the named
+// environment variable, falling back to def when it is unset.
+func durationEnv(key string, def time.Duration) (time.Duration, error) {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("%s must be a positive duration, got %q", key, raw)
+	}
+	return d, nil
+}
+
+// intEnv parses a non-negative integer from the named environment variable,
+// falling back to def when it is unset.
+func intEnv(key string, def int) (int, error) {
+	raw := os.Getenv(key)
+	if raw == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative integer, got %q", key, raw)
+	}
+	return n, nil
+}
+
+// listEnv splits a comma-separated environment variable, dropping blanks.
+func listEnv(key string) []string {
+	var out []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
@@ -0,0 +1,392 @@
+// Package content moves posts and projects in and out of the database: as a
+// single JSON document, for backups and moving content between instances,
+// and from the site's MDX archive and projects.json.
+package content
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/subculture-collective/subcult-tv/api/internal/authors"
+	"github.com/subculture-collective/subcult-tv/api/internal/models"
+	"github.com/subculture-collective/subcult-tv/api/internal/render"
+	"github.com/subculture-collective/subcult-tv/api/internal/revisions"
+	"github.com/subculture-collective/subcult-tv/api/internal/textdiff"
+)
+
+// FormatVersion identifies the Bundle layout.
+const FormatVersion = 1
+
+// Bundle is an export of all posts and projects.
+type Bundle struct {
+	Version    int              `json:"version"`
+	ExportedAt time.Time        `json:"exported_at"`
+	Projects   []models.Project `json:"projects"`
+	Posts      []models.Post    `json:"posts"`
+}
+
+// Options controls an Import.
+type Options struct {
+	DryRun bool // work out the changes without writing them
+}
+
+// Result counts what an import changed. Records identical to the database
+// are skipped.
+type Result struct {
+	DryRun          bool     `json:"dry_run"`
+	ProjectsCreated int      `json:"projects_created"`
+	ProjectsUpdated int      `json:"projects_updated"`
+	ProjectsSkipped int      `json:"projects_skipped"`
+	PostsCreated    int      `json:"posts_created"`
+	PostsUpdated    int      `json:"posts_updated"`
+	PostsSkipped    int      `json:"posts_skipped"`
+	PostsPlaced     int      `json:"posts_placed"` // moved into their issue
+	Changes         []Change `json:"changes"`
+}
+
+// Change is a record an import creates or updates.
+type Change struct {
+	Kind   string `json:"kind"` // "project" or "post"
+	Slug   string `json:"slug"`
+	Action string `json:"action"` // "create" or "update"
+	Diff   string `json:"diff"`   // unified diff of the imported fields
+}
+
+// Column lists scanProject and scanPost expect.
+const (
+	projectColumns = `id, slug, name, description, long_description, why_it_exists,
+	  type, status, stack, topics, repo_url, homepage,
+	  cover_pattern, cover_color, featured, sort_order, stars, last_updated,
+	  created_at, updated_at`
+	postColumns = `id, slug, title, excerpt, content, content_html, toc, word_count,
+	  tags, author, published, date::text, publish_at, published_at, created_at, updated_at`
+)
+
+func scanProject(row pgx.Row) (models.Project, error) {
+	var p models.Project
+	err := row.Scan(
+		&p.ID, &p.Slug, &p.Name, &p.Description, &p.LongDescription, &p.WhyItExists,
+		&p.Type, &p.Status, &p.Stack, &p.Topics, &p.RepoURL, &p.Homepage,
+		&p.CoverPattern, &p.CoverColor, &p.Featured, &p.SortOrder, &p.Stars, &p.LastUpdated,
+		&p.CreatedAt, &p.UpdatedAt,
+	)
+	return p, err
+}
+
+func scanPost(row pgx.Row) (models.Post, error) {
+	var p models.Post
+	err := row.Scan(
+		&p.ID, &p.Slug, &p.Title, &p.Excerpt, &p.Content, &p.ContentHTML, &p.TOC, &p.WordCount,
+		&p.Tags, &p.Author, &p.Published, &p.Date,
+		&p.PublishAt, &p.PublishedAt, &p.CreatedAt, &p.UpdatedAt,
+	)
+	return p, err
+}
+
+// Export reads every post and project.
+func Export(ctx context.Context, db *pgxpool.Pool) (*Bundle, error) {
+	b := &Bundle{Version: FormatVersion, ExportedAt: time.Now().UTC()}
+
+	rows, err := db.Query(ctx,
+		`SELECT `+projectColumns+` FROM projects ORDER BY sort_order ASC, slug ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("query projects: %w", err)
+	}
+	b.Projects, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.Project, error) {
+		return scanProject(row)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scan projects: %w", err)
+	}
+
+	rows, err = db.Query(ctx,
+		`SELECT `+postColumns+` FROM posts ORDER BY date ASC, slug ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("query posts: %w", err)
+	}
+	b.Posts, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (models.Post, error) {
+		return scanPost(row)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scan posts: %w", err)
+	}
+
+	return b, nil
+}
+
+// Import upserts the bundle's posts and projects by slug in a single
+// transaction, recording a revision for each post it changes. Records that
+// already match are skipped and content not in the bundle is left alone.
+// Posts with an issue are then placed in it; see placeInIssue. With
+// opts.DryRun the changes are reported and rolled back.
+func Import(ctx context.Context, db *pgxpool.Pool, b *Bundle, opts Options) (Result, error) {
+	res := Result{DryRun: opts.DryRun, Changes: []Change{}}
+	if b.Version != FormatVersion {
+		return res, fmt.Errorf("unsupported bundle version %d", b.Version)
+	}
+
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return res, err
+	}
+	defer tx.Rollback(ctx)
+
+	for _, p := range b.Projects {
+		if p.Stack == nil {
+			p.Stack = []string{}
+		}
+		if p.Topics == nil {
+			p.Topics = []string{}
+		}
+
+		current, err := scanProject(tx.QueryRow(ctx,
+			`SELECT `+projectColumns+` FROM projects WHERE slug = $1 FOR UPDATE`, p.Slug))
+		exists := err == nil
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return res, fmt.Errorf("import project %s: %w", p.Slug, err)
+		}
+		change, changed := diff("project", p.Slug, exists, projectText(current), projectText(p))
+		if !changed {
+			res.ProjectsSkipped++
+			continue
+		}
+		res.Changes = append(res.Changes, change)
+		if exists {
+			res.ProjectsUpdated++
+		} else {
+			res.ProjectsCreated++
+		}
+		if opts.DryRun {
+			continue
+		}
+
+		_, err = tx.Exec(ctx,
+			`INSERT INTO projects (slug, name, description, long_description, why_it_exists,
+			  type, status, stack, topics, repo_url, homepage,
+			  cover_pattern, cover_color, featured, sort_order, stars, last_updated)
+			 VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17)
+			 ON CONFLICT (slug) DO UPDATE SET
+			  name=EXCLUDED.name, description=EXCLUDED.description,
+			  long_description=EXCLUDED.long_description, why_it_exists=EXCLUDED.why_it_exists,
+			  type=EXCLUDED.type, status=EXCLUDED.status, stack=EXCLUDED.stack, topics=EXCLUDED.topics,
+			  repo_url=EXCLUDED.repo_url, homepage=EXCLUDED.homepage,
+			  cover_pattern=EXCLUDED.cover_pattern, cover_color=EXCLUDED.cover_color,
+			  featured=EXCLUDED.featured, sort_order=EXCLUDED.sort_order,
+			  stars=EXCLUDED.stars, last_updated=EXCLUDED.last_updated, updated_at=NOW()`,
+			p.Slug, p.Name, p.Description, p.LongDescription, p.WhyItExists,
+			p.Type, p.Status, p.Stack, p.Topics, p.RepoURL, p.Homepage,
+			p.CoverPattern, p.CoverColor, p.Featured, p.SortOrder, p.Stars, p.LastUpdated,
+		)
+		if err != nil {
+			return res, fmt.Errorf("import project %s: %w", p.Slug, err)
+		}
+	}
+
+	for _, p := range b.Posts {
+		if p.Tags == nil {
+			p.Tags = []string{}
+		}
+
+		current, err := scanPost(tx.QueryRow(ctx,
+			`SELECT `+postColumns+` FROM posts WHERE slug = $1 FOR UPDATE`, p.Slug))
+		exists := err == nil
+		if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			return res, fmt.Errorf("import post %s: %w", p.Slug, err)
+		}
+		change, changed := diff("post", p.Slug, exists, postText(current), postText(p))
+		if !changed {
+			res.PostsSkipped++
+			continue
+		}
+		res.Changes = append(res.Changes, change)
+		if exists {
+			res.PostsUpdated++
+		} else {
+			res.PostsCreated++
+		}
+		if opts.DryRun {
+			continue
+		}
+
+		var id string
+		err = tx.QueryRow(ctx,
+			`INSERT INTO posts (slug, title, excerpt, content, tags, author, published, date,
+			  publish_at, published_at)
+			 VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10)
+			 ON CONFLICT (slug) DO UPDATE SET
+			  title=EXCLUDED.title, excerpt=EXCLUDED.excerpt, content=EXCLUDED.content,
+			  tags=EXCLUDED.tags, author=EXCLUDED.author, published=EXCLUDED.published,
+			  date=EXCLUDED.date, publish_at=EXCLUDED.publish_at,
+			  published_at=COALESCE(posts.published_at, EXCLUDED.published_at), updated_at=NOW()
+			 RETURNING id::text`,
+			p.Slug, p.Title, p.Excerpt, p.Content, p.Tags, p.Author, p.Published, p.Date,
+			p.PublishAt, p.PublishedAt,
+		).Scan(&id)
+		if err != nil {
+			return res, fmt.Errorf("import post %s: %w", p.Slug, err)
+		}
+		// Authors are matched or created from the byline.
+		if _, err := authors.Link(ctx, tx, id, p.Author); err != nil {
+			return res, fmt.Errorf("import post %s: %w", p.Slug, err)
+		}
+		// Rendered fields in the bundle are ignored and regenerated.
+		if _, err := render.Store(ctx, tx, id, p.Content); err != nil {
+			return res, fmt.Errorf("import post %s: %w", p.Slug, err)
+		}
+		// Retention is left to the next save through the API.
+		if err := revisions.Record(ctx, tx, id, "", 0); err != nil {
+			return res, fmt.Errorf("import post %s: %w", p.Slug, err)
+		}
+	}
+
+	// Posts from a series go into the issue of the same title, after their
+	// rows exist.
+	for _, p := range b.Posts {
+		if p.Issue == nil {
+			continue
+		}
+		change, changed, err := placeInIssue(ctx, tx, p.Slug, p.Issue, opts.DryRun)
+		if err != nil {
+			return res, fmt.Errorf("place post %s: %w", p.Slug, err)
+		}
+		if changed {
+			res.Changes = append(res.Changes, change)
+			res.PostsPlaced++
+		}
+	}
+
+	if opts.DryRun {
+		return res, nil
+	}
+	return res, tx.Commit(ctx)
+}
+
+// placeInIssue puts a post at issue.Position in the issue titled
+// issue.Title, creating the issue with the next free number if there is
+// none. A post already at that position is taken out of the issue. It
+// reports the move as a change of kind "issue", or no change when the post
+// is already in place.
+func placeInIssue(ctx context.Context, tx pgx.Tx, slug string, issue *models.PostIssue, dryRun bool) (Change, bool, error) {
+	placement := func(title string, position int) string {
+		return fmt.Sprintf("issue: %s\nposition: %d\n", title, position)
+	}
+
+	var (
+		title    string
+		position int
+	)
+	err := tx.QueryRow(ctx,
+		`SELECT i.title, ip.position FROM issue_posts ip
+		 JOIN issues i ON i.id = ip.issue_id JOIN posts ON posts.id = ip.post_id
+		 WHERE posts.slug = $1`, slug,
+	).Scan(&title, &position)
+	exists := err == nil
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return Change{}, false, err
+	}
+	change, changed := diff("issue", slug, exists, placement(title, position),
+		placement(issue.Title, issue.Position))
+	if !changed || dryRun {
+		return change, changed, nil
+	}
+
+	var issueID string
+	err = tx.QueryRow(ctx,
+		`SELECT id::text FROM issues WHERE title = $1 ORDER BY number LIMIT 1`, issue.Title,
+	).Scan(&issueID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		err = tx.QueryRow(ctx,
+			`INSERT INTO issues (number, title)
+			 SELECT COALESCE(MAX(number), 0) + 1, $1 FROM issues RETURNING id::text`, issue.Title,
+		).Scan(&issueID)
+	}
+	if err != nil {
+		return Change{}, false, err
+	}
+
+	if _, err := tx.Exec(ctx,
+		`DELETE FROM issue_posts ip USING posts
+		 WHERE ip.issue_id = $1 AND ip.position = $2 AND posts.id = ip.post_id AND posts.slug <> $3`,
+		issueID, issue.Position, slug,
+	); err != nil {
+		return Change{}, false, err
+	}
+	_, err = tx.Exec(ctx,
+		`INSERT INTO issue_posts (post_id, issue_id, position)
+		 SELECT id, $1::uuid, $2 FROM posts WHERE slug = $3
+		 ON CONFLICT (post_id) DO UPDATE SET issue_id = EXCLUDED.issue_id, position = EXCLUDED.position`,
+		issueID, issue.Position, slug,
+	)
+	return change, true, err
+}
+
+// diff compares the imported fields of a record in the database (when it
+// exists) with the bundle's, and reports whether they differ.
+func diff(kind, slug string, exists bool, current, imported string) (Change, bool) {
+	c := Change{Kind: kind, Slug: slug, Action: "update"}
+	from := "database/" + kind + "s/" + slug
+	if !exists {
+		c.Action = "create"
+		from, current = "/dev/null", ""
+	}
+	c.Diff = textdiff.Unified(from, "import/"+kind+"s/"+slug, current, imported, 3)
+	return c, !exists || c.Diff != ""
+}
+
+// projectText lays out the fields an import writes to a project, one per
+// line, for comparison and diffs.
+func projectText(p models.Project) string {
+	var sb strings.Builder
+	line := func(key, value string) { fmt.Fprintf(&sb, "%s: %s\n", key, value) }
+	opt := func(key string, value *string) {
+		if value != nil && *value != "" {
+			line(key, *value)
+		}
+	}
+	line("name", p.Name)
+	line("description", p.Description)
+	opt("long_description", p.LongDescription)
+	opt("why_it_exists", p.WhyItExists)
+	line("type", p.Type)
+	line("status", p.Status)
+	line("stack", strings.Join(p.Stack, ", "))
+	line("topics", strings.Join(p.Topics, ", "))
+	opt("repo_url", p.RepoURL)
+	opt("homepage", p.Homepage)
+	line("cover_pattern", p.CoverPattern)
+	opt("cover_color", p.CoverColor)
+	line("featured", strconv.FormatBool(p.Featured))
+	line("sort_order", strconv.Itoa(p.SortOrder))
+	line("stars", strconv.Itoa(p.Stars))
+	if p.LastUpdated != nil {
+		line("last_updated", p.LastUpdated.UTC().Format(time.RFC3339))
+	}
+	return sb.String()
+}
+
+// postText lays out the fields an import writes to a post in the same
+// form as a revision. published_at is left out: it records when a post
+// went live rather than what it says.
+func postText(p models.Post) string {
+	if p.Author != nil && *p.Author == "" {
+		p.Author = nil
+	}
+	return revisions.Text(models.PostRevision{
+		Slug:      p.Slug,
+		Title:     p.Title,
+		Excerpt:   p.Excerpt,
+		Content:   p.Content,
+		Tags:      p.Tags,
+		Author:    p.Author,
+		Published: p.Published,
+		Date:      p.Date,
+		PublishAt: p.PublishAt,
+	})
+}
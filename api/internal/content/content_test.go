@@ -0,0 +1,115 @@
+package content
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/subculture-collective/subcult-tv/api/internal/database"
+	"github.com/subculture-collective/subcult-tv/api/internal/dbtest"
+	"github.com/subculture-collective/subcult-tv/api/internal/models"
+)
+
+// TestImportKeepsPublishedAt tests that re-importing a changed post keeps
+// the go-live time already recorded for it.
+func TestImportKeepsPublishedAt(t *testing.T) {
+	pool := dbtest.Pool(t)
+	ctx := context.Background()
+	if err := database.Migrate(ctx, pool); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+
+	dated := time.Date(2026, 2, 17, 0, 0, 0, 0, time.UTC)
+	post := models.Post{
+		Slug: "boot-sequence", Title: "Boot Sequence", Content: "# Boot Sequence\n",
+		Tags: []string{}, Published: true, Date: "2026-02-17", PublishedAt: &dated,
+	}
+	bundle := &Bundle{Version: FormatVersion, Posts: []models.Post{post}}
+	if _, err := Import(ctx, pool, bundle, Options{}); err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+
+	wentLive := time.Date(2026, 2, 18, 9, 30, 0, 0, time.UTC)
+	if _, err := pool.Exec(ctx, `UPDATE posts SET published_at = $1`, wentLive); err != nil {
+		t.Fatal(err)
+	}
+
+	bundle.Posts[0].Content = "# Boot Sequence\n\nRevised.\n"
+	res, err := Import(ctx, pool, bundle, Options{})
+	if err != nil {
+		t.Fatalf("re-Import: %v", err)
+	}
+	if res.PostsUpdated != 1 {
+		t.Fatalf("PostsUpdated = %d, want 1", res.PostsUpdated)
+	}
+
+	var got time.Time
+	if err := pool.QueryRow(ctx, `SELECT published_at FROM posts`).Scan(&got); err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(wentLive) {
+		t.Errorf("published_at = %v, want %v", got, wentLive)
+	}
+}
+
+// TestImportPlacesSeriesInIssues tests that series posts go into the issue
+// named after the series, in week order, and that a second import leaves
+// them in place.
+func TestImportPlacesSeriesInIssues(t *testing.T) {
+	pool := dbtest.Pool(t)
+	ctx := context.Background()
+	if err := database.Migrate(ctx, pool); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+
+	post := func(slug string, week int) models.Post {
+		return models.Post{
+			Slug: slug, Title: slug, Content: "# " + slug + "\n", Tags: []string{}, Date: "2026-02-17",
+			Issue: &models.PostIssue{Title: "Foundations", Position: week},
+		}
+	}
+	bundle := &Bundle{Version: FormatVersion, Posts: []models.Post{post("two", 2), post("one", 1)}}
+
+	res, err := Import(ctx, pool, bundle, Options{DryRun: true})
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if res.PostsPlaced != 2 {
+		t.Errorf("dry run PostsPlaced = %d, want 2", res.PostsPlaced)
+	}
+
+	if res, err = Import(ctx, pool, bundle, Options{}); err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if res.PostsPlaced != 2 {
+		t.Errorf("PostsPlaced = %d, want 2", res.PostsPlaced)
+	}
+
+	var got []string
+	rows, err := pool.Query(ctx,
+		`SELECT i.number || ' ' || i.title || ' ' || ip.position || ' ' || posts.slug
+		 FROM issue_posts ip JOIN issues i ON i.id = ip.issue_id JOIN posts ON posts.id = ip.post_id
+		 ORDER BY ip.position`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, s)
+	}
+	rows.Close()
+	if want := "1 Foundations 1 one,1 Foundations 2 two"; strings.Join(got, ",") != want {
+		t.Errorf("placements = %v, want %s", got, want)
+	}
+
+	if res, err = Import(ctx, pool, bundle, Options{}); err != nil {
+		t.Fatalf("re-Import: %v", err)
+	}
+	if res.PostsPlaced != 0 || res.PostsSkipped != 2 {
+		t.Errorf("re-Import placed %d and skipped %d, want 0 and 2", res.PostsPlaced, res.PostsSkipped)
+	}
+}
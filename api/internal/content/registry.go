@@ -0,0 +1,327 @@
+package content
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// RegistryPost is an entry of the site's post registry, src/lib/posts.ts,
+// which holds the metadata of the MDX posts in content/posts. Fields of the
+// frontend's Post type that are not listed here, such as the mdx loader,
+// are ignored.
+type RegistryPost struct {
+	Slug    string          `json:"slug"`
+	Title   string          `json:"title"`
+	Date    string          `json:"date"`
+	Excerpt string          `json:"excerpt"`
+	Tags    []string        `json:"tags"`
+	Author  string          `json:"author"`
+	Series  *RegistrySeries `json:"series"`
+}
+
+// RegistrySeries places a post in a series, which imports as the issue of
+// the same title with the week as the post's position.
+type RegistrySeries struct {
+	Name  string `json:"name"`
+	Week  int    `json:"week"`
+	Total int    `json:"total"`
+}
+
+var registryRE = regexp.MustCompile(`export\s+const\s+posts\b[^=]*=\s*`)
+
+// ParseRegistry reads the posts array of src/lib/posts.ts. The array must
+// be a literal of objects, arrays, strings, and numbers; any other value,
+// such as an arrow function, is skipped.
+func ParseRegistry(src []byte) ([]RegistryPost, error) {
+	m := registryRE.FindIndex(src)
+	if m == nil {
+		return nil, fmt.Errorf("no \"export const posts\" array")
+	}
+	p := &literalParser{src: string(src), pos: m[1]}
+	v, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := v.([]any); !ok {
+		return nil, fmt.Errorf("posts is not an array literal")
+	}
+
+	// The literal is plain data by now, so let encoding/json type it.
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var posts []RegistryPost
+	if err := json.Unmarshal(data, &posts); err != nil {
+		return nil, fmt.Errorf("posts: %w", err)
+	}
+	for i, rp := range posts {
+		if !slugRE.MatchString(rp.Slug) {
+			return nil, fmt.Errorf("post %d: invalid slug %q", i+1, rp.Slug)
+		}
+	}
+	return posts, nil
+}
+
+// literalParser reads a JavaScript value literal from src at pos.
+type literalParser struct {
+	src string
+	pos int
+}
+
+func (p *literalParser) errorf(format string, args ...any) error {
+	line := 1 + strings.Count(p.src[:p.pos], "\n")
+	return fmt.Errorf("line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+// space skips whitespace and comments.
+func (p *literalParser) space() {
+	for p.pos < len(p.src) {
+		rest := p.src[p.pos:]
+		switch {
+		case strings.HasPrefix(rest, "//"):
+			if i := strings.IndexByte(rest, '\n'); i >= 0 {
+				p.pos += i + 1
+			} else {
+				p.pos = len(p.src)
+			}
+		case strings.HasPrefix(rest, "/*"):
+			if i := strings.Index(rest[2:], "*/"); i >= 0 {
+				p.pos += i + 4
+			} else {
+				p.pos = len(p.src)
+			}
+		case strings.ContainsRune(" \t\r\n", rune(rest[0])):
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+// value reads an object, array, string, or number. Anything else, such as
+// a function or a sum of strings, is skipped up to the end of the
+// enclosing element and read as nil.
+func (p *literalParser) value() (any, error) {
+	v, err := p.literal()
+	if err != nil {
+		return nil, err
+	}
+	p.space()
+	if p.pos < len(p.src) && strings.IndexByte(",]};", p.src[p.pos]) < 0 {
+		return nil, p.skip()
+	}
+	return v, nil
+}
+
+// literal reads the value at pos, or skips it if it is not a literal.
+func (p *literalParser) literal() (any, error) {
+	p.space()
+	if p.pos >= len(p.src) {
+		return nil, p.errorf("unexpected end of file")
+	}
+	switch c := p.src[p.pos]; {
+	case c == '{':
+		return p.object()
+	case c == '[':
+		return p.array()
+	case c == '\'' || c == '"':
+		return p.string()
+	case c == '-' || c >= '0' && c <= '9':
+		start := p.pos
+		p.pos++
+		for p.pos < len(p.src) && strings.IndexByte("0123456789.eE+-_", p.src[p.pos]) >= 0 {
+			p.pos++
+		}
+		n, err := strconv.ParseFloat(strings.ReplaceAll(p.src[start:p.pos], "_", ""), 64)
+		if err != nil {
+			return nil, p.errorf("invalid number %q", p.src[start:p.pos])
+		}
+		return n, nil
+	default:
+		return nil, p.skip()
+	}
+}
+
+// skip passes over an expression, stopping at a comma, semicolon, or
+// closing bracket that is not nested in it.
+func (p *literalParser) skip() error {
+	depth := 0
+	for p.pos < len(p.src) {
+		switch c := p.src[p.pos]; c {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			if depth == 0 {
+				return nil
+			}
+			depth--
+		case ',', ';':
+			if depth == 0 {
+				return nil
+			}
+		case '\'', '"', '`':
+			if _, err := p.string(); err != nil {
+				return err
+			}
+			continue
+		}
+		p.pos++
+	}
+	return p.errorf("unexpected end of file")
+}
+
+func (p *literalParser) object() (any, error) {
+	obj := map[string]any{}
+	p.pos++ // {
+	for {
+		p.space()
+		if p.pos >= len(p.src) {
+			return nil, p.errorf("unterminated object")
+		}
+		if p.src[p.pos] == '}' {
+			p.pos++
+			return obj, nil
+		}
+
+		var key string
+		if c := p.src[p.pos]; c == '\'' || c == '"' {
+			k, err := p.string()
+			if err != nil {
+				return nil, err
+			}
+			key = k.(string)
+		} else {
+			start := p.pos
+			for p.pos < len(p.src) && isIdentByte(p.src[p.pos]) {
+				p.pos++
+			}
+			if key = p.src[start:p.pos]; key == "" {
+				return nil, p.errorf("expected a property name")
+			}
+		}
+		p.space()
+		if p.pos >= len(p.src) || p.src[p.pos] != ':' {
+			return nil, p.errorf("expected ':' after %q", key)
+		}
+		p.pos++
+
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		if v != nil {
+			obj[key] = v
+		}
+		if err := p.separator('}'); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *literalParser) array() (any, error) {
+	arr := []any{}
+	p.pos++ // [
+	for {
+		p.space()
+		if p.pos >= len(p.src) {
+			return nil, p.errorf("unterminated array")
+		}
+		if p.src[p.pos] == ']' {
+			p.pos++
+			return arr, nil
+		}
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		arr = append(arr, v)
+		if err := p.separator(']'); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// separator consumes the comma after an element, or checks that the
+// closing bracket follows.
+func (p *literalParser) separator(end byte) error {
+	p.space()
+	switch {
+	case p.pos < len(p.src) && p.src[p.pos] == ',':
+		p.pos++
+		return nil
+	case p.pos < len(p.src) && p.src[p.pos] == end:
+		return nil
+	}
+	return p.errorf("expected ',' or '%c'", end)
+}
+
+// string reads a quoted string, decoding escapes. Template literals are
+// read as written.
+func (p *literalParser) string() (any, error) {
+	quote := p.src[p.pos]
+	p.pos++
+	var sb strings.Builder
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch {
+		case c == quote:
+			p.pos++
+			return sb.String(), nil
+		case c == '\n' && quote != '`':
+			return nil, p.errorf("unterminated string")
+		case c == '\\' && p.pos+1 < len(p.src):
+			p.pos++
+			if err := p.escape(&sb); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		sb.WriteByte(c)
+		p.pos++
+	}
+	return nil, p.errorf("unterminated string")
+}
+
+// escape decodes the escape sequence after a backslash.
+func (p *literalParser) escape(sb *strings.Builder) error {
+	c := p.src[p.pos]
+	p.pos++
+	switch c {
+	case 'n':
+		sb.WriteByte('\n')
+	case 't':
+		sb.WriteByte('\t')
+	case 'r':
+		sb.WriteByte('\r')
+	case '\n':
+		// A line continuation.
+	case 'u':
+		hex := ""
+		if strings.HasPrefix(p.src[p.pos:], "{") {
+			end := strings.IndexByte(p.src[p.pos:], '}')
+			if end < 0 {
+				return p.errorf("invalid \\u escape")
+			}
+			hex, p.pos = p.src[p.pos+1:p.pos+end], p.pos+end+1
+		} else if p.pos+4 <= len(p.src) {
+			hex, p.pos = p.src[p.pos:p.pos+4], p.pos+4
+		}
+		r, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil || !utf8.ValidRune(rune(r)) {
+			return p.errorf("invalid \\u escape")
+		}
+		sb.WriteRune(rune(r))
+	default:
+		sb.WriteByte(c)
+	}
+	return nil
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c == '$' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
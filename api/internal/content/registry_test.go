@@ -0,0 +1,89 @@
+package content
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParseRegistry(t *testing.T) {
+	src := `import type { Post } from '@/types';
+
+// A comment with a [bracket] and posts = [];
+export const posts: Post[] = [
+  {
+    slug: 'first',
+    title: 'It’s "quoted"', // trailing comment
+    date: "2026-02-17",
+    excerpt:
+      'Two ' + 'parts',
+    tags: ['a', 'b',],
+    author: 'SUBCULT',
+    mdx: () => import('@content/posts/first.mdx'),
+    series: { name: 'Foundations', week: 1, total: 8 },
+  },
+  /* a block comment */
+  { slug: 'second', title: 'Second', date: '2026-02-24', tags: [] },
+];
+`
+	posts, err := ParseRegistry([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) != 2 {
+		t.Fatalf("got %d posts, want 2", len(posts))
+	}
+	first := posts[0]
+	if first.Slug != "first" || first.Title != "It’s \"quoted\"" || first.Date != "2026-02-17" {
+		t.Errorf("first = %+v", first)
+	}
+	if first.Excerpt != "" {
+		t.Errorf("Excerpt = %q, want an expression skipped", first.Excerpt)
+	}
+	if strings.Join(first.Tags, ",") != "a,b" || first.Author != "SUBCULT" {
+		t.Errorf("Tags = %v, Author = %q", first.Tags, first.Author)
+	}
+	if s := first.Series; s == nil || s.Name != "Foundations" || s.Week != 1 || s.Total != 8 {
+		t.Errorf("Series = %+v", first.Series)
+	}
+	if posts[1].Slug != "second" || posts[1].Series != nil {
+		t.Errorf("second = %+v", posts[1])
+	}
+}
+
+func TestParseRegistryErrors(t *testing.T) {
+	tests := []struct {
+		name, src, want string
+	}{
+		{"no array", "export const other = [];", "no \"export const posts\""},
+		{"not an array", "export const posts = load();", "not an array literal"},
+		{"unterminated", "export const posts = [{ slug: 'a' }", "expected ',' or ']'"},
+		{"bad slug", "export const posts = [{ slug: 'Bad Slug' }];", "invalid slug"},
+		{"bad field", "export const posts = [{ slug: 'a', tags: 'x' }];", "posts:"},
+	}
+	for _, tt := range tests {
+		_, err := ParseRegistry([]byte(tt.src))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: err = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestParseRegistrySiteFile(t *testing.T) {
+	data, err := os.ReadFile("../../../src/lib/posts.ts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	posts, err := ParseRegistry(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) == 0 {
+		t.Fatal("no posts in the registry")
+	}
+	for _, p := range posts {
+		if p.Title == "" || p.Date == "" || p.Excerpt == "" || len(p.Tags) == 0 {
+			t.Errorf("incomplete entry %+v", p)
+		}
+	}
+}
@@ -0,0 +1,398 @@
+package content
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.yaml.in/yaml/v2"
+
+	"github.com/subculture-collective/subcult-tv/api/internal/models"
+)
+
+// maxExcerpt caps an excerpt taken from a post's first paragraph.
+const maxExcerpt = 300
+
+// frontMatter is the optional YAML block that opens an MDX post.
+type frontMatter struct {
+	Title     string     `yaml:"title"`
+	Date      string     `yaml:"date"`
+	Excerpt   *string    `yaml:"excerpt"`
+	Tags      []string   `yaml:"tags"`
+	Author    string     `yaml:"author"`
+	Published *bool      `yaml:"published"`
+	PublishAt *time.Time `yaml:"publish_at"`
+}
+
+// SourceProject is an entry of the site's content/projects.json, keyed by
+// slug in the file. Its fields are those of the frontend's Project type.
+type SourceProject struct {
+	Name            string     `json:"name"`
+	Description     string     `json:"description"`
+	LongDescription string     `json:"longDescription,omitempty"`
+	WhyItExists     string     `json:"whyItExists,omitempty"`
+	Status          string     `json:"status"`
+	Type            []string   `json:"type"`
+	Stack           []string   `json:"stack"`
+	Topics          []string   `json:"topics,omitempty"`
+	URL             string     `json:"url,omitempty"`
+	RepoURL         string     `json:"repoUrl,omitempty"`
+	Homepage        string     `json:"homepage,omitempty"`
+	Stars           int        `json:"stars,omitempty"`
+	LastUpdated     *time.Time `json:"lastUpdated,omitempty"`
+	Screenshot      string     `json:"screenshot,omitempty"`
+	Featured        bool       `json:"featured"`
+	Order           int        `json:"order"`
+	CoverColor      string     `json:"coverColor,omitempty"`
+	CoverPattern    string     `json:"coverPattern,omitempty"`
+}
+
+// LoadSource reads the site's content tree into a Bundle: every *.mdx file
+// in postsDir, with metadata from the post registry in registryFile, and
+// the projects in projectsFile. Any may be empty to skip it.
+func LoadSource(postsDir, registryFile, projectsFile string) (*Bundle, error) {
+	src := source{posts: map[string][]byte{}, registry: map[string]RegistryPost{}}
+
+	if postsDir != "" {
+		paths, err := filepath.Glob(filepath.Join(postsDir, "*.mdx"))
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range paths {
+			if src.posts[path], err = os.ReadFile(path); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if registryFile != "" {
+		data, err := os.ReadFile(registryFile)
+		if err != nil {
+			return nil, err
+		}
+		entries, err := ParseRegistry(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", registryFile, err)
+		}
+		for _, rp := range entries {
+			src.registry[rp.Slug] = rp
+		}
+	}
+
+	if projectsFile != "" {
+		data, err := os.ReadFile(projectsFile)
+		if err != nil {
+			return nil, err
+		}
+		src.projectsPath, src.projects = projectsFile, data
+	}
+
+	return src.bundle()
+}
+
+// source is a content tree read into memory: MDX files by path, registry
+// entries by slug, and the projects file, if any.
+type source struct {
+	posts        map[string][]byte
+	registry     map[string]RegistryPost
+	projectsPath string
+	projects     []byte
+}
+
+// bundle parses the tree, naming each post after its file. Registry
+// entries without a file are not posts yet and are left out.
+func (s source) bundle() (*Bundle, error) {
+	b := &Bundle{Version: FormatVersion, ExportedAt: time.Now().UTC()}
+
+	for path, data := range s.posts {
+		slug := strings.TrimSuffix(filepath.Base(path), ".mdx")
+		var entry *RegistryPost
+		if rp, ok := s.registry[slug]; ok {
+			entry = &rp
+		}
+		p, err := parseMDX(slug, data, entry)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		b.Posts = append(b.Posts, p)
+	}
+	sort.Slice(b.Posts, func(i, j int) bool {
+		if b.Posts[i].Date != b.Posts[j].Date {
+			return b.Posts[i].Date < b.Posts[j].Date
+		}
+		return b.Posts[i].Slug < b.Posts[j].Slug
+	})
+
+	if s.projects != nil {
+		var err error
+		if b.Projects, err = ParseProjects(s.projects); err != nil {
+			return nil, fmt.Errorf("%s: %w", s.projectsPath, err)
+		}
+	}
+
+	return b, nil
+}
+
+var (
+	frontMatterRE = regexp.MustCompile(`(?s)\A---\r?\n(.*?)\r?\n---\r?\n`)
+	headingRE     = regexp.MustCompile(`(?m)^#[ \t]+(.+?)[ \t#]*$`)
+	slugRE        = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
+)
+
+// ParseMDX reads a post from an MDX file. Front matter may set the title,
+// date, excerpt, tags, author, published (default true), and publish_at.
+// Without them the title is the first "# " heading and the excerpt its
+// first paragraph; the date is required. The body, headings included, is
+// kept as the post's content.
+func ParseMDX(slug string, src []byte) (models.Post, error) {
+	return parseMDX(slug, src, nil)
+}
+
+// parseMDX is ParseMDX with the post's registry entry, if any, filling in
+// the date, excerpt, tags, and author that front matter leaves out. The
+// registry title is used only when the post has no heading, and its series
+// becomes the post's issue.
+func parseMDX(slug string, src []byte, entry *RegistryPost) (models.Post, error) {
+	p := models.Post{Slug: slug, Published: true, Tags: []string{}}
+	if !slugRE.MatchString(slug) {
+		return p, fmt.Errorf("invalid slug %q", slug)
+	}
+	if !utf8.Valid(src) {
+		return p, fmt.Errorf("not valid UTF-8")
+	}
+	src = bytes.TrimPrefix(src, []byte("\uFEFF"))
+
+	var fm frontMatter
+	if m := frontMatterRE.FindSubmatchIndex(src); m != nil {
+		if err := yaml.UnmarshalStrict(src[m[2]:m[3]], &fm); err != nil {
+			return p, fmt.Errorf("front matter: %w", err)
+		}
+		// One blank line separates the front matter from the body.
+		src = src[m[1]:]
+		src = bytes.TrimPrefix(bytes.TrimPrefix(src, []byte("\r")), []byte("\n"))
+	}
+	if entry != nil {
+		if fm.Date == "" {
+			fm.Date = entry.Date
+		}
+		if fm.Excerpt == nil && entry.Excerpt != "" {
+			fm.Excerpt = &entry.Excerpt
+		}
+		if fm.Tags == nil {
+			fm.Tags = entry.Tags
+		}
+		if fm.Author == "" {
+			fm.Author = entry.Author
+		}
+		if s := entry.Series; s != nil && s.Name != "" && s.Week > 0 {
+			p.Issue = &models.PostIssue{Title: s.Name, Position: s.Week}
+		}
+	}
+	p.Content = string(src)
+
+	p.Title = strings.TrimSpace(fm.Title)
+	if p.Title == "" {
+		if m := headingRE.FindStringSubmatch(stripMDX(p.Content)); m != nil {
+			p.Title = m[1]
+		} else if entry != nil && entry.Title != "" {
+			p.Title = entry.Title
+		} else {
+			return p, fmt.Errorf("no title: add a \"# \" heading or a title in front matter")
+		}
+	}
+
+	if fm.Excerpt != nil {
+		p.Excerpt = strings.TrimSpace(*fm.Excerpt)
+	} else {
+		p.Excerpt = firstParagraph(p.Content)
+	}
+
+	if fm.Date == "" {
+		return p, fmt.Errorf("no date in front matter or the post registry")
+	}
+	date, err := time.Parse(time.DateOnly, fm.Date)
+	if err != nil {
+		return p, fmt.Errorf("date %q is not YYYY-MM-DD", fm.Date)
+	}
+	p.Date = fm.Date
+
+	for _, t := range fm.Tags {
+		if t = strings.TrimSpace(t); t != "" {
+			p.Tags = append(p.Tags, t)
+		}
+	}
+	if a := strings.TrimSpace(fm.Author); a != "" {
+		p.Author = &a
+	}
+	if fm.Published != nil {
+		p.Published = *fm.Published
+	}
+	p.PublishAt = fm.PublishAt
+
+	// Posts from the archive went live on their date, or when scheduled.
+	if p.Published {
+		live := date.UTC()
+		if p.PublishAt != nil {
+			live = p.PublishAt.UTC()
+		}
+		if !live.After(time.Now()) {
+			p.PublishedAt = &live
+		}
+	}
+	return p, nil
+}
+
+// stripMDX blanks fenced code blocks so headings inside them are not
+// mistaken for the title.
+func stripMDX(content string) string {
+	lines := strings.Split(content, "\n")
+	fence := ""
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case fence != "":
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			lines[i] = ""
+		case strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~"):
+			fence = trimmed[:3]
+			lines[i] = ""
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+var inlineMarkup = strings.NewReplacer("**", "", "__", "", "*", "", "`", "")
+
+// firstParagraph returns the plain text of the first prose paragraph,
+// shortened to maxExcerpt at a word boundary.
+func firstParagraph(content string) string {
+	var para []string
+	for _, line := range strings.Split(stripMDX(content), "\n") {
+		trimmed := strings.TrimSpace(line)
+		prose := trimmed != "" && !strings.HasPrefix(trimmed, "#") &&
+			!strings.HasPrefix(trimmed, "<") && !strings.HasPrefix(trimmed, "import ") &&
+			!strings.HasPrefix(trimmed, "export ") && !strings.HasPrefix(trimmed, "---")
+		if prose {
+			para = append(para, trimmed)
+			continue
+		}
+		if len(para) > 0 {
+			break
+		}
+	}
+	text := inlineMarkup.Replace(strings.Join(para, " "))
+	if utf8.RuneCountInString(text) <= maxExcerpt {
+		return text
+	}
+	runes := []rune(text)[:maxExcerpt]
+	if i := strings.LastIndexByte(string(runes), ' '); i > 0 {
+		return string(runes)[:i] + "…"
+	}
+	return string(runes) + "…"
+}
+
+// ParseProjects reads content/projects.json. The first type is the
+// project's type and any others are added to its topics. url fills in
+// repoUrl when it points at GitHub and homepage otherwise. Screenshots have
+// no column and are ignored.
+func ParseProjects(data []byte) ([]models.Project, error) {
+	var src map[string]SourceProject
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&src); err != nil {
+		return nil, err
+	}
+
+	projects := make([]models.Project, 0, len(src))
+	for slug, sp := range src {
+		if !slugRE.MatchString(slug) {
+			return nil, fmt.Errorf("invalid slug %q", slug)
+		}
+		if strings.TrimSpace(sp.Name) == "" {
+			return nil, fmt.Errorf("project %s: name is required", slug)
+		}
+		p := models.Project{
+			Slug:         slug,
+			Name:         sp.Name,
+			Description:  sp.Description,
+			Type:         "software",
+			Status:       sp.Status,
+			Stack:        append([]string{}, sp.Stack...),
+			Topics:       []string{},
+			CoverPattern: sp.CoverPattern,
+			Featured:     sp.Featured,
+			SortOrder:    sp.Order,
+			Stars:        sp.Stars,
+			LastUpdated:  sp.LastUpdated,
+		}
+		var topics []string
+		if len(sp.Type) > 0 {
+			p.Type = sp.Type[0]
+			topics = sp.Type[1:]
+		}
+		seen := map[string]bool{}
+		for _, t := range append(topics, sp.Topics...) {
+			if !seen[t] {
+				seen[t] = true
+				p.Topics = append(p.Topics, t)
+			}
+		}
+		if p.Status == "" {
+			p.Status = "active"
+		}
+		if p.CoverPattern == "" {
+			p.CoverPattern = "circuit"
+		}
+		p.LongDescription = optional(sp.LongDescription)
+		p.WhyItExists = optional(sp.WhyItExists)
+		p.CoverColor = optional(sp.CoverColor)
+		p.RepoURL = optional(sp.RepoURL)
+		p.Homepage = optional(sp.Homepage)
+		if sp.URL != "" {
+			github, err := isGitHub(sp.URL)
+			if err != nil {
+				return nil, fmt.Errorf("project %s: %w", slug, err)
+			}
+			if github && p.RepoURL == nil {
+				p.RepoURL = &sp.URL
+			} else if !github && p.Homepage == nil {
+				p.Homepage = &sp.URL
+			}
+		}
+		projects = append(projects, p)
+	}
+
+	sort.Slice(projects, func(i, j int) bool {
+		if projects[i].SortOrder != projects[j].SortOrder {
+			return projects[i].SortOrder < projects[j].SortOrder
+		}
+		return projects[i].Slug < projects[j].Slug
+	})
+	return projects, nil
+}
+
+// isGitHub reports whether an http(s) URL points at github.com.
+func isGitHub(raw string) (bool, error) {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return false, fmt.Errorf("invalid url %q", raw)
+	}
+	return u.Host == "github.com", nil
+}
+
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
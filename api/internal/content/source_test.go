@@ -0,0 +1,181 @@
+package content
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/subculture-collective/subcult-tv/api/internal/models"
+)
+
+func TestParseMDX(t *testing.T) {
+	src := "---\ndate: \"2026-02-17\"\ntags: [foundations, mission]\nauthor: SUBCULT\n---\n\n" +
+		"# Boot Sequence\n\nThere are already *too many*\nplaces to post.\n\nSecond paragraph.\n"
+	p, err := ParseMDX("boot-sequence", []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Title != "Boot Sequence" {
+		t.Errorf("Title = %q", p.Title)
+	}
+	if p.Excerpt != "There are already too many places to post." {
+		t.Errorf("Excerpt = %q", p.Excerpt)
+	}
+	if p.Date != "2026-02-17" || strings.Join(p.Tags, ",") != "foundations,mission" {
+		t.Errorf("Date = %q, Tags = %v", p.Date, p.Tags)
+	}
+	if p.Author == nil || *p.Author != "SUBCULT" || !p.Published || p.PublishedAt == nil {
+		t.Errorf("Author = %v, Published = %v, PublishedAt = %v", p.Author, p.Published, p.PublishedAt)
+	}
+	if !strings.HasPrefix(p.Content, "# Boot Sequence\n") {
+		t.Errorf("Content = %q", p.Content)
+	}
+}
+
+func TestParseMDXFrontMatterWins(t *testing.T) {
+	src := "---\ntitle: Real Title\nexcerpt: Given.\ndate: \"2026-01-01\"\npublished: false\n---\n" +
+		"```md\n# Not a heading\n```\n\n# Heading\n\nBody\n\n---\n\nAfter a rule.\n"
+	p, err := ParseMDX("real", []byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Title != "Real Title" || p.Excerpt != "Given." || p.Published || p.PublishedAt != nil {
+		t.Errorf("post = %+v", p)
+	}
+	if !strings.Contains(p.Content, "After a rule.") {
+		t.Errorf("horizontal rule taken for front matter: %q", p.Content)
+	}
+}
+
+func TestParseMDXErrors(t *testing.T) {
+	tests := []struct {
+		name, slug, src, want string
+	}{
+		{"no date", "a", "# Title\n", "no date"},
+		{"bad date", "a", "---\ndate: \"17/02/2026\"\n---\n# Title\n", "YYYY-MM-DD"},
+		{"no title", "a", "---\ndate: \"2026-02-17\"\n---\nJust text.\n", "no title"},
+		{"heading only in code", "a", "---\ndate: \"2026-02-17\"\n---\n```\n# x\n```\n", "no title"},
+		{"unknown key", "a", "---\ndate: \"2026-02-17\"\nsubtitle: x\n---\n# T\n", "front matter"},
+		{"bad slug", "Bad Slug", "# T\n", "invalid slug"},
+	}
+	for _, tt := range tests {
+		_, err := ParseMDX(tt.slug, []byte(tt.src))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: err = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestParseProjects(t *testing.T) {
+	src := `{
+	  "b": {"name": "B", "description": "d", "status": "incubating", "type": ["media", "software"],
+	        "stack": ["Go"], "url": "https://b.example", "featured": true, "order": 2,
+	        "coverColor": "#fff", "coverPattern": "grid", "screenshot": "/b.png"},
+	  "a": {"name": "A", "description": "d", "type": [], "stack": [],
+	        "url": "https://github.com/subculture-collective/a", "order": 1}
+	}`
+	projects, err := ParseProjects([]byte(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(projects) != 2 || projects[0].Slug != "a" || projects[1].Slug != "b" {
+		t.Fatalf("projects = %+v", projects)
+	}
+	a, b := projects[0], projects[1]
+	if a.Type != "software" || a.Status != "active" || a.CoverPattern != "circuit" ||
+		a.RepoURL == nil || a.Homepage != nil {
+		t.Errorf("a = %+v", a)
+	}
+	if b.Type != "media" || strings.Join(b.Topics, ",") != "software" || b.Homepage == nil ||
+		b.CoverColor == nil || *b.CoverColor != "#fff" || !b.Featured || b.SortOrder != 2 {
+		t.Errorf("b = %+v", b)
+	}
+
+	if _, err := ParseProjects([]byte(`{"a": {"name": "A", "colour": "red"}}`)); err == nil {
+		t.Error("unknown field accepted")
+	}
+}
+
+func TestLoadSourceArchive(t *testing.T) {
+	b, err := LoadSource("../../../content/posts", "../../../src/lib/posts.ts", "../../../content/projects.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b.Posts) == 0 || len(b.Projects) == 0 {
+		t.Fatalf("loaded %d posts and %d projects", len(b.Posts), len(b.Projects))
+	}
+	for i := 1; i < len(b.Posts); i++ {
+		if b.Posts[i-1].Date > b.Posts[i].Date {
+			t.Errorf("posts out of date order: %s before %s", b.Posts[i-1].Slug, b.Posts[i].Slug)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	p, err := ParseMDX("a", []byte("---\ndate: \"2026-02-17\"\n---\n# Title\n\nBody\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := postText(p)
+
+	if c, changed := diff("post", "a", false, "", text); !changed || c.Action != "create" ||
+		!strings.HasPrefix(c.Diff, "--- /dev/null\n+++ import/posts/a\n") {
+		t.Errorf("create = %+v, %v", c, changed)
+	}
+	if _, changed := diff("post", "a", true, text, text); changed {
+		t.Error("identical post reported as changed")
+	}
+
+	edited := p
+	edited.Title = "New Title"
+	c, changed := diff("post", "a", true, text, postText(edited))
+	if !changed || c.Action != "update" || !strings.Contains(c.Diff, "-title: Title\n+title: New Title\n") {
+		t.Errorf("update = %+v, %v", c, changed)
+	}
+}
+
+func TestLoadSourceRegistry(t *testing.T) {
+	b, err := LoadSource("../../../content/posts", "../../../src/lib/posts.ts", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var p *models.Post
+	for i := range b.Posts {
+		if b.Posts[i].Slug == "boot-sequence" {
+			p = &b.Posts[i]
+		}
+	}
+	if p == nil {
+		t.Fatal("boot-sequence not loaded")
+	}
+	// The title is the heading; the registry's curly apostrophe is not used.
+	if p.Title != "Boot Sequence: Why We're Building Subculture Collective" {
+		t.Errorf("Title = %q", p.Title)
+	}
+	if !strings.HasPrefix(p.Excerpt, "There are already too many places to post. What the internet") {
+		t.Errorf("Excerpt = %q", p.Excerpt)
+	}
+	if p.Date != "2026-02-17" || strings.Join(p.Tags, ",") != "foundations,mission,infrastructure" {
+		t.Errorf("Date = %q, Tags = %v", p.Date, p.Tags)
+	}
+	if p.Issue == nil || p.Issue.Title != "Foundations" || p.Issue.Position != 1 {
+		t.Errorf("Issue = %+v", p.Issue)
+	}
+}
+
+func TestParseMDXRegistryDefaults(t *testing.T) {
+	entry := &RegistryPost{Title: "Registry", Date: "2026-01-01", Excerpt: "From the registry.",
+		Tags: []string{"r"}, Author: "Reg"}
+	p, err := parseMDX("a", []byte("---\nauthor: Front\n---\nNo heading.\n"), entry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Title != "Registry" || p.Excerpt != "From the registry." || p.Date != "2026-01-01" {
+		t.Errorf("post = %+v", p)
+	}
+	if p.Author == nil || *p.Author != "Front" || strings.Join(p.Tags, ",") != "r" {
+		t.Errorf("Author = %v, Tags = %v", p.Author, p.Tags)
+	}
+	if p.Issue != nil {
+		t.Errorf("Issue = %+v, want none without a series", p.Issue)
+	}
+}
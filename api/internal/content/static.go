@@ -0,0 +1,341 @@
+package content
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"go.yaml.in/yaml/v2"
+
+	"github.com/subculture-collective/subcult-tv/api/internal/models"
+)
+
+// Archive formats for static bundles.
+const (
+	FormatTarGz = "tar.gz"
+	FormatZip   = "zip"
+)
+
+// Paths inside a static bundle, laid out like the repository so it can be
+// unpacked over it.
+const (
+	archivePostsDir     = "content/posts"
+	archiveProjectsFile = "content/projects.json"
+)
+
+// maxArchiveFile caps each file read from a static bundle.
+const maxArchiveFile = 16 << 20
+
+// ArchiveFormat infers a static bundle's format from its file name, or
+// returns "".
+func ArchiveFormat(name string) string {
+	switch {
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return FormatTarGz
+	case strings.HasSuffix(name, ".zip"):
+		return FormatZip
+	}
+	return ""
+}
+
+// exportFrontMatter is frontMatter as written by FormatMDX: every field is
+// set so nothing falls back to the body on the way back in.
+type exportFrontMatter struct {
+	Title     string     `yaml:"title"`
+	Date      string     `yaml:"date"`
+	Excerpt   string     `yaml:"excerpt"`
+	Tags      []string   `yaml:"tags,flow"`
+	Author    string     `yaml:"author,omitempty"`
+	Published bool       `yaml:"published"`
+	PublishAt *time.Time `yaml:"publish_at,omitempty"`
+}
+
+// FormatMDX writes a post as an MDX file that ParseMDX reads back to the
+// same post.
+func FormatMDX(p models.Post) ([]byte, error) {
+	fm := exportFrontMatter{
+		Title:     p.Title,
+		Date:      p.Date,
+		Excerpt:   p.Excerpt,
+		Tags:      p.Tags,
+		Published: p.Published,
+		PublishAt: p.PublishAt,
+	}
+	if fm.Tags == nil {
+		fm.Tags = []string{}
+	}
+	if p.Author != nil {
+		fm.Author = *p.Author
+	}
+	if fm.PublishAt != nil {
+		utc := fm.PublishAt.UTC()
+		fm.PublishAt = &utc
+	}
+	head, err := yaml.Marshal(fm)
+	if err != nil {
+		return nil, fmt.Errorf("post %s: %w", p.Slug, err)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("---\n")
+	buf.Write(head)
+	buf.WriteString("---\n\n")
+	buf.WriteString(p.Content)
+	return buf.Bytes(), nil
+}
+
+// FormatProjects writes projects as content/projects.json, keyed by slug
+// in the order given. A lone URL is written as url, as the site's own file
+// does; ParseProjects reads the result back to the same projects.
+func FormatProjects(projects []models.Project) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("{")
+	for i, p := range projects {
+		sp := SourceProject{
+			Name:         p.Name,
+			Description:  p.Description,
+			Status:       p.Status,
+			Type:         []string{p.Type},
+			Stack:        p.Stack,
+			Topics:       p.Topics,
+			Stars:        p.Stars,
+			LastUpdated:  p.LastUpdated,
+			Featured:     p.Featured,
+			Order:        p.SortOrder,
+			CoverPattern: p.CoverPattern,
+		}
+		if sp.Stack == nil {
+			sp.Stack = []string{}
+		}
+		if p.LongDescription != nil {
+			sp.LongDescription = *p.LongDescription
+		}
+		if p.WhyItExists != nil {
+			sp.WhyItExists = *p.WhyItExists
+		}
+		if p.CoverColor != nil {
+			sp.CoverColor = *p.CoverColor
+		}
+		if p.RepoURL != nil {
+			sp.RepoURL = *p.RepoURL
+		}
+		if p.Homepage != nil {
+			sp.Homepage = *p.Homepage
+		}
+		if github, err := isGitHub(sp.RepoURL); sp.Homepage == "" && err == nil && github {
+			sp.URL, sp.RepoURL = sp.RepoURL, ""
+		} else if github, err := isGitHub(sp.Homepage); sp.RepoURL == "" && err == nil && !github {
+			sp.URL, sp.Homepage = sp.Homepage, ""
+		}
+
+		key, err := marshalJSON(p.Slug, "")
+		if err != nil {
+			return nil, err
+		}
+		value, err := marshalJSON(sp, "  ")
+		if err != nil {
+			return nil, fmt.Errorf("project %s: %w", p.Slug, err)
+		}
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		buf.WriteString("\n  ")
+		buf.Write(key)
+		buf.WriteString(": ")
+		buf.Write(value)
+	}
+	buf.WriteString("\n}\n")
+	return buf.Bytes(), nil
+}
+
+// marshalJSON is json.MarshalIndent without HTML escaping or the trailing
+// newline.
+func marshalJSON(v any, prefix string) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent(prefix, "  ")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// staticFile is one file of a static bundle.
+type staticFile struct {
+	name string
+	data []byte
+}
+
+// staticFiles lays a bundle out as files: one MDX file per post and the
+// projects file.
+func staticFiles(b *Bundle) ([]staticFile, error) {
+	var files []staticFile
+	for _, p := range b.Posts {
+		// The slug becomes a file name, so it must survive the trip back.
+		if !slugRE.MatchString(p.Slug) {
+			return nil, fmt.Errorf("post slug %q cannot be a file name", p.Slug)
+		}
+		data, err := FormatMDX(p)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, staticFile{path.Join(archivePostsDir, p.Slug+".mdx"), data})
+	}
+	for _, p := range b.Projects {
+		if !slugRE.MatchString(p.Slug) {
+			return nil, fmt.Errorf("project slug %q is not valid in projects.json", p.Slug)
+		}
+	}
+	data, err := FormatProjects(b.Projects)
+	if err != nil {
+		return nil, err
+	}
+	return append(files, staticFile{archiveProjectsFile, data}), nil
+}
+
+// WriteArchive writes a bundle as a tar.gz or zip of MDX posts and
+// projects.json.
+func WriteArchive(w io.Writer, format string, b *Bundle) error {
+	files, err := staticFiles(b)
+	if err != nil {
+		return err
+	}
+	modified := b.ExportedAt
+	if modified.IsZero() {
+		modified = time.Now()
+	}
+
+	switch format {
+	case FormatTarGz:
+		gz := gzip.NewWriter(w)
+		tw := tar.NewWriter(gz)
+		for _, f := range files {
+			hdr := &tar.Header{
+				Name:    f.name,
+				Mode:    0o644,
+				Size:    int64(len(f.data)),
+				ModTime: modified,
+				Format:  tar.FormatPAX,
+			}
+			if err := tw.WriteHeader(hdr); err != nil {
+				return err
+			}
+			if _, err := tw.Write(f.data); err != nil {
+				return err
+			}
+		}
+		if err := tw.Close(); err != nil {
+			return err
+		}
+		return gz.Close()
+
+	case FormatZip:
+		zw := zip.NewWriter(w)
+		for _, f := range files {
+			fw, err := zw.CreateHeader(&zip.FileHeader{
+				Name:     f.name,
+				Method:   zip.Deflate,
+				Modified: modified,
+			})
+			if err != nil {
+				return err
+			}
+			if _, err := fw.Write(f.data); err != nil {
+				return err
+			}
+		}
+		return zw.Close()
+	}
+	return fmt.Errorf("unknown archive format %q", format)
+}
+
+// ReadArchive reads a static bundle written by WriteArchive, or any tar.gz
+// or zip with the same layout. Other files are ignored.
+func ReadArchive(r io.Reader, format string) (*Bundle, error) {
+	src := source{posts: map[string][]byte{}}
+	add := func(name string, rc io.Reader) error {
+		name = path.Clean(name)
+		isPost := path.Dir(name) == archivePostsDir && path.Ext(name) == ".mdx"
+		if !isPost && name != archiveProjectsFile {
+			return nil
+		}
+		data, err := io.ReadAll(io.LimitReader(rc, maxArchiveFile+1))
+		if err != nil {
+			return err
+		}
+		if len(data) > maxArchiveFile {
+			return fmt.Errorf("%s: larger than %d bytes", name, maxArchiveFile)
+		}
+		if isPost {
+			src.posts[name] = data
+		} else {
+			src.projectsPath, src.projects = name, data
+		}
+		return nil
+	}
+
+	switch format {
+	case FormatTarGz:
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return nil, err
+		}
+		tr := tar.NewReader(gz)
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			if hdr.Typeflag != tar.TypeReg {
+				continue
+			}
+			if err := add(hdr.Name, tr); err != nil {
+				return nil, err
+			}
+		}
+
+	case FormatZip:
+		// zip needs random access, so the archive is read into memory.
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range zr.File {
+			if f.FileInfo().IsDir() {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return nil, err
+			}
+			err = add(f.Name, rc)
+			rc.Close()
+			if err != nil {
+				return nil, err
+			}
+		}
+
+	default:
+		return nil, fmt.Errorf("unknown archive format %q", format)
+	}
+
+	if len(src.posts) == 0 && src.projects == nil {
+		return nil, fmt.Errorf("no %s/*.mdx or %s in archive", archivePostsDir, archiveProjectsFile)
+	}
+	return src.bundle()
+}
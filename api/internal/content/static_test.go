@@ -0,0 +1,149 @@
+package content
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/subculture-collective/subcult-tv/api/internal/models"
+)
+
+func ptr[T any](v T) *T { return &v }
+
+func sampleBundle() *Bundle {
+	publishAt := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	updated := time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)
+	return &Bundle{
+		Version: FormatVersion,
+		Posts: []models.Post{
+			{
+				Slug: "plain", Title: "Plain: a \"quoted\" title", Excerpt: "", Date: "2026-02-17",
+				Tags: []string{"a", "b c"}, Author: ptr("SUBCULT"), Published: true,
+				Content: "# Heading\n\nBody with --- inside.\n",
+			},
+			{
+				Slug: "draft", Title: "Draft", Excerpt: "Teaser.", Date: "2030-01-02",
+				Tags: []string{}, Published: true, PublishAt: &publishAt,
+				Content: "\nStarts with a blank line and has no heading",
+			},
+		},
+		Projects: []models.Project{
+			{
+				Slug: "site", Name: "site", Description: "The <hub> & more", Type: "software",
+				Status: "active", Stack: []string{"Go"}, Topics: []string{"media"},
+				Homepage: ptr("https://subcult.tv"), CoverPattern: "circuit", CoverColor: ptr("#ff3333"),
+				Featured: true, SortOrder: 0,
+			},
+			{
+				Slug: "tool", Name: "Tool", Type: "tools", Status: "incubating",
+				Stack: []string{}, Topics: []string{},
+				RepoURL: ptr("https://github.com/subculture-collective/tool"), Homepage: ptr("https://tool.example"),
+				LongDescription: ptr("Long."), CoverPattern: "grid", SortOrder: 1, Stars: 12, LastUpdated: &updated,
+			},
+		},
+	}
+}
+
+// assertSame checks that two bundles carry the same imported fields.
+func assertSame(t *testing.T, want, got *Bundle) {
+	t.Helper()
+	if len(got.Posts) != len(want.Posts) || len(got.Projects) != len(want.Projects) {
+		t.Fatalf("got %d posts and %d projects, want %d and %d",
+			len(got.Posts), len(got.Projects), len(want.Posts), len(want.Projects))
+	}
+	posts := map[string]models.Post{}
+	for _, p := range got.Posts {
+		posts[p.Slug] = p
+	}
+	for _, p := range want.Posts {
+		if _, changed := diff("post", p.Slug, true, postText(p), postText(posts[p.Slug])); changed {
+			t.Errorf("post %s changed:\n%s", p.Slug, textDiff(postText(p), postText(posts[p.Slug])))
+		}
+	}
+	for i, p := range want.Projects {
+		if _, changed := diff("project", p.Slug, true, projectText(p), projectText(got.Projects[i])); changed {
+			t.Errorf("project %s changed:\n%s", p.Slug, textDiff(projectText(p), projectText(got.Projects[i])))
+		}
+	}
+}
+
+func textDiff(a, b string) string {
+	c, _ := diff("x", "x", true, a, b)
+	return c.Diff
+}
+
+func TestArchiveRoundTrip(t *testing.T) {
+	for _, format := range []string{FormatTarGz, FormatZip} {
+		t.Run(format, func(t *testing.T) {
+			want := sampleBundle()
+			var buf bytes.Buffer
+			if err := WriteArchive(&buf, format, want); err != nil {
+				t.Fatal(err)
+			}
+			got, err := ReadArchive(&buf, format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertSame(t, want, got)
+		})
+	}
+}
+
+func TestArchiveRoundTripsSiteContent(t *testing.T) {
+	want, err := LoadSource("../../../content/posts", "../../../src/lib/posts.ts", "../../../content/projects.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := WriteArchive(&buf, FormatTarGz, want); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ReadArchive(&buf, FormatTarGz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSame(t, want, got)
+}
+
+func TestFormatProjectsShape(t *testing.T) {
+	out, err := FormatProjects(sampleBundle().Projects)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := string(out)
+	for _, want := range []string{
+		"{\n  \"site\": {\n    \"name\": \"site\",",
+		`"description": "The <hub> & more"`,
+		`"url": "https://subcult.tv"`,
+		`"repoUrl": "https://github.com/subculture-collective/tool"`,
+		`"homepage": "https://tool.example"`,
+		`"type": [` + "\n" + `      "tools"`,
+		`"coverColor": "#ff3333"`,
+	} {
+		if !strings.Contains(s, want) {
+			t.Errorf("projects.json missing %q:\n%s", want, s)
+		}
+	}
+	if strings.Index(s, `"site"`) > strings.Index(s, `"tool"`) {
+		t.Error("projects not in the given order")
+	}
+}
+
+func TestExportRejectsUnsafeSlug(t *testing.T) {
+	b := sampleBundle()
+	b.Posts[0].Slug = "../escape"
+	if err := WriteArchive(&bytes.Buffer{}, FormatZip, b); err == nil {
+		t.Error("slug with a path accepted")
+	}
+}
+
+func TestArchiveFormat(t *testing.T) {
+	for name, want := range map[string]string{
+		"site.tar.gz": FormatTarGz, "site.tgz": FormatTarGz, "site.zip": FormatZip, "site.json": "",
+	} {
+		if got := ArchiveFormat(name); got != want {
+			t.Errorf("ArchiveFormat(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -3,10 +3,13 @@ package database
 import (
 	"context"
 	"embed"
+	"errors"
 	"fmt"
+	"io/fs"
 	"log/slog"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -14,9 +17,14 @@ import (
 //go:embed migrations/*.sql
 var migrationsFS embed.FS
 
-// Migrate runs all pending SQL migrations in order.
-func Migrate(ctx context.Context, pool *pgxpool.Pool) error {
-	// Create the migrations tracking table
+// MigrationStatus describes one migration and whether it has been applied.
+type MigrationStatus struct {
+	Version string
+	Applied *time.Time
+}
+
+// ensureMigrationsTable creates the migrations tracking table.
+func ensureMigrationsTable(ctx context.Context, pool *pgxpool.Pool) error {
 	_, err := pool.Exec(ctx, `
 		CREATE TABLE IF NOT EXISTS schema_migrations (
 			version  TEXT PRIMARY KEY,
@@ -26,25 +34,39 @@ func Migrate(ctx context.Context, pool *pgxpool.Pool) error {
 	if err != nil {
 		return fmt.Errorf("create migrations table: %w", err)
 	}
+	return nil
+}
 
-	// Read all migration files
+// migrationVersions returns the version of every embedded *.up.sql file,
+// sorted.
+func migrationVersions() ([]string, error) {
 	entries, err := migrationsFS.ReadDir("migrations")
 	if err != nil {
-		return fmt.Errorf("read migrations dir: %w", err)
+		return nil, fmt.Errorf("read migrations dir: %w", err)
 	}
 
-	// Filter to *.up.sql and sort
-	var upFiles []string
+	var versions []string
 	for _, e := range entries {
 		if strings.HasSuffix(e.Name(), ".up.sql") {
-			upFiles = append(upFiles, e.Name())
+			versions = append(versions, strings.TrimSuffix(e.Name(), ".up.sql"))
 		}
 	}
-	sort.Strings(upFiles)
+	sort.Strings(versions)
+	return versions, nil
+}
+
+// Migrate runs all pending SQL migrations in order.
+func Migrate(ctx context.Context, pool *pgxpool.Pool) error {
+	if err := ensureMigrationsTable(ctx, pool); err != nil {
+		return err
+	}
 
-	for _, f := range upFiles {
-		version := strings.TrimSuffix(f, ".up.sql")
+	versions, err := migrationVersions()
+	if err != nil {
+		return err
+	}
 
+	for _, version := range versions {
 		// Check if already applied
 		var exists bool
 		err := pool.QueryRow(ctx, `SELECT EXISTS(SELECT 1 FROM schema_migrations WHERE version=$1)`, version).Scan(&exists)
@@ -55,33 +77,135 @@ func Migrate(ctx context.Context, pool *pgxpool.Pool) error {
 			continue
 		}
 
-		// Read and execute
-		data, err := migrationsFS.ReadFile("migrations/" + f)
-		if err != nil {
-			return fmt.Errorf("read migration %s: %w", f, err)
+		if err := runMigration(ctx, pool, version, "up",
+			`INSERT INTO schema_migrations (version) VALUES ($1)`); err != nil {
+			return err
 		}
+		slog.Info("applied migration", "version", version)
+	}
 
-		tx, err := pool.Begin(ctx)
-		if err != nil {
-			return fmt.Errorf("begin tx for %s: %w", version, err)
+	return nil
+}
+
+// MigrateDown reverts the most recently applied steps migrations, newest
+// first, and returns the versions it reverted.
+func MigrateDown(ctx context.Context, pool *pgxpool.Pool, steps int) ([]string, error) {
+	if err := ensureMigrationsTable(ctx, pool); err != nil {
+		return nil, err
+	}
+
+	rows, err := pool.Query(ctx,
+		`SELECT version FROM schema_migrations ORDER BY version DESC LIMIT $1`, steps,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("list applied migrations: %w", err)
+	}
+	var applied []string
+	for rows.Next() {
+		var v string
+		if err := rows.Scan(&v); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		applied = append(applied, v)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var reverted []string
+	for _, version := range applied {
+		if err := runMigration(ctx, pool, version, "down",
+			`DELETE FROM schema_migrations WHERE version = $1`); err != nil {
+			return reverted, err
 		}
+		slog.Info("reverted migration", "version", version)
+		reverted = append(reverted, version)
+	}
+	return reverted, nil
+}
+
+// Status lists every known migration with the time it was applied, if it
+// was. Applied versions missing from this binary are included too.
+func Status(ctx context.Context, pool *pgxpool.Pool) ([]MigrationStatus, error) {
+	if err := ensureMigrationsTable(ctx, pool); err != nil {
+		return nil, err
+	}
+
+	versions, err := migrationVersions()
+	if err != nil {
+		return nil, err
+	}
 
-		if _, err := tx.Exec(ctx, string(data)); err != nil {
-			_ = tx.Rollback(ctx)
-			return fmt.Errorf("exec migration %s: %w", version, err)
+	applied := map[string]time.Time{}
+	rows, err := pool.Query(ctx, `SELECT version, applied FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("list applied migrations: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var v string
+		var at time.Time
+		if err := rows.Scan(&v, &at); err != nil {
+			return nil, err
 		}
+		applied[v] = at
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
-		if _, err := tx.Exec(ctx, `INSERT INTO schema_migrations (version) VALUES ($1)`, version); err != nil {
-			_ = tx.Rollback(ctx)
-			return fmt.Errorf("record migration %s: %w", version, err)
+	known := map[string]bool{}
+	for _, v := range versions {
+		known[v] = true
+	}
+	for v := range applied {
+		if !known[v] {
+			versions = append(versions, v)
 		}
+	}
+	sort.Strings(versions)
 
-		if err := tx.Commit(ctx); err != nil {
-			return fmt.Errorf("commit migration %s: %w", version, err)
+	status := make([]MigrationStatus, 0, len(versions))
+	for _, v := range versions {
+		s := MigrationStatus{Version: v}
+		if at, ok := applied[v]; ok {
+			s.Applied = &at
 		}
+		status = append(status, s)
+	}
+	return status, nil
+}
 
-		slog.Info("applied migration", "version", version)
+// runMigration executes version's up or down script and the bookkeeping
+// statement in one transaction.
+func runMigration(ctx context.Context, pool *pgxpool.Pool, version, direction, record string) error {
+	data, err := migrationsFS.ReadFile("migrations/" + version + "." + direction + ".sql")
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("migration %s has no %s script", version, direction)
+	}
+	if err != nil {
+		return fmt.Errorf("read migration %s: %w", version, err)
 	}
 
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin tx for %s: %w", version, err)
+	}
+
+	if _, err := tx.Exec(ctx, string(data)); err != nil {
+		_ = tx.Rollback(ctx)
+		return fmt.Errorf("exec migration %s %s: %w", version, direction, err)
+	}
+
+	if _, err := tx.Exec(ctx, record, version); err != nil {
+		_ = tx.Rollback(ctx)
+		return fmt.Errorf("record migration %s: %w", version, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit migration %s: %w", version, err)
+	}
 	return nil
 }
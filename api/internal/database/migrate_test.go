@@ -0,0 +1,115 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/subculture-collective/subcult-tv/api/internal/dbtest"
+)
+
+// TestMigrationsHaveDownScripts tests that every migration can be reverted.
+func TestMigrationsHaveDownScripts(t *testing.T) {
+	versions, err := migrationVersions()
+	if err != nil {
+		t.Fatalf("migrationVersions: %v", err)
+	}
+	if len(versions) == 0 {
+		t.Fatal("no migrations found")
+	}
+	for _, v := range versions {
+		if _, err := migrationsFS.ReadFile("migrations/" + v + ".down.sql"); err != nil {
+			t.Errorf("migration %s has no down script", v)
+		}
+	}
+}
+
+// TestMigrationVersionsSorted tests that versions are numbered and ordered.
+func TestMigrationVersionsSorted(t *testing.T) {
+	versions, err := migrationVersions()
+	if err != nil {
+		t.Fatalf("migrationVersions: %v", err)
+	}
+	seen := map[string]bool{}
+	for i, v := range versions {
+		prefix, _, ok := strings.Cut(v, "_")
+		if !ok || len(prefix) != 3 {
+			t.Errorf("migration %s should start with a three-digit number", v)
+		}
+		if seen[prefix] {
+			t.Errorf("duplicate migration number %s", prefix)
+		}
+		seen[prefix] = true
+		if i > 0 && versions[i-1] >= v {
+			t.Errorf("migrations out of order: %s before %s", versions[i-1], v)
+		}
+	}
+}
+
+// TestDownScriptsKeepMigrationsTable tests that no down script drops the
+// table MigrateDown records its progress in.
+func TestDownScriptsKeepMigrationsTable(t *testing.T) {
+	versions, err := migrationVersions()
+	if err != nil {
+		t.Fatalf("migrationVersions: %v", err)
+	}
+	for _, v := range versions {
+		data, err := migrationsFS.ReadFile("migrations/" + v + ".down.sql")
+		if err != nil {
+			continue
+		}
+		if strings.Contains(strings.ToLower(string(data)), "schema_migrations") {
+			t.Errorf("migration %s down script touches schema_migrations", v)
+		}
+	}
+}
+
+// TestMigrateDownToZero tests reverting every migration and applying them
+// again.
+func TestMigrateDownToZero(t *testing.T) {
+	pool := dbtest.Pool(t)
+	ctx := context.Background()
+
+	versions, err := migrationVersions()
+	if err != nil {
+		t.Fatalf("migrationVersions: %v", err)
+	}
+	if err := Migrate(ctx, pool); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+
+	reverted, err := MigrateDown(ctx, pool, len(versions)+1)
+	if err != nil {
+		t.Fatalf("MigrateDown after %v: %v", reverted, err)
+	}
+	if len(reverted) != len(versions) {
+		t.Fatalf("reverted %d migrations, expected %d", len(reverted), len(versions))
+	}
+	for i, v := range reverted {
+		if expected := versions[len(versions)-1-i]; v != expected {
+			t.Errorf("reverted[%d] = %s, expected %s", i, v, expected)
+		}
+	}
+
+	var tables []string
+	rows, err := pool.Query(ctx,
+		`SELECT table_name FROM information_schema.tables WHERE table_schema = current_schema()`)
+	if err != nil {
+		t.Fatalf("list tables: %v", err)
+	}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			t.Fatal(err)
+		}
+		tables = append(tables, name)
+	}
+	rows.Close()
+	if len(tables) != 1 || tables[0] != "schema_migrations" {
+		t.Errorf("tables left = %v, expected only schema_migrations", tables)
+	}
+
+	if err := Migrate(ctx, pool); err != nil {
+		t.Fatalf("Migrate again: %v", err)
+	}
+}
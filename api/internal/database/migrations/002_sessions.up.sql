@@ -0,0 +1,18 @@
+-- ── Sessions (refresh tokens) ───────────────────────────────
+-- One row per login. Access tokens carry the session id in their
+-- `sid` claim, so revoking the row invalidates them immediately.
+CREATE TABLE IF NOT EXISTS sessions (
+    id                  UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id             UUID         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    refresh_token_hash  VARCHAR(64)  UNIQUE NOT NULL,
+    previous_token_hash VARCHAR(64),
+    user_agent          VARCHAR(500) NOT NULL DEFAULT '',
+    ip                  VARCHAR(100) NOT NULL DEFAULT '',
+    created_at          TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    last_used_at        TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    expires_at          TIMESTAMPTZ  NOT NULL,
+    revoked_at          TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id       ON sessions (user_id);
+CREATE INDEX IF NOT EXISTS idx_sessions_previous_hash ON sessions (previous_token_hash);
@@ -0,0 +1,8 @@
+-- ── User roles ───────────────────────────────────────────────
+-- Restrict users.role to the roles enforced by the API. New users
+-- default to the least-privileged content role instead of admin.
+UPDATE users SET role = 'admin' WHERE role NOT IN ('admin', 'editor', 'moderator');
+
+ALTER TABLE users ALTER COLUMN role SET DEFAULT 'editor';
+ALTER TABLE users ADD CONSTRAINT users_role_check
+    CHECK (role IN ('admin', 'editor', 'moderator'));
@@ -0,0 +1,3 @@
+-- ── User management ──────────────────────────────────────────
+-- Disabled users keep their row (and authorship) but cannot log in.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS disabled_at TIMESTAMPTZ;
@@ -0,0 +1,24 @@
+-- ── Two-factor authentication (TOTP) ────────────────────────
+-- totp_secret is set during enrollment; 2FA is active once
+-- totp_enabled_at is set. totp_last_step blocks code replay.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_secret     VARCHAR(64);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_enabled_at TIMESTAMPTZ;
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_last_step  BIGINT NOT NULL DEFAULT 0;
+
+-- ── Recovery codes (single use, SHA-256 hashed) ─────────────
+CREATE TABLE IF NOT EXISTS recovery_codes (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id    UUID        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    code_hash  VARCHAR(64) NOT NULL,
+    used_at    TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_recovery_codes_user_id ON recovery_codes (user_id);
+
+-- ── Runtime settings (admin-editable) ───────────────────────
+CREATE TABLE IF NOT EXISTS settings (
+    key        VARCHAR(100) PRIMARY KEY,
+    value      JSONB        NOT NULL,
+    updated_at TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
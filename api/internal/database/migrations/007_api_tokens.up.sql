@@ -0,0 +1,17 @@
+-- ── Personal access tokens ──────────────────────────────────
+-- Long-lived credentials for automation. Only a SHA-256 hash is stored;
+-- token_prefix is kept so owners can tell their tokens apart.
+CREATE TABLE IF NOT EXISTS api_tokens (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id      UUID         NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    name         VARCHAR(100) NOT NULL,
+    token_hash   VARCHAR(64)  UNIQUE NOT NULL,
+    token_prefix VARCHAR(20)  NOT NULL,
+    scopes       TEXT[]       NOT NULL DEFAULT '{}',
+    last_used_at TIMESTAMPTZ,
+    expires_at   TIMESTAMPTZ  NOT NULL,
+    revoked_at   TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_tokens_user_id ON api_tokens (user_id);
@@ -0,0 +1,9 @@
+-- ── Login throttling ────────────────────────────────────────
+-- Failed logins are tracked per (lower-cased) username, whether or not
+-- the account exists, so lockouts never reveal which usernames are real.
+CREATE TABLE IF NOT EXISTS login_failures (
+    username       VARCHAR(50) PRIMARY KEY,
+    failed_count   INTEGER     NOT NULL DEFAULT 0,
+    last_failed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    locked_until   TIMESTAMPTZ
+);
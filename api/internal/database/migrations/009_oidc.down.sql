@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS oidc_handoffs;
+DROP TABLE IF EXISTS oidc_logins;
+ALTER TABLE users DROP COLUMN IF EXISTS oidc_subject;
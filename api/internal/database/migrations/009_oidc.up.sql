@@ -0,0 +1,20 @@
+-- ── OpenID Connect ──────────────────────────────────────────
+-- Users are linked to the identity provider by its subject claim.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS oidc_subject VARCHAR(255) UNIQUE;
+
+-- In-flight authorization requests, keyed by a hash of the state value.
+CREATE TABLE IF NOT EXISTS oidc_logins (
+    state_hash    VARCHAR(64) PRIMARY KEY,
+    nonce         VARCHAR(64) NOT NULL,
+    code_verifier VARCHAR(128) NOT NULL,
+    expires_at    TIMESTAMPTZ NOT NULL,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- One-time codes handed to the frontend after a successful callback and
+-- exchanged for a session.
+CREATE TABLE IF NOT EXISTS oidc_handoffs (
+    code_hash  VARCHAR(64) PRIMARY KEY,
+    user_id    UUID        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    expires_at TIMESTAMPTZ NOT NULL
+);
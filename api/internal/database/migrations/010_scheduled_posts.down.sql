@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_posts_pending_publish;
+ALTER TABLE posts DROP COLUMN IF EXISTS published_at;
+ALTER TABLE posts DROP COLUMN IF EXISTS publish_at;
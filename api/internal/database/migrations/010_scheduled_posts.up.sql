@@ -0,0 +1,11 @@
+-- ── Scheduled publishing ────────────────────────────────────
+-- A published post goes live at publish_at (immediately when NULL).
+-- published_at records when it actually went live; the scheduler fills it
+-- in for posts whose publish_at has passed.
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS publish_at   TIMESTAMPTZ;
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS published_at TIMESTAMPTZ;
+
+UPDATE posts SET published_at = created_at WHERE published AND published_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_posts_pending_publish ON posts (publish_at)
+    WHERE published AND published_at IS NULL;
@@ -0,0 +1,26 @@
+-- ── Post revisions ──────────────────────────────────────────
+-- A snapshot of a post after every save, numbered per post. Retention is
+-- applied by the API when a revision is written.
+CREATE TABLE IF NOT EXISTS post_revisions (
+    post_id    UUID         NOT NULL REFERENCES posts (id) ON DELETE CASCADE,
+    number     INTEGER      NOT NULL,
+    slug       VARCHAR(200) NOT NULL,
+    title      VARCHAR(300) NOT NULL,
+    excerpt    TEXT         NOT NULL DEFAULT '',
+    content    TEXT         NOT NULL DEFAULT '',
+    tags       TEXT[]       NOT NULL DEFAULT '{}',
+    author     VARCHAR(100),
+    published  BOOLEAN      NOT NULL,
+    date       DATE         NOT NULL,
+    publish_at TIMESTAMPTZ,
+    editor_id  UUID         REFERENCES users (id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (post_id, number)
+);
+
+-- Existing posts start their history at their current state.
+INSERT INTO post_revisions (post_id, number, slug, title, excerpt, content, tags,
+    author, published, date, publish_at, created_at)
+SELECT id, 1, slug, title, excerpt, content, tags, author, published, date, publish_at, updated_at
+FROM posts
+ON CONFLICT DO NOTHING;
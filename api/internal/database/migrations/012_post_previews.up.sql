@@ -0,0 +1,15 @@
+-- ── Post preview links ──────────────────────────────────────
+-- Each row backs a signed preview token for one post. The token carries the
+-- row id, so deleting or revoking the row invalidates it before it expires.
+CREATE TABLE IF NOT EXISTS post_previews (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    post_id      UUID         NOT NULL REFERENCES posts (id) ON DELETE CASCADE,
+    label        VARCHAR(100) NOT NULL DEFAULT '',
+    created_by   UUID         REFERENCES users (id) ON DELETE SET NULL,
+    expires_at   TIMESTAMPTZ  NOT NULL,
+    revoked_at   TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_post_previews_post_id ON post_previews (post_id);
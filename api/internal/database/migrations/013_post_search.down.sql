@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_posts_search;
+DROP TRIGGER IF EXISTS posts_search_vector_update ON posts;
+DROP FUNCTION IF EXISTS posts_search_vector();
+ALTER TABLE posts DROP COLUMN IF EXISTS search_vector;
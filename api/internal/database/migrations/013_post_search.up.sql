@@ -0,0 +1,26 @@
+-- ── Full-text search ────────────────────────────────────────
+-- search_vector weights the title highest, then tags, excerpt, and body.
+-- It is maintained by a trigger because array_to_string is not immutable
+-- and so cannot back a generated column.
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS search_vector tsvector;
+
+CREATE OR REPLACE FUNCTION posts_search_vector() RETURNS trigger AS $$
+BEGIN
+    NEW.search_vector :=
+        setweight(to_tsvector('english', coalesce(NEW.title, '')), 'A') ||
+        setweight(to_tsvector('english', array_to_string(NEW.tags, ' ')), 'B') ||
+        setweight(to_tsvector('english', coalesce(NEW.excerpt, '')), 'C') ||
+        setweight(to_tsvector('english', coalesce(NEW.content, '')), 'D');
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS posts_search_vector_update ON posts;
+CREATE TRIGGER posts_search_vector_update
+    BEFORE INSERT OR UPDATE OF title, tags, excerpt, content ON posts
+    FOR EACH ROW EXECUTE FUNCTION posts_search_vector();
+
+-- Fire the trigger once for existing rows.
+UPDATE posts SET title = title;
+
+CREATE INDEX IF NOT EXISTS idx_posts_search ON posts USING GIN (search_vector);
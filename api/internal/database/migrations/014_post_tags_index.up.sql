@@ -0,0 +1,3 @@
+-- ── Tag filtering ───────────────────────────────────────────
+-- Serves tags && / @> filters on the post list.
+CREATE INDEX IF NOT EXISTS idx_posts_tags ON posts USING GIN (tags);
@@ -0,0 +1,4 @@
+ALTER TABLE posts DROP COLUMN IF EXISTS render_version;
+ALTER TABLE posts DROP COLUMN IF EXISTS word_count;
+ALTER TABLE posts DROP COLUMN IF EXISTS toc;
+ALTER TABLE posts DROP COLUMN IF EXISTS content_html;
@@ -0,0 +1,8 @@
+-- ── Rendered content ────────────────────────────────────────
+-- Sanitized HTML, table of contents, and word count derived from content
+-- by the API on every save. render_version = 0 marks rows the API has not
+-- rendered yet; it fills them in at startup.
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS content_html   TEXT    NOT NULL DEFAULT '';
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS toc            JSONB   NOT NULL DEFAULT '[]';
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS word_count     INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS render_version INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,6 @@
+DROP TRIGGER IF EXISTS projects_slug_history_update ON projects;
+DROP FUNCTION IF EXISTS projects_slug_history();
+DROP TRIGGER IF EXISTS posts_slug_history_update ON posts;
+DROP FUNCTION IF EXISTS posts_slug_history();
+DROP TABLE IF EXISTS project_slug_history;
+DROP TABLE IF EXISTS post_slug_history;
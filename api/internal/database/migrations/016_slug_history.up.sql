@@ -0,0 +1,59 @@
+-- ── Slug history ────────────────────────────────────────────
+-- Slugs a post or project was renamed from, so old links can be
+-- redirected to the current one. An old slug stays with its record: it
+-- cannot be taken by another post or project, only by the same one, which
+-- makes it current again. Triggers keep the history so every write path,
+-- content import included, follows the same rules.
+CREATE TABLE IF NOT EXISTS post_slug_history (
+    slug       TEXT PRIMARY KEY,
+    post_id    UUID NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_post_slug_history_post ON post_slug_history(post_id);
+
+CREATE TABLE IF NOT EXISTS project_slug_history (
+    slug       TEXT PRIMARY KEY,
+    project_id UUID NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_project_slug_history_project ON project_slug_history(project_id);
+
+-- A conflict is raised as a unique violation, like a clash with a current
+-- slug.
+CREATE OR REPLACE FUNCTION posts_slug_history() RETURNS trigger AS $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM post_slug_history WHERE slug = NEW.slug AND post_id <> NEW.id) THEN
+        RAISE EXCEPTION 'slug "%" redirects to another post', NEW.slug
+            USING ERRCODE = 'unique_violation', CONSTRAINT = 'post_slug_history_pkey';
+    END IF;
+    DELETE FROM post_slug_history WHERE slug = NEW.slug;
+    IF TG_OP = 'UPDATE' AND OLD.slug <> NEW.slug THEN
+        INSERT INTO post_slug_history (slug, post_id) VALUES (OLD.slug, NEW.id);
+    END IF;
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS posts_slug_history_update ON posts;
+CREATE TRIGGER posts_slug_history_update
+    BEFORE INSERT OR UPDATE OF slug ON posts
+    FOR EACH ROW EXECUTE FUNCTION posts_slug_history();
+
+CREATE OR REPLACE FUNCTION projects_slug_history() RETURNS trigger AS $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM project_slug_history WHERE slug = NEW.slug AND project_id <> NEW.id) THEN
+        RAISE EXCEPTION 'slug "%" redirects to another project', NEW.slug
+            USING ERRCODE = 'unique_violation', CONSTRAINT = 'project_slug_history_pkey';
+    END IF;
+    DELETE FROM project_slug_history WHERE slug = NEW.slug;
+    IF TG_OP = 'UPDATE' AND OLD.slug <> NEW.slug THEN
+        INSERT INTO project_slug_history (slug, project_id) VALUES (OLD.slug, NEW.id);
+    END IF;
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS projects_slug_history_update ON projects;
+CREATE TRIGGER projects_slug_history_update
+    BEFORE INSERT OR UPDATE OF slug ON projects
+    FOR EACH ROW EXECUTE FUNCTION projects_slug_history();
@@ -0,0 +1,19 @@
+-- ── Zine issues ─────────────────────────────────────────────
+-- A numbered issue groups posts in reading order. A post is in at most one
+-- issue, so it has a single previous and next post.
+CREATE TABLE IF NOT EXISTS issues (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    number      INTEGER      UNIQUE NOT NULL CHECK (number > 0),
+    title       VARCHAR(300) NOT NULL,
+    description TEXT         NOT NULL DEFAULT '',
+    cover_url   TEXT,
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS issue_posts (
+    post_id  UUID    PRIMARY KEY REFERENCES posts (id) ON DELETE CASCADE,
+    issue_id UUID    NOT NULL REFERENCES issues (id) ON DELETE CASCADE,
+    position INTEGER NOT NULL,
+    UNIQUE (issue_id, position)
+);
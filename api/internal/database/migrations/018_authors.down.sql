@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS post_authors;
+DROP TABLE IF EXISTS authors;
+-- Bylines longer than 100 characters are cut.
+ALTER TABLE post_revisions ALTER COLUMN author TYPE VARCHAR(100) USING left(author, 100);
+ALTER TABLE posts ALTER COLUMN author TYPE VARCHAR(100) USING left(author, 100);
@@ -0,0 +1,30 @@
+-- ── Authors ─────────────────────────────────────────────────
+-- Writers with a public profile, optionally tied to a user account. A post
+-- lists its authors in order in post_authors; posts.author stays as the
+-- byline and is rewritten by the API from the authors' names. Existing
+-- bylines are turned into authors by the API at startup, since splitting
+-- and slugging them is shared with every save.
+CREATE TABLE IF NOT EXISTS authors (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    slug       VARCHAR(200) UNIQUE NOT NULL,
+    name       VARCHAR(100) NOT NULL,
+    bio        TEXT         NOT NULL DEFAULT '',
+    avatar_url TEXT,
+    links      JSONB        NOT NULL DEFAULT '[]',
+    user_id    UUID         UNIQUE REFERENCES users (id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+-- Authors with posts cannot be deleted.
+CREATE TABLE IF NOT EXISTS post_authors (
+    post_id   UUID    NOT NULL REFERENCES posts (id) ON DELETE CASCADE,
+    author_id UUID    NOT NULL REFERENCES authors (id) ON DELETE RESTRICT,
+    position  INTEGER NOT NULL,
+    PRIMARY KEY (post_id, author_id)
+);
+CREATE INDEX IF NOT EXISTS idx_post_authors_author ON post_authors (author_id);
+
+-- Bylines of several authors outgrow a single name.
+ALTER TABLE posts ALTER COLUMN author TYPE VARCHAR(300);
+ALTER TABLE post_revisions ALTER COLUMN author TYPE VARCHAR(300);
@@ -0,0 +1,25 @@
+-- ── Comments ────────────────────────────────────────────────
+-- Reader comments on posts, threaded through parent_id. Every comment
+-- waits in the moderation queue until approved. Commenters manage their
+-- own comment with a secret token, stored hashed. A comment deleted by
+-- its commenter keeps its row, without the body, so replies stay threaded.
+CREATE TABLE IF NOT EXISTS comments (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    post_id      UUID         NOT NULL REFERENCES posts (id) ON DELETE CASCADE,
+    parent_id    UUID         REFERENCES comments (id) ON DELETE CASCADE,
+    depth        INTEGER      NOT NULL DEFAULT 0,
+    author_name  VARCHAR(100) NOT NULL,
+    author_email VARCHAR(255),
+    body         TEXT         NOT NULL,
+    status       VARCHAR(20)  NOT NULL DEFAULT 'pending'
+                 CHECK (status IN ('pending', 'approved', 'rejected', 'spam')),
+    token_hash   VARCHAR(64)  NOT NULL,
+    moderated_by UUID         REFERENCES users (id) ON DELETE SET NULL,
+    moderated_at TIMESTAMPTZ,
+    edited_at    TIMESTAMPTZ,
+    deleted_at   TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_comments_post ON comments (post_id, created_at) WHERE status = 'approved';
+CREATE INDEX IF NOT EXISTS idx_comments_status ON comments (status, created_at);
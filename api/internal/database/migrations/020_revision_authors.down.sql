@@ -0,0 +1 @@
+ALTER TABLE post_revisions DROP COLUMN IF EXISTS author_ids;
@@ -0,0 +1,13 @@
+-- ── Revision authors ────────────────────────────────────────
+-- The authors of a post, in order, when the revision was taken, so a
+-- restore links the same authors even if they were renamed since. NULL
+-- for revisions taken before authors were recorded.
+ALTER TABLE post_revisions ADD COLUMN IF NOT EXISTS author_ids UUID[];
+
+-- Revisions with the post's current byline name its current authors.
+UPDATE post_revisions r SET author_ids = ARRAY(
+    SELECT pa.author_id FROM post_authors pa WHERE pa.post_id = r.post_id ORDER BY pa.position
+)
+FROM posts p
+WHERE p.id = r.post_id AND r.author_ids IS NULL AND r.author IS NOT DISTINCT FROM p.author
+  AND EXISTS (SELECT 1 FROM post_authors pa WHERE pa.post_id = p.id);
@@ -0,0 +1,63 @@
+// Package dbtest gives tests a PostgreSQL pool of their own. Tests that
+// use it are skipped unless TEST_DATABASE_URL names a database to run in.
+package dbtest
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// EnvURL names the environment variable holding the test database URL.
+const EnvURL = "TEST_DATABASE_URL"
+
+// Pool returns a pool whose tables live in a new, empty schema that is
+// dropped when the test ends, so tests in different packages can share one
+// database. It skips the test when EnvURL is unset.
+func Pool(t *testing.T) *pgxpool.Pool {
+	t.Helper()
+	url := os.Getenv(EnvURL)
+	if url == "" {
+		t.Skip(EnvURL + " not set")
+	}
+	ctx := context.Background()
+
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		t.Fatal(err)
+	}
+	schema := pgx.Identifier{"test_" + hex.EncodeToString(b)}.Sanitize()
+
+	admin, err := pgx.Connect(ctx, url)
+	if err != nil {
+		t.Fatalf("connect to test database: %v", err)
+	}
+	if _, err := admin.Exec(ctx, `CREATE SCHEMA `+schema); err != nil {
+		admin.Close(ctx)
+		t.Fatalf("create schema: %v", err)
+	}
+
+	cfg, err := pgxpool.ParseConfig(url)
+	if err != nil {
+		t.Fatalf("parse %s: %v", EnvURL, err)
+	}
+	cfg.ConnConfig.RuntimeParams["search_path"] = schema + ", public"
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		t.Fatalf("create pool: %v", err)
+	}
+
+	t.Cleanup(func() {
+		pool.Close()
+		if _, err := admin.Exec(ctx, `DROP SCHEMA `+schema+` CASCADE`); err != nil {
+			t.Errorf("drop schema: %v", err)
+		}
+		admin.Close(ctx)
+	})
+	return pool
+}
@@ -0,0 +1,112 @@
+package feed
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+func sample() Feed {
+	published := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	return Feed{
+		Title:       "SUBCULT Zine",
+		Description: "Transmissions",
+		HomeURL:     "https://subcult.tv/zine",
+		FeedURL:     "https://api.subcult.tv/feed.xml",
+		Updated:     published.Add(time.Hour),
+		Items: []Item{{
+			ID:          "https://subcult.tv/zine/boot",
+			URL:         "https://subcult.tv/zine/boot",
+			Title:       "Boot & Sequence",
+			Summary:     "First <post>",
+			ContentHTML: `<p>Hello <em>world</em></p>`,
+			Author:      "SUBCULT",
+			Tags:        []string{"manifesto", "infra"},
+			Published:   published,
+			Updated:     published.Add(time.Hour),
+		}},
+	}
+}
+
+func TestRSS(t *testing.T) {
+	out, err := RSS(sample())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := string(out)
+	for _, want := range []string{
+		`<rss version="2.0"`,
+		`<atom:link href="https://api.subcult.tv/feed.xml" rel="self" type="application/rss+xml">`,
+		`<title>Boot &amp; Sequence</title>`,
+		`<guid isPermaLink="true">https://subcult.tv/zine/boot</guid>`,
+		`<pubDate>Sat, 01 Mar 2025 12:00:00 +0000</pubDate>`,
+		`<content:encoded>&lt;p&gt;Hello &lt;em&gt;world&lt;/em&gt;&lt;/p&gt;</content:encoded>`,
+		`<category>infra</category>`,
+	} {
+		if !strings.Contains(s, want) {
+			t.Errorf("RSS missing %q:\n%s", want, s)
+		}
+	}
+	if err := xml.Unmarshal(out, new(struct{})); err != nil {
+		t.Errorf("RSS is not well-formed: %v", err)
+	}
+}
+
+func TestAtom(t *testing.T) {
+	out, err := Atom(sample())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		XMLName xml.Name `xml:"http://www.w3.org/2005/Atom feed"`
+		Updated string   `xml:"updated"`
+		Entries []struct {
+			ID      string `xml:"id"`
+			Author  string `xml:"author>name"`
+			Content struct {
+				Type  string `xml:"type,attr"`
+				Value string `xml:",chardata"`
+			} `xml:"content"`
+		} `xml:"entry"`
+	}
+	if err := xml.Unmarshal(out, &doc); err != nil {
+		t.Fatalf("Atom does not parse: %v\n%s", err, out)
+	}
+	if doc.Updated != "2025-03-01T13:00:00Z" || len(doc.Entries) != 1 {
+		t.Fatalf("Atom = %+v", doc)
+	}
+	e := doc.Entries[0]
+	if e.ID != "https://subcult.tv/zine/boot" || e.Author != "SUBCULT" ||
+		e.Content.Type != "html" || e.Content.Value != `<p>Hello <em>world</em></p>` {
+		t.Errorf("entry = %+v", e)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	out, err := JSON(sample())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(out, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc["version"] != "https://jsonfeed.org/version/1.1" {
+		t.Errorf("version = %v", doc["version"])
+	}
+	items := doc["items"].([]any)
+	item := items[0].(map[string]any)
+	if item["content_html"] != `<p>Hello <em>world</em></p>` || item["date_published"] != "2025-03-01T12:00:00Z" {
+		t.Errorf("item = %v", item)
+	}
+
+	empty, err := JSON(Feed{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(empty), `"items": []`) {
+		t.Errorf("empty feed items not an array:\n%s", empty)
+	}
+}
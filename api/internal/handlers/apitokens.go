@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/subculture-collective/subcult-tv/api/internal/middleware"
+	"github.com/subculture-collective/subcult-tv/api/internal/models"
+)
+
+const (
+	// defaultAPITokenDays is used when a token is created without an expiry.
+	defaultAPITokenDays = 90
+	// maxAPITokenDays caps how long a token may live.
+	maxAPITokenDays = 365
+	// apiTokenPrefixLen is how much of the token is kept for display.
+	apiTokenPrefixLen = 12
+)
+
+// scanAPIToken scans an api_tokens row joined with its owner's username.
+func scanAPIToken(s scanner) (models.APIToken, error) {
+	var t models.APIToken
+	err := s.Scan(&t.ID, &t.UserID, &t.Username, &t.Name, &t.Prefix, &t.Scopes,
+		&t.LastUsedAt, &t.ExpiresAt, &t.RevokedAt, &t.CreatedAt)
+	return t, err
+}
+
+// LookupAPIToken implements middleware.AuthStore. The owner's current role
+// is used, so demoting a user also narrows their tokens.
+func (h *Handler) LookupAPIToken(ctx context.Context, token string) (*middleware.TokenIdentity, error) {
+	var id middleware.TokenIdentity
+	var scopes []string
+	err := h.DB.QueryRow(ctx,
+		`UPDATE api_tokens t SET last_used_at = NOW()
+		 FROM users u
+		 WHERE t.token_hash = $1 AND t.user_id = u.id
+		   AND t.revoked_at IS NULL AND t.expires_at > NOW() AND u.disabled_at IS NULL
+		 RETURNING u.id::text, u.username, u.role, t.scopes`, hashToken(token),
+	).Scan(&id.UserID, &id.Username, &id.Role, &scopes)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	id.Scopes = make([]middleware.Permission, len(scopes))
+	for i, s := range scopes {
+		id.Scopes[i] = middleware.Permission(s)
+	}
+	return &id, nil
+}
+
+// listAPITokens writes the tokens matching an optional owner filter.
+func (h *Handler) listAPITokens(w http.ResponseWriter, r *http.Request, userID string) {
+	query := `SELECT t.id, t.user_id, u.username, t.name, t.token_prefix, t.scopes,
+	           t.last_used_at, t.expires_at, t.revoked_at, t.created_at
+	          FROM api_tokens t JOIN users u ON u.id = t.user_id
+	          WHERE t.revoked_at IS NULL AND t.expires_at > NOW()`
+	var args []interface{}
+	if userID != "" {
+		query += ` AND t.user_id = $1`
+		args = append(args, userID)
+	}
+	query += ` ORDER BY t.created_at DESC`
+
+	rows, err := h.DB.Query(r.Context(), query, args...)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to query tokens")
+		return
+	}
+	defer rows.Close()
+
+	var tokens []models.APIToken
+	for rows.Next() {
+		t, err := scanAPIToken(rows)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to scan token")
+			return
+		}
+		tokens = append(tokens, t)
+	}
+
+	if tokens == nil {
+		tokens = []models.APIToken{}
+	}
+	writeJSON(w, http.StatusOK, tokens)
+}
+
+// ListMyAPITokens returns the current user's active tokens.
+func (h *Handler) ListMyAPITokens(w http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value(middleware.UserIDKey).(string)
+	h.listAPITokens(w, r, userID)
+}
+
+// ListAPITokens returns every active token (admin only).
+func (h *Handler) ListAPITokens(w http.ResponseWriter, r *http.Request) {
+	h.listAPITokens(w, r, r.URL.Query().Get("user_id"))
+}
+
+// CreateAPIToken issues a personal access token for the current user.
+// Scopes must be permissions the user's role already grants.
+func (h *Handler) CreateAPIToken(w http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value(middleware.UserIDKey).(string)
+	role, _ := r.Context().Value(middleware.RoleKey).(string)
+
+	var req models.CreateAPITokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Scopes) == 0 {
+		writeError(w, http.StatusBadRequest, "name and scopes are required")
+		return
+	}
+	for _, s := range req.Scopes {
+		perm := middleware.Permission(s)
+		if !middleware.ValidPermission(perm) {
+			writeError(w, http.StatusBadRequest, "unknown scope: "+s)
+			return
+		}
+		if !middleware.RoleHas(role, perm) {
+			writeError(w, http.StatusForbidden, "your role does not grant scope: "+s)
+			return
+		}
+	}
+
+	days := req.ExpiresInDays
+	if days == 0 {
+		days = defaultAPITokenDays
+	}
+	if days < 0 || days > maxAPITokenDays {
+		writeError(w, http.StatusBadRequest, "expires_in_days must be between 1 and 365")
+		return
+	}
+
+	secret, _, err := newOpaqueToken()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to generate token")
+		return
+	}
+	token := middleware.APITokenPrefix + secret
+
+	t, err := scanAPIToken(h.DB.QueryRow(r.Context(),
+		`WITH t AS (
+		   INSERT INTO api_tokens (user_id, name, token_hash, token_prefix, scopes, expires_at)
+		   VALUES ($1, $2, $3, $4, $5, $6)
+		   RETURNING *
+		 )
+		 SELECT t.id, t.user_id, u.username, t.name, t.token_prefix, t.scopes,
+		   t.last_used_at, t.expires_at, t.revoked_at, t.created_at
+		 FROM t JOIN users u ON u.id = t.user_id`,
+		userID, req.Name, hashToken(token), token[:apiTokenPrefixLen], req.Scopes,
+		time.Now().AddDate(0, 0, days),
+	))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to create token")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, models.CreateAPITokenResponse{APIToken: t, Token: token})
+}
+
+// RevokeMyAPIToken revokes one of the current user's tokens.
+func (h *Handler) RevokeMyAPIToken(w http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value(middleware.UserIDKey).(string)
+	id := chi.URLParam(r, "id")
+
+	tag, err := h.DB.Exec(r.Context(),
+		`UPDATE api_tokens SET revoked_at = NOW()
+		 WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL`, id, userID,
+	)
+	if err != nil || tag.RowsAffected() == 0 {
+		writeError(w, http.StatusNotFound, "token not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RevokeAPIToken revokes any user's token (admin only).
+func (h *Handler) RevokeAPIToken(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	tag, err := h.DB.Exec(r.Context(),
+		`UPDATE api_tokens SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL`, id,
+	)
+	if err != nil || tag.RowsAffected() == 0 {
+		writeError(w, http.StatusNotFound, "token not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
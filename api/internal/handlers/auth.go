@@ -2,16 +2,18 @@ package handlers
 
 import (
 	"encoding/json"
+	"log/slog"
 	"net/http"
-	"time"
 
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/subculture-collective/subcult-tv/api/internal/middleware"
 	"github.com/subculture-collective/subcult-tv/api/internal/models"
 	"golang.org/x/crypto/bcrypt"
 )
 
-// Login authenticates an admin user and returns a JWT.
+// Login authenticates an admin user and returns an access token plus a
+// refresh token for a new session, or sets them as cookies when mode is
+// "cookie". Users with 2FA get an mfa challenge instead, completed at
+// LoginMFA.
 func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 	var req models.LoginRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -24,40 +26,169 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	locked, err := h.loginLockedFor(r.Context(), req.Username)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to check login attempts")
+		return
+	}
+	if locked > 0 {
+		writeLocked(w, locked)
+		return
+	}
+
 	var user models.User
-	err := h.DB.QueryRow(r.Context(),
-		`SELECT id, username, email, password_hash, role, created_at, updated_at
-		 FROM users WHERE username = $1`, req.Username,
-	).Scan(&user.ID, &user.Username, &user.Email, &user.PasswordHash, &user.Role, &user.CreatedAt, &user.UpdatedAt)
+	err = h.DB.QueryRow(r.Context(),
+		`SELECT id, username, email, password_hash, role, totp_enabled_at IS NOT NULL, created_at, updated_at
+		 FROM users WHERE username = $1 AND disabled_at IS NULL`, req.Username,
+	).Scan(&user.ID, &user.Username, &user.Email, &user.PasswordHash, &user.Role, &user.TOTPEnabled, &user.CreatedAt, &user.UpdatedAt)
 	if err != nil {
+		// Unknown user: spend the same bcrypt time as a wrong password.
+		_ = bcrypt.CompareHashAndPassword(dummyHash(), []byte(req.Password))
+		h.recordLoginFailure(r.Context(), req.Username, "credentials")
 		writeError(w, http.StatusUnauthorized, "invalid credentials")
 		return
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+		h.recordLoginFailure(r.Context(), req.Username, "credentials")
 		writeError(w, http.StatusUnauthorized, "invalid credentials")
 		return
 	}
 
-	// Generate JWT
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub":      user.ID.String(),
-		"username": user.Username,
-		"role":     user.Role,
-		"iat":      time.Now().Unix(),
-		"exp":      time.Now().Add(24 * time.Hour).Unix(),
-	})
+	h.clearLoginFailures(r.Context(), req.Username)
 
-	tokenStr, err := token.SignedString([]byte(h.JWTSecret))
+	h.loginOrChallenge(w, r, user, req.Mode == loginModeCookie)
+}
+
+// loginOrChallenge finishes the first login step for user: it writes an
+// MFA challenge when a second factor is required, and otherwise starts the
+// session.
+func (h *Handler) loginOrChallenge(w http.ResponseWriter, r *http.Request, user models.User, cookieMode bool) {
+	required, enroll, err := h.mfaRequirement(r.Context(), user)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to load settings")
+		return
+	}
+	if required {
+		mfaToken, err := h.issueMFAToken(user.ID.String())
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to generate token")
+			return
+		}
+		writeJSON(w, http.StatusOK, models.MFAChallengeResponse{
+			MFARequired:        true,
+			MFAToken:           mfaToken,
+			EnrollmentRequired: enroll,
+		})
+		return
+	}
+
+	h.completeLogin(w, r, user, nil, cookieMode)
+}
+
+// completeLogin starts a session for an authenticated user and writes the
+// token pair.
+func (h *Handler) completeLogin(w http.ResponseWriter, r *http.Request, user models.User, recoveryCodes []string, cookieMode bool) {
+	sessionID, refreshToken, err := h.createSession(r.Context(), user.ID.String(), r)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to create session")
+		return
+	}
+
+	tokenStr, expiresAt, err := h.issueAccessToken(user, sessionID)
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "failed to generate token")
 		return
 	}
 
-	writeJSON(w, http.StatusOK, models.LoginResponse{
-		Token: tokenStr,
-		User:  user,
-	})
+	h.writeLoginResponse(w, models.LoginResponse{
+		Token:         tokenStr,
+		RefreshToken:  refreshToken,
+		ExpiresAt:     expiresAt,
+		User:          user,
+		RecoveryCodes: recoveryCodes,
+	}, cookieMode)
+}
+
+// Refresh rotates a refresh token and returns a new access token for the
+// same session. Presenting an already-rotated token revokes the session,
+// since it means the token was copied. Cookie sessions are refreshed from
+// the refresh cookie and get new cookies back.
+func (h *Handler) Refresh(w http.ResponseWriter, r *http.Request) {
+	refreshToken, cookieMode, ok := h.readRefreshToken(w, r)
+	if !ok {
+		return
+	}
+
+	newToken, newHash, err := newOpaqueToken()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to generate token")
+		return
+	}
+
+	oldHash := hashToken(refreshToken)
+
+	var sessionID string
+	var user models.User
+	err = h.DB.QueryRow(r.Context(),
+		`UPDATE sessions s SET
+		   refresh_token_hash = $2, previous_token_hash = $1, last_used_at = NOW()
+		 FROM users u
+		 WHERE s.refresh_token_hash = $1 AND s.user_id = u.id AND u.disabled_at IS NULL
+		   AND s.revoked_at IS NULL AND s.expires_at > NOW()
+		 RETURNING s.id, u.id, u.username, u.email, u.role, u.totp_enabled_at IS NOT NULL,
+		   u.created_at, u.updated_at`,
+		oldHash, newHash,
+	).Scan(&sessionID, &user.ID, &user.Username, &user.Email, &user.Role, &user.TOTPEnabled, &user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		tag, rerr := h.DB.Exec(r.Context(),
+			`UPDATE sessions SET revoked_at = NOW()
+			 WHERE previous_token_hash = $1 AND revoked_at IS NULL`, oldHash,
+		)
+		if rerr == nil && tag.RowsAffected() > 0 {
+			slog.Warn("refresh token reuse detected, session revoked", "ip", r.RemoteAddr)
+		}
+		writeError(w, http.StatusUnauthorized, "invalid or expired refresh token")
+		return
+	}
+
+	tokenStr, expiresAt, err := h.issueAccessToken(user, sessionID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to generate token")
+		return
+	}
+
+	h.writeLoginResponse(w, models.LoginResponse{
+		Token:        tokenStr,
+		RefreshToken: newToken,
+		ExpiresAt:    expiresAt,
+		User:         user,
+	}, cookieMode)
+}
+
+// Logout revokes the session identified by a refresh token. It works even
+// after the access token has expired. Cookie sessions also have their
+// cookies cleared.
+func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
+	refreshToken, cookieMode, ok := h.readRefreshToken(w, r)
+	if !ok {
+		return
+	}
+
+	_, err := h.DB.Exec(r.Context(),
+		`UPDATE sessions SET revoked_at = NOW()
+		 WHERE refresh_token_hash = $1 AND revoked_at IS NULL`, hashToken(refreshToken),
+	)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to revoke session")
+		return
+	}
+
+	if cookieMode {
+		h.clearAuthCookies(w)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
 }
 
 // Me returns the currently authenticated user.
@@ -70,9 +201,9 @@ func (h *Handler) Me(w http.ResponseWriter, r *http.Request) {
 
 	var user models.User
 	err := h.DB.QueryRow(r.Context(),
-		`SELECT id, username, email, role, created_at, updated_at
+		`SELECT id, username, email, role, totp_enabled_at IS NOT NULL, created_at, updated_at
 		 FROM users WHERE id = $1`, userID,
-	).Scan(&user.ID, &user.Username, &user.Email, &user.Role, &user.CreatedAt, &user.UpdatedAt)
+	).Scan(&user.ID, &user.Username, &user.Email, &user.Role, &user.TOTPEnabled, &user.CreatedAt, &user.UpdatedAt)
 	if err != nil {
 		writeError(w, http.StatusNotFound, "user not found")
 		return
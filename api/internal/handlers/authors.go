@@ -0,0 +1,263 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/subculture-collective/subcult-tv/api/internal/authors"
+	"github.com/subculture-collective/subcult-tv/api/internal/middleware"
+	"github.com/subculture-collective/subcult-tv/api/internal/models"
+)
+
+// authorColumns are the columns scanAuthor reads, with the public post
+// count.
+const authorColumns = `a.id, a.slug, a.name, a.bio, a.avatar_url, a.links, a.user_id,
+	a.created_at, a.updated_at,
+	(SELECT COUNT(*) FROM post_authors pa JOIN posts ON posts.id = pa.post_id
+	 WHERE pa.author_id = a.id AND ` + postIsPublic + `)`
+
+// scanAuthor scans a row of authorColumns into a models.Author.
+func scanAuthor(s scanner) (models.Author, error) {
+	var a models.Author
+	err := s.Scan(&a.ID, &a.Slug, &a.Name, &a.Bio, &a.AvatarURL, &a.Links, &a.UserID,
+		&a.CreatedAt, &a.UpdatedAt, &a.PostCount)
+	return a, err
+}
+
+// setPostAuthors sets a post's authors from a create or update request,
+// by id or else by byline, within tx. It writes the error response and
+// returns false on failure.
+func (h *Handler) setPostAuthors(w http.ResponseWriter, r *http.Request, tx pgx.Tx, p *models.Post, req models.CreatePostRequest) bool {
+	var err error
+	if req.AuthorIDs != nil {
+		p.Authors, err = authors.Set(r.Context(), tx, p.ID.String(), req.AuthorIDs)
+	} else {
+		p.Authors, err = authors.Link(r.Context(), tx, p.ID.String(), req.Author)
+	}
+	switch {
+	case errors.Is(err, authors.ErrUnknownAuthor), errors.Is(err, authors.ErrNoSlug):
+		writeError(w, http.StatusBadRequest, err.Error())
+		return false
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, "failed to set post authors")
+		return false
+	}
+	p.Author = authors.Byline(p.Authors)
+	return true
+}
+
+// ListAuthors returns authors with at least one public post, by name.
+func (h *Handler) ListAuthors(w http.ResponseWriter, r *http.Request) {
+	h.listAuthors(w, r, false)
+}
+
+// ListAllAuthors is ListAuthors including authors of drafts only, with
+// linked user ids (admin only).
+func (h *Handler) ListAllAuthors(w http.ResponseWriter, r *http.Request) {
+	h.listAuthors(w, r, true)
+}
+
+func (h *Handler) listAuthors(w http.ResponseWriter, r *http.Request, admin bool) {
+	rows, err := h.DB.Query(r.Context(),
+		`SELECT `+authorColumns+` FROM authors a ORDER BY lower(a.name), a.slug`)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to query authors")
+		return
+	}
+	defer rows.Close()
+
+	list := []models.Author{}
+	for rows.Next() {
+		a, err := scanAuthor(rows)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to scan author")
+			return
+		}
+		if !admin {
+			if a.PostCount == 0 {
+				continue
+			}
+			a.UserID = nil
+		}
+		list = append(list, a)
+	}
+
+	writeJSON(w, http.StatusOK, list)
+}
+
+// GetAuthor returns an author's profile by slug with their public posts,
+// newest first. Authors without public posts are not found.
+func (h *Handler) GetAuthor(w http.ResponseWriter, r *http.Request) {
+	slug := chi.URLParam(r, "slug")
+
+	a, err := scanAuthor(h.DB.QueryRow(r.Context(),
+		`SELECT `+authorColumns+` FROM authors a WHERE a.slug = $1`, slug,
+	))
+	if err != nil || a.PostCount == 0 {
+		writeError(w, http.StatusNotFound, "author not found")
+		return
+	}
+	a.UserID = nil
+
+	rows, err := h.DB.Query(r.Context(),
+		`SELECT `+postColumns+` FROM posts JOIN post_authors ON post_authors.post_id = posts.id
+		 WHERE post_authors.author_id = $1 AND `+postIsPublic+`
+		 ORDER BY date DESC, created_at DESC`, a.ID,
+	)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to query posts")
+		return
+	}
+	defer rows.Close()
+
+	detail := models.AuthorDetail{Author: a, Posts: []models.Post{}}
+	for rows.Next() {
+		p, err := scanPost(rows)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to scan post")
+			return
+		}
+		detail.Posts = append(detail.Posts, p)
+	}
+
+	writeJSON(w, http.StatusOK, detail)
+}
+
+// CreateAuthor creates an author profile (admin only).
+func (h *Handler) CreateAuthor(w http.ResponseWriter, r *http.Request) {
+	h.saveAuthor(w, r, "")
+}
+
+// UpdateAuthor updates an author profile and the bylines of their posts
+// (admin only).
+func (h *Handler) UpdateAuthor(w http.ResponseWriter, r *http.Request) {
+	h.saveAuthor(w, r, chi.URLParam(r, "id"))
+}
+
+// saveAuthor creates the author when id is empty and updates it otherwise.
+func (h *Handler) saveAuthor(w http.ResponseWriter, r *http.Request, id string) {
+	var req models.CreateAuthorRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	req.Name = strings.Join(strings.Fields(req.Name), " ")
+	if req.Slug == "" {
+		req.Slug = authors.Slugify(req.Name)
+	}
+	switch {
+	case req.Name == "":
+		writeError(w, http.StatusBadRequest, "name is required")
+		return
+	case strings.ContainsAny(req.Name, ",&"):
+		writeError(w, http.StatusBadRequest, "name cannot contain , or & (they separate names in bylines)")
+		return
+	case req.Slug == "" || authors.Slugify(req.Slug) != req.Slug:
+		writeError(w, http.StatusBadRequest, "slug must be lower-case letters, digits, and single hyphens")
+		return
+	}
+	if req.Links == nil {
+		req.Links = []models.AuthorLink{}
+	}
+	for _, l := range req.Links {
+		if !strings.HasPrefix(l.URL, "https://") && !strings.HasPrefix(l.URL, "http://") {
+			writeError(w, http.StatusBadRequest, "links must be http(s) URLs")
+			return
+		}
+	}
+
+	ctx := r.Context()
+	tx, err := h.DB.Begin(ctx)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to save author")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	if req.UserID != nil {
+		var exists bool
+		if err := tx.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM users WHERE id = $1)`, *req.UserID).Scan(&exists); err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to save author")
+			return
+		}
+		if !exists {
+			writeError(w, http.StatusBadRequest, "unknown user_id")
+			return
+		}
+	}
+
+	var authorID string
+	if id == "" {
+		err = tx.QueryRow(ctx,
+			`INSERT INTO authors (slug, name, bio, avatar_url, links, user_id)
+			 VALUES ($1,$2,$3,$4,$5,$6) RETURNING id::text`,
+			req.Slug, req.Name, req.Bio, req.AvatarURL, req.Links, req.UserID,
+		).Scan(&authorID)
+	} else {
+		err = tx.QueryRow(ctx,
+			`UPDATE authors SET slug=$1, name=$2, bio=$3, avatar_url=$4, links=$5, user_id=$6,
+			  updated_at=NOW()
+			 WHERE id=$7 RETURNING id::text`,
+			req.Slug, req.Name, req.Bio, req.AvatarURL, req.Links, req.UserID, id,
+		).Scan(&authorID)
+	}
+	if isUniqueViolation(err) {
+		writeError(w, http.StatusConflict, "slug or user already belongs to another author")
+		return
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		writeError(w, http.StatusNotFound, "author not found")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to save author")
+		return
+	}
+
+	if id != "" {
+		editorID, _ := ctx.Value(middleware.UserIDKey).(string)
+		if err := authors.RefreshBylines(ctx, tx, authorID, editorID, h.RevisionsKeep); err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to save author")
+			return
+		}
+	}
+	a, err := scanAuthor(tx.QueryRow(ctx,
+		`SELECT `+authorColumns+` FROM authors a WHERE a.id = $1`, authorID,
+	))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to save author")
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to save author")
+		return
+	}
+
+	status := http.StatusOK
+	if id == "" {
+		status = http.StatusCreated
+	}
+	writeJSON(w, status, a)
+}
+
+// DeleteAuthor deletes an author who has no posts (admin only).
+func (h *Handler) DeleteAuthor(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	tag, err := h.DB.Exec(r.Context(), `DELETE FROM authors WHERE id = $1`, id)
+	if isForeignKeyViolation(err) {
+		writeError(w, http.StatusConflict, "author still has posts")
+		return
+	}
+	if err != nil || tag.RowsAffected() == 0 {
+		writeError(w, http.StatusNotFound, "author not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,392 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/subculture-collective/subcult-tv/api/internal/middleware"
+	"github.com/subculture-collective/subcult-tv/api/internal/models"
+)
+
+// CommentTokenHeader carries a commenter's secret token to UpdateComment
+// and DeleteComment.
+const CommentTokenHeader = "X-Comment-Token"
+
+// Comment limits.
+const (
+	MaxCommentLength = 5000 // characters in a body
+	MaxCommentName   = 100  // characters in an author name
+	MaxCommentEmail  = 255  // characters in an author email
+	MaxCommentDepth  = 4    // replies nested below a top-level comment
+)
+
+// commentColumns are the columns scanComment reads, for a comments table
+// aliased c.
+const commentColumns = `c.id, c.post_id, c.parent_id, c.author_name, c.body,
+	c.deleted_at IS NOT NULL, c.edited_at, c.created_at`
+
+// scanComment scans a row of commentColumns into a models.Comment.
+func scanComment(s scanner) (models.Comment, error) {
+	var c models.Comment
+	err := s.Scan(&c.ID, &c.PostID, &c.ParentID, &c.AuthorName, &c.Body,
+		&c.Deleted, &c.EditedAt, &c.CreatedAt)
+	if c.Deleted {
+		c.AuthorName, c.Body = "", ""
+	}
+	return c, err
+}
+
+// validCommentBody trims a comment body and reports a problem with it, or
+// "" if there is none.
+func validCommentBody(body *string) string {
+	*body = strings.TrimSpace(*body)
+	switch {
+	case *body == "":
+		return "body is required"
+	case utf8.RuneCountInString(*body) > MaxCommentLength:
+		return fmt.Sprintf("body must be at most %d characters", MaxCommentLength)
+	}
+	return ""
+}
+
+// validCommentEmail reduces an optional author email to its bare address,
+// or to nil if blank, and reports a problem with it, or "" if there is none.
+func validCommentEmail(email **string) string {
+	if *email == nil {
+		return ""
+	}
+	s := strings.TrimSpace(**email)
+	if s == "" {
+		*email = nil
+		return ""
+	}
+	addr, err := mail.ParseAddress(s)
+	if err != nil {
+		return "invalid author_email"
+	}
+	if utf8.RuneCountInString(addr.Address) > MaxCommentEmail {
+		return fmt.Sprintf("author_email must be at most %d characters", MaxCommentEmail)
+	}
+	*email = &addr.Address
+	return ""
+}
+
+// ListComments returns the approved comments on a public post as threads,
+// oldest first. An ancestor that is not approved, such as a comment whose
+// edit awaits moderation, is listed hidden, without its name and body, so
+// approved replies below it stay visible.
+func (h *Handler) ListComments(w http.ResponseWriter, r *http.Request) {
+	slug := chi.URLParam(r, "slug")
+
+	rows, err := h.DB.Query(r.Context(),
+		`WITH RECURSIVE shown AS (
+		   SELECT c.id, c.parent_id FROM comments c JOIN posts ON posts.id = c.post_id
+		   WHERE posts.slug = $1 AND `+postIsPublic+` AND c.status = 'approved'
+		   UNION
+		   SELECT p.id, p.parent_id FROM comments p JOIN shown ON shown.parent_id = p.id
+		 )
+		 SELECT `+commentColumns+`, c.status <> 'approved'
+		 FROM comments c JOIN shown ON shown.id = c.id
+		 ORDER BY c.created_at`, slug,
+	)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to query comments")
+		return
+	}
+	defer rows.Close()
+
+	var flat []models.Comment
+	for rows.Next() {
+		var c models.Comment
+		if err := rows.Scan(&c.ID, &c.PostID, &c.ParentID, &c.AuthorName, &c.Body,
+			&c.Deleted, &c.EditedAt, &c.CreatedAt, &c.Hidden); err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to scan comment")
+			return
+		}
+		if c.Hidden {
+			c.AuthorName, c.Body = "", ""
+		}
+		flat = append(flat, c)
+	}
+
+	writeJSON(w, http.StatusOK, commentThreads(flat))
+}
+
+// commentThreads nests comments, given oldest first, under their parents.
+// Replies whose parent is missing are dropped with it, as are deleted and
+// hidden comments left without replies.
+func commentThreads(flat []models.Comment) []models.Comment {
+	children := map[uuid.UUID][]models.Comment{}
+	var roots []models.Comment
+	for _, c := range flat {
+		if c.ParentID == nil {
+			roots = append(roots, c)
+		} else {
+			children[*c.ParentID] = append(children[*c.ParentID], c)
+		}
+	}
+
+	var build func([]models.Comment) []models.Comment
+	build = func(list []models.Comment) []models.Comment {
+		var out []models.Comment
+		for _, c := range list {
+			c.Replies = build(children[c.ID])
+			if (c.Deleted || c.Hidden) && len(c.Replies) == 0 {
+				continue
+			}
+			out = append(out, c)
+		}
+		return out
+	}
+
+	threads := build(roots)
+	if threads == nil {
+		threads = []models.Comment{}
+	}
+	return threads
+}
+
+// SubmitComment queues a reader's comment on a public post for moderation
+// and returns the token that edits or deletes it (public).
+func (h *Handler) SubmitComment(w http.ResponseWriter, r *http.Request) {
+	slug := chi.URLParam(r, "slug")
+
+	var req models.CreateCommentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	req.AuthorName = strings.Join(strings.Fields(req.AuthorName), " ")
+	if req.AuthorName == "" || utf8.RuneCountInString(req.AuthorName) > MaxCommentName {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("author_name is required, at most %d characters", MaxCommentName))
+		return
+	}
+	if msg := validCommentBody(&req.Body); msg != "" {
+		writeError(w, http.StatusBadRequest, msg)
+		return
+	}
+	if msg := validCommentEmail(&req.AuthorEmail); msg != "" {
+		writeError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	ctx := r.Context()
+	var postID uuid.UUID
+	if err := h.DB.QueryRow(ctx,
+		`SELECT id FROM posts WHERE slug = $1 AND `+postIsPublic, slug,
+	).Scan(&postID); err != nil {
+		writeError(w, http.StatusNotFound, "post not found")
+		return
+	}
+
+	depth := 0
+	if req.ParentID != nil {
+		err := h.DB.QueryRow(ctx,
+			`SELECT depth + 1 FROM comments
+			 WHERE id = $1 AND post_id = $2 AND status = 'approved' AND deleted_at IS NULL`,
+			*req.ParentID, postID,
+		).Scan(&depth)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "parent comment not found")
+			return
+		}
+		if depth > MaxCommentDepth {
+			writeError(w, http.StatusBadRequest, "replies are nested too deeply")
+			return
+		}
+	}
+
+	token, hash, err := newOpaqueToken()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to save comment")
+		return
+	}
+	c, err := scanComment(h.DB.QueryRow(ctx,
+		`INSERT INTO comments AS c (post_id, parent_id, depth, author_name, author_email, body, token_hash)
+		 VALUES ($1,$2,$3,$4,$5,$6,$7)
+		 RETURNING `+commentColumns,
+		postID, req.ParentID, depth, req.AuthorName, req.AuthorEmail, req.Body, hash,
+	))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to save comment")
+		return
+	}
+	c.Status = models.CommentPending
+
+	writeJSON(w, http.StatusCreated, models.CreateCommentResponse{Comment: c, Token: token})
+}
+
+// UpdateComment replaces a comment's body given its token. The edit goes
+// back to the moderation queue; approved replies stay listed under it
+// (public).
+func (h *Handler) UpdateComment(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var req models.UpdateCommentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if msg := validCommentBody(&req.Body); msg != "" {
+		writeError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	c, err := scanComment(h.DB.QueryRow(r.Context(),
+		`UPDATE comments c SET body = $3, status = 'pending', edited_at = NOW(),
+		  moderated_by = NULL, moderated_at = NULL
+		 WHERE id = $1 AND token_hash = $2 AND deleted_at IS NULL
+		 RETURNING `+commentColumns,
+		id, hashToken(r.Header.Get(CommentTokenHeader)), req.Body,
+	))
+	if err != nil {
+		// A wrong token is indistinguishable from a missing comment.
+		writeError(w, http.StatusNotFound, "comment not found")
+		return
+	}
+	c.Status = models.CommentPending
+
+	writeJSON(w, http.StatusOK, c)
+}
+
+// DeleteComment removes a comment's author and body given its token;
+// replies stay in place (public).
+func (h *Handler) DeleteComment(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	tag, err := h.DB.Exec(r.Context(),
+		`UPDATE comments SET body = '', author_name = '', author_email = NULL, deleted_at = NOW()
+		 WHERE id = $1 AND token_hash = $2 AND deleted_at IS NULL`,
+		id, hashToken(r.Header.Get(CommentTokenHeader)),
+	)
+	if err != nil || tag.RowsAffected() == 0 {
+		writeError(w, http.StatusNotFound, "comment not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListModerationComments returns comments with a status, pending by
+// default, oldest first so the queue is worked in order (moderators only).
+func (h *Handler) ListModerationComments(w http.ResponseWriter, r *http.Request) {
+	page, perPage, offset := pagination(r)
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = models.CommentPending
+	}
+	if !validCommentStatus(status) {
+		writeError(w, http.StatusBadRequest, "status must be pending, approved, rejected, or spam")
+		return
+	}
+
+	var total int64
+	if err := h.DB.QueryRow(r.Context(),
+		`SELECT COUNT(*) FROM comments WHERE status = $1 AND deleted_at IS NULL`, status,
+	).Scan(&total); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to count comments")
+		return
+	}
+
+	rows, err := h.DB.Query(r.Context(),
+		`SELECT `+commentColumns+`, c.author_email, c.status, c.moderated_at, posts.slug, posts.title
+		 FROM comments c JOIN posts ON posts.id = c.post_id
+		 WHERE c.status = $1 AND c.deleted_at IS NULL
+		 ORDER BY c.created_at LIMIT $2 OFFSET $3`,
+		status, perPage, offset,
+	)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to query comments")
+		return
+	}
+	defer rows.Close()
+
+	comments := []models.Comment{}
+	for rows.Next() {
+		var c models.Comment
+		if err := rows.Scan(&c.ID, &c.PostID, &c.ParentID, &c.AuthorName, &c.Body,
+			&c.Deleted, &c.EditedAt, &c.CreatedAt,
+			&c.AuthorEmail, &c.Status, &c.ModeratedAt, &c.PostSlug, &c.PostTitle); err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to scan comment")
+			return
+		}
+		comments = append(comments, c)
+	}
+
+	writeJSON(w, http.StatusOK, models.PaginatedResponse[models.Comment]{
+		Data:       comments,
+		Total:      total,
+		Page:       page,
+		PerPage:    perPage,
+		TotalPages: totalPages(total, perPage),
+	})
+}
+
+// validCommentStatus reports whether s is a comment status.
+func validCommentStatus(s string) bool {
+	switch s {
+	case models.CommentPending, models.CommentApproved, models.CommentRejected, models.CommentSpam:
+		return true
+	}
+	return false
+}
+
+// ModerateComment approves, rejects, or marks a comment as spam
+// (moderators only).
+func (h *Handler) ModerateComment(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var req models.ModerateCommentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Status == models.CommentPending || !validCommentStatus(req.Status) {
+		writeError(w, http.StatusBadRequest, "status must be approved, rejected, or spam")
+		return
+	}
+
+	moderator, _ := r.Context().Value(middleware.UserIDKey).(string)
+	var c models.Comment
+	err := h.DB.QueryRow(r.Context(),
+		`UPDATE comments c SET status = $2, moderated_by = NULLIF($3, '')::uuid, moderated_at = NOW()
+		 FROM posts
+		 WHERE c.id = $1 AND posts.id = c.post_id AND c.deleted_at IS NULL
+		 RETURNING `+commentColumns+`, c.author_email, c.status, c.moderated_at, posts.slug, posts.title`,
+		id, req.Status, moderator,
+	).Scan(&c.ID, &c.PostID, &c.ParentID, &c.AuthorName, &c.Body,
+		&c.Deleted, &c.EditedAt, &c.CreatedAt,
+		&c.AuthorEmail, &c.Status, &c.ModeratedAt, &c.PostSlug, &c.PostTitle)
+	if errors.Is(err, pgx.ErrNoRows) {
+		writeError(w, http.StatusNotFound, "comment not found")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to moderate comment")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, c)
+}
+
+// RemoveComment deletes a comment and its replies (moderators only).
+func (h *Handler) RemoveComment(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	tag, err := h.DB.Exec(r.Context(), `DELETE FROM comments WHERE id = $1`, id)
+	if err != nil || tag.RowsAffected() == 0 {
+		writeError(w, http.StatusNotFound, "comment not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
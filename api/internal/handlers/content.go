@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/subculture-collective/subcult-tv/api/internal/content"
+)
+
+// ExportContent downloads every post and project, drafts included, as a
+// static bundle: MDX posts with front matter and projects.json in a tar.gz
+// (the default) or zip, or as the JSON bundle with ?format=json (admin
+// only).
+func (h *Handler) ExportContent(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = content.FormatTarGz
+	}
+	contentType := map[string]string{
+		content.FormatTarGz: "application/gzip",
+		content.FormatZip:   "application/zip",
+		"json":              "application/json",
+	}[format]
+	if contentType == "" {
+		writeError(w, http.StatusBadRequest, "format must be tar.gz, zip, or json")
+		return
+	}
+
+	bundle, err := content.Export(r.Context(), h.DB)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to export content")
+		return
+	}
+
+	// Build the whole file first so a failure can still be reported.
+	var buf bytes.Buffer
+	if format == "json" {
+		enc := json.NewEncoder(&buf)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(bundle)
+	} else {
+		err = content.WriteArchive(&buf, format, bundle)
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to export content: "+err.Error())
+		return
+	}
+
+	name := fmt.Sprintf("subcult-content-%s.%s", bundle.ExportedAt.Format("20060102-150405"), format)
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="`+name+`"`)
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(buf.Bytes())
+}
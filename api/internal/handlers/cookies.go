@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/subculture-collective/subcult-tv/api/internal/middleware"
+	"github.com/subculture-collective/subcult-tv/api/internal/models"
+)
+
+// loginModeCookie selects cookie-based sessions in LoginRequest.Mode.
+const loginModeCookie = "cookie"
+
+// refreshCookiePath limits the refresh cookie to the endpoints that use it.
+const refreshCookiePath = "/api/v1/auth"
+
+// newCookie returns a cookie with the shared security attributes.
+func (h *Handler) newCookie(name, value, path string, maxAge int, httpOnly bool) *http.Cookie {
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   h.CookieDomain,
+		MaxAge:   maxAge,
+		Secure:   h.CookieSecure,
+		HttpOnly: httpOnly,
+		SameSite: http.SameSiteStrictMode,
+	}
+}
+
+// writeLoginResponse writes resp, moving the tokens into cookies (and
+// issuing a fresh CSRF token) when cookieMode is set.
+func (h *Handler) writeLoginResponse(w http.ResponseWriter, resp models.LoginResponse, cookieMode bool) {
+	if cookieMode {
+		csrf, _, err := newOpaqueToken()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to generate csrf token")
+			return
+		}
+		http.SetCookie(w, h.newCookie(middleware.SessionCookie, resp.Token, "/", int(h.AccessTokenTTL.Seconds()), true))
+		http.SetCookie(w, h.newCookie(middleware.RefreshCookie, resp.RefreshToken, refreshCookiePath, int(h.RefreshTokenTTL.Seconds()), true))
+		http.SetCookie(w, h.newCookie(middleware.CSRFCookie, csrf, "/", int(h.RefreshTokenTTL.Seconds()), false))
+		resp.Token = ""
+		resp.RefreshToken = ""
+		resp.CSRFToken = csrf
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// clearAuthCookies expires every auth cookie.
+func (h *Handler) clearAuthCookies(w http.ResponseWriter) {
+	http.SetCookie(w, h.newCookie(middleware.SessionCookie, "", "/", -1, true))
+	http.SetCookie(w, h.newCookie(middleware.RefreshCookie, "", refreshCookiePath, -1, true))
+	http.SetCookie(w, h.newCookie(middleware.CSRFCookie, "", "/", -1, false))
+}
+
+// readRefreshToken reads the refresh token from the JSON body or, failing
+// that, from the refresh cookie, which must come with a valid CSRF header.
+// cookieMode reports the latter. On failure it writes the error response
+// and returns ok=false.
+func (h *Handler) readRefreshToken(w http.ResponseWriter, r *http.Request) (token string, cookieMode, ok bool) {
+	var req models.RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return "", false, false
+	}
+	if req.RefreshToken != "" {
+		return req.RefreshToken, false, true
+	}
+
+	cookie, err := r.Cookie(middleware.RefreshCookie)
+	if err != nil || cookie.Value == "" {
+		writeError(w, http.StatusBadRequest, "refresh_token is required")
+		return "", false, false
+	}
+	if !middleware.ValidCSRF(r) {
+		writeError(w, http.StatusForbidden, "invalid csrf token")
+		return "", true, false
+	}
+	return cookie.Value, true, true
+}
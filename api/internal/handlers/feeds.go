@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/subculture-collective/subcult-tv/api/internal/feed"
+	"github.com/subculture-collective/subcult-tv/api/internal/render"
+)
+
+// FeedSize is the number of most recent posts a feed carries.
+const FeedSize = 50
+
+// Feed metadata.
+const (
+	feedTitle       = "SUBCULT Zine"
+	feedDescription = "Transmissions from the Subculture Collective"
+	feedAuthor      = "SUBCULT"
+)
+
+// feedFormat is one serialisation of the post feed.
+type feedFormat struct {
+	name        string
+	contentType string
+	build       func(feed.Feed) ([]byte, error)
+}
+
+var (
+	rssFeed  = feedFormat{"rss", feed.RSSContentType, feed.RSS}
+	atomFeed = feedFormat{"atom", feed.AtomContentType, feed.Atom}
+	jsonFeed = feedFormat{"json", feed.JSONContentType, feed.JSON}
+)
+
+// RSSFeed serves the latest public posts as RSS 2.0, for every post or,
+// under /tags/{tag}, for one tag.
+func (h *Handler) RSSFeed(w http.ResponseWriter, r *http.Request) {
+	h.serveFeed(w, r, rssFeed)
+}
+
+// AtomFeed is RSSFeed as Atom 1.0.
+func (h *Handler) AtomFeed(w http.ResponseWriter, r *http.Request) {
+	h.serveFeed(w, r, atomFeed)
+}
+
+// JSONFeed is RSSFeed as JSON Feed 1.1.
+func (h *Handler) JSONFeed(w http.ResponseWriter, r *http.Request) {
+	h.serveFeed(w, r, jsonFeed)
+}
+
+func (h *Handler) serveFeed(w http.ResponseWriter, r *http.Request, format feedFormat) {
+	ctx := r.Context()
+
+	where := postIsPublic
+	var args []interface{}
+	tag := ""
+	if raw := chi.URLParam(r, "tag"); raw != "" {
+		var err error
+		if tag, err = url.PathUnescape(raw); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid tag")
+			return
+		}
+		where += ` AND $1 = ANY(tags)`
+		args = append(args, tag)
+	}
+
+	// A post changes the feed when it is edited, goes live, or is removed;
+	// the count catches removals.
+	var (
+		count   int64
+		lastMod *time.Time
+	)
+	if err := h.DB.QueryRow(ctx,
+		`SELECT COUNT(*), MAX(GREATEST(updated_at, published_at, publish_at))
+		 FROM posts WHERE `+where, args...,
+	).Scan(&count, &lastMod); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to build feed")
+		return
+	}
+
+	updated := time.Unix(0, 0).UTC()
+	if lastMod != nil {
+		updated = lastMod.UTC().Truncate(time.Second)
+	}
+	etag := feedETag(format.name, tag, count, updated)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Last-Modified", updated.Format(http.TimeFormat))
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	if notModified(r, etag, updated) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	rows, err := h.DB.Query(ctx,
+		`SELECT `+postColumns+` FROM posts WHERE `+where+
+			fmt.Sprintf(` ORDER BY date DESC, created_at DESC LIMIT %d`, FeedSize),
+		args...,
+	)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to build feed")
+		return
+	}
+	defer rows.Close()
+
+	f := feed.Feed{
+		Title:       feedTitle,
+		Description: feedDescription,
+		HomeURL:     h.AppURL + "/zine",
+		FeedURL:     h.APIURL + r.URL.EscapedPath(),
+		Updated:     updated,
+	}
+	if tag != "" {
+		f.Title = feedTitle + ": " + tag
+		f.Description = fmt.Sprintf("%s tagged %q", feedDescription, tag)
+	}
+	for rows.Next() {
+		p, err := scanPost(rows)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to build feed")
+			return
+		}
+		link := h.AppURL + "/zine/" + url.PathEscape(p.Slug)
+		author := feedAuthor
+		if p.Author != nil && strings.TrimSpace(*p.Author) != "" {
+			author = *p.Author
+		}
+		published := p.CreatedAt
+		if p.PublishedAt != nil {
+			published = *p.PublishedAt
+		} else if d, err := time.Parse(time.DateOnly, p.Date); err == nil {
+			published = d
+		}
+		f.Items = append(f.Items, feed.Item{
+			ID:          link,
+			URL:         link,
+			Title:       p.Title,
+			Summary:     p.Excerpt,
+			ContentHTML: p.ContentHTML,
+			Author:      author,
+			Tags:        p.Tags,
+			Published:   published,
+			Updated:     p.UpdatedAt,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to build feed")
+		return
+	}
+
+	body, err := format.build(f)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to build feed")
+		return
+	}
+	w.Header().Set("Content-Type", format.contentType)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(body)
+}
+
+// feedETag identifies a feed's content from what it is built from. The
+// render version is included because re-rendering does not touch
+// updated_at. The tag is weak since responses may be compressed.
+func feedETag(format, tag string, count int64, updated time.Time) string {
+	sum := sha256.Sum256(fmt.Appendf(nil, "%s\x00%s\x00%d\x00%d\x00%d",
+		format, tag, count, updated.Unix(), render.Version))
+	return `W/"` + hex.EncodeToString(sum[:12]) + `"`
+}
+
+// notModified evaluates If-None-Match, or If-Modified-Since when no ETags
+// are given, as RFC 9110 describes for GET.
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" {
+		t, err := http.ParseTime(ims)
+		return err == nil && !lastModified.Truncate(time.Second).After(t)
+	}
+	return false
+}
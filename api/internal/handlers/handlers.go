@@ -2,20 +2,41 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"math"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 
+	"github.com/subculture-collective/subcult-tv/api/internal/config"
+	"github.com/subculture-collective/subcult-tv/api/internal/jwtkeys"
+	"github.com/subculture-collective/subcult-tv/api/internal/mail"
+	"github.com/subculture-collective/subcult-tv/api/internal/oidc"
 	"github.com/subculture-collective/subcult-tv/api/internal/patreon"
 )
 
 // Handler bundles dependencies for all HTTP handlers.
 type Handler struct {
-	DB       *pgxpool.Pool
-	JWTSecret string
-	Patreon  *patreon.Client
+	DB              *pgxpool.Pool
+	Keys            *jwtkeys.Set // access tokens, published in the JWKS
+	InternalKeys    *jwtkeys.Set // MFA-pending and preview tokens
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	AppURL          string
+	APIURL          string
+	CookieSecure    bool
+	CookieDomain    string
+	Patreon         *patreon.Client
+	Mailer          mail.Sender
+	OIDC            *oidc.Provider    // nil when single sign-on is disabled
+	OIDCGroupRoles  map[string]string // IdP group → local role
+	RevisionsKeep   int               // post revisions kept per post; 0 keeps all
+
+	related relatedCache
 }
 
 // Pagination defaults.
@@ -25,8 +46,35 @@ const (
 )
 
 // New creates a new Handler.
-func New(db *pgxpool.Pool, jwtSecret string, patreonClient *patreon.Client) *Handler {
-	return &Handler{DB: db, JWTSecret: jwtSecret, Patreon: patreonClient}
+func New(db *pgxpool.Pool, cfg *config.Config, keys *jwtkeys.Set, patreonClient *patreon.Client, mailer mail.Sender) *Handler {
+	var provider *oidc.Provider
+	if cfg.OIDCIssuer != "" {
+		provider = oidc.New(oidc.Config{
+			Issuer:       cfg.OIDCIssuer,
+			ClientID:     cfg.OIDCClientID,
+			ClientSecret: cfg.OIDCClientSecret,
+			RedirectURL:  cfg.OIDCRedirectURL,
+			Scopes:       cfg.OIDCScopes,
+			GroupsClaim:  cfg.OIDCGroupsClaim,
+		})
+	}
+
+	return &Handler{
+		DB:              db,
+		Keys:            keys,
+		InternalKeys:    jwtkeys.Internal(cfg.JWTSecret),
+		AccessTokenTTL:  cfg.AccessTokenTTL,
+		RefreshTokenTTL: cfg.RefreshTokenTTL,
+		AppURL:          cfg.AppURL,
+		APIURL:          cfg.APIURL,
+		CookieSecure:    cfg.CookieSecure,
+		CookieDomain:    cfg.CookieDomain,
+		Patreon:         patreonClient,
+		Mailer:          mailer,
+		OIDC:            provider,
+		OIDCGroupRoles:  cfg.OIDCGroupRoles,
+		RevisionsKeep:   cfg.PostRevisionsKeep,
+	}
 }
 
 // ── Helpers ──────────────────────────────────────────────────
@@ -60,3 +108,33 @@ func pagination(r *http.Request) (page, perPage int, offset int) {
 func totalPages(total int64, perPage int) int {
 	return int(math.Ceil(float64(total) / float64(perPage)))
 }
+
+// queryList collects a query parameter given repeatedly or as a
+// comma-separated list, trimming blanks and duplicates.
+func queryList(r *http.Request, key string) []string {
+	var out []string
+	seen := map[string]bool{}
+	for _, raw := range r.URL.Query()[key] {
+		for _, item := range strings.Split(raw, ",") {
+			item = strings.TrimSpace(item)
+			if item != "" && !seen[item] {
+				seen[item] = true
+				out = append(out, item)
+			}
+		}
+	}
+	return out
+}
+
+// isUniqueViolation reports whether err is a Postgres unique constraint error.
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+// isForeignKeyViolation reports whether err is a Postgres foreign key
+// constraint error.
+func isForeignKeyViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23503"
+}
@@ -0,0 +1,311 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/subculture-collective/subcult-tv/api/internal/models"
+)
+
+// issueColumns are the columns scanIssue reads, prefixed with i. for use
+// next to posts. The public post count is included.
+const issueColumns = `i.id, i.number, i.title, i.description, i.cover_url, i.created_at, i.updated_at,
+	(SELECT COUNT(*) FROM issue_posts ip JOIN posts ON posts.id = ip.post_id
+	 WHERE ip.issue_id = i.id AND ` + postIsPublic + `)`
+
+// scanIssue scans a row of issueColumns into a models.Issue.
+func scanIssue(s scanner) (models.Issue, error) {
+	var i models.Issue
+	err := s.Scan(&i.ID, &i.Number, &i.Title, &i.Description, &i.CoverURL,
+		&i.CreatedAt, &i.UpdatedAt, &i.PostCount)
+	return i, err
+}
+
+// ListIssues returns issues with at least one public post, newest first.
+func (h *Handler) ListIssues(w http.ResponseWriter, r *http.Request) {
+	h.listIssues(w, r, false)
+}
+
+// ListAllIssues is ListIssues including empty issues, with each issue's
+// posts (admin only).
+func (h *Handler) ListAllIssues(w http.ResponseWriter, r *http.Request) {
+	h.listIssues(w, r, true)
+}
+
+func (h *Handler) listIssues(w http.ResponseWriter, r *http.Request, admin bool) {
+	query := `SELECT ` + issueColumns + `,
+	  ARRAY(SELECT post_id FROM issue_posts WHERE issue_id = i.id ORDER BY position)
+	 FROM issues i ORDER BY i.number DESC`
+
+	rows, err := h.DB.Query(r.Context(), query)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to query issues")
+		return
+	}
+	defer rows.Close()
+
+	issues := []models.Issue{}
+	for rows.Next() {
+		var i models.Issue
+		if err := rows.Scan(&i.ID, &i.Number, &i.Title, &i.Description, &i.CoverURL,
+			&i.CreatedAt, &i.UpdatedAt, &i.PostCount, &i.PostIDs); err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to scan issue")
+			return
+		}
+		if !admin {
+			if i.PostCount == 0 {
+				continue
+			}
+			i.PostIDs = nil
+		}
+		issues = append(issues, i)
+	}
+
+	writeJSON(w, http.StatusOK, issues)
+}
+
+// GetIssue returns an issue by number with its public posts in reading
+// order. Issues without public posts are not found.
+func (h *Handler) GetIssue(w http.ResponseWriter, r *http.Request) {
+	number, err := strconv.Atoi(chi.URLParam(r, "number"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, "issue not found")
+		return
+	}
+
+	issue, err := scanIssue(h.DB.QueryRow(r.Context(),
+		`SELECT `+issueColumns+` FROM issues i WHERE i.number = $1`, number,
+	))
+	if err != nil || issue.PostCount == 0 {
+		writeError(w, http.StatusNotFound, "issue not found")
+		return
+	}
+
+	rows, err := h.DB.Query(r.Context(),
+		`SELECT `+postColumns+` FROM posts JOIN issue_posts ON issue_posts.post_id = posts.id
+		 WHERE issue_posts.issue_id = $1 AND `+postIsPublic+`
+		 ORDER BY issue_posts.position`, issue.ID,
+	)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to query posts")
+		return
+	}
+	defer rows.Close()
+
+	detail := models.IssueDetail{Issue: issue, Posts: []models.Post{}}
+	for rows.Next() {
+		p, err := scanPost(rows)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to scan post")
+			return
+		}
+		detail.Posts = append(detail.Posts, p)
+	}
+
+	writeJSON(w, http.StatusOK, detail)
+}
+
+// CreateIssue creates an issue with its posts (admin only).
+func (h *Handler) CreateIssue(w http.ResponseWriter, r *http.Request) {
+	h.saveIssue(w, r, "")
+}
+
+// UpdateIssue replaces an issue and its list of posts (admin only).
+func (h *Handler) UpdateIssue(w http.ResponseWriter, r *http.Request) {
+	h.saveIssue(w, r, chi.URLParam(r, "id"))
+}
+
+// saveIssue creates the issue when id is empty and updates it otherwise.
+func (h *Handler) saveIssue(w http.ResponseWriter, r *http.Request, id string) {
+	var req models.CreateIssueRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	req.Title = strings.TrimSpace(req.Title)
+	if req.Number < 1 || req.Title == "" {
+		writeError(w, http.StatusBadRequest, "a positive number and a title are required")
+		return
+	}
+	postIDs := make([]string, len(req.PostIDs))
+	seen := map[uuid.UUID]bool{}
+	for n, postID := range req.PostIDs {
+		if seen[postID] {
+			writeError(w, http.StatusBadRequest, "post listed twice: "+postID.String())
+			return
+		}
+		seen[postID] = true
+		postIDs[n] = postID.String()
+	}
+
+	ctx := r.Context()
+	tx, err := h.DB.Begin(ctx)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to save issue")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	var found int
+	if err := tx.QueryRow(ctx,
+		`SELECT COUNT(*) FROM posts WHERE id = ANY($1::uuid[])`, postIDs,
+	).Scan(&found); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to save issue")
+		return
+	}
+	if found != len(postIDs) {
+		writeError(w, http.StatusBadRequest, "unknown post in post_ids")
+		return
+	}
+
+	var issueID uuid.UUID
+	if id == "" {
+		err = tx.QueryRow(ctx,
+			`INSERT INTO issues (number, title, description, cover_url)
+			 VALUES ($1,$2,$3,$4) RETURNING id`,
+			req.Number, req.Title, req.Description, req.CoverURL,
+		).Scan(&issueID)
+	} else {
+		err = tx.QueryRow(ctx,
+			`UPDATE issues SET number=$1, title=$2, description=$3, cover_url=$4, updated_at=NOW()
+			 WHERE id=$5 RETURNING id`,
+			req.Number, req.Title, req.Description, req.CoverURL, id,
+		).Scan(&issueID)
+	}
+	if isUniqueViolation(err) {
+		writeError(w, http.StatusConflict, "issue number already in use")
+		return
+	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		writeError(w, http.StatusNotFound, "issue not found")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to save issue")
+		return
+	}
+
+	if err := setIssuePosts(ctx, tx, issueID, postIDs); err != nil {
+		if isUniqueViolation(err) {
+			writeError(w, http.StatusConflict, "a post is already in another issue")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "failed to save issue")
+		return
+	}
+
+	issue, err := scanIssue(tx.QueryRow(ctx,
+		`SELECT `+issueColumns+` FROM issues i WHERE i.id = $1`, issueID,
+	))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to save issue")
+		return
+	}
+	issue.PostIDs = req.PostIDs
+	if err := tx.Commit(ctx); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to save issue")
+		return
+	}
+
+	status := http.StatusOK
+	if id == "" {
+		status = http.StatusCreated
+	}
+	writeJSON(w, status, issue)
+}
+
+// setIssuePosts replaces an issue's posts with postIDs in order.
+func setIssuePosts(ctx context.Context, tx pgx.Tx, issueID uuid.UUID, postIDs []string) error {
+	if _, err := tx.Exec(ctx, `DELETE FROM issue_posts WHERE issue_id = $1`, issueID); err != nil {
+		return err
+	}
+	_, err := tx.Exec(ctx,
+		`INSERT INTO issue_posts (issue_id, post_id, position)
+		 SELECT $1::uuid, post_id, position FROM unnest($2::uuid[]) WITH ORDINALITY AS t(post_id, position)`,
+		issueID, postIDs,
+	)
+	return err
+}
+
+// DeleteIssue deletes an issue; its posts are kept (admin only).
+func (h *Handler) DeleteIssue(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	tag, err := h.DB.Exec(r.Context(), `DELETE FROM issues WHERE id = $1`, id)
+	if err != nil || tag.RowsAffected() == 0 {
+		writeError(w, http.StatusNotFound, "issue not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// postIssue places a public post in its issue, or returns nil if it is in
+// none.
+func (h *Handler) postIssue(ctx context.Context, postID uuid.UUID) (*models.PostIssue, error) {
+	rows, err := h.DB.Query(ctx,
+		`SELECT i.number, i.title, posts.id, posts.slug, posts.title
+		 FROM issue_posts this_post
+		 JOIN issues i ON i.id = this_post.issue_id
+		 JOIN issue_posts ip ON ip.issue_id = this_post.issue_id
+		 JOIN posts ON posts.id = ip.post_id
+		 WHERE this_post.post_id = $1 AND `+postIsPublic+`
+		 ORDER BY ip.position`, postID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var (
+		issue *models.PostIssue
+		ids   []uuid.UUID
+		links []models.PostLink
+	)
+	for rows.Next() {
+		var (
+			id   uuid.UUID
+			link models.PostLink
+		)
+		if issue == nil {
+			issue = &models.PostIssue{}
+		}
+		if err := rows.Scan(&issue.Number, &issue.Title, &id, &link.Slug, &link.Title); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+		links = append(links, link)
+	}
+	if err := rows.Err(); err != nil || issue == nil {
+		return nil, err
+	}
+	issue.Position, issue.Previous, issue.Next = neighbours(ids, links, postID)
+	return issue, nil
+}
+
+// neighbours finds id in an issue's posts and returns its 1-based position
+// and the posts either side of it, or 0 and nils if it is not there.
+func neighbours(ids []uuid.UUID, links []models.PostLink, id uuid.UUID) (int, *models.PostLink, *models.PostLink) {
+	for n, candidate := range ids {
+		if candidate != id {
+			continue
+		}
+		var prev, next *models.PostLink
+		if n > 0 {
+			prev = &links[n-1]
+		}
+		if n+1 < len(links) {
+			next = &links[n+1]
+		}
+		return n + 1, prev, next
+	}
+	return 0, nil, nil
+}
@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Account lockout policy. After lockoutThreshold consecutive failures the
+// account is locked for lockoutBase, doubling with every further failure
+// up to lockoutMax. Failures older than failureWindow are forgotten.
+const (
+	lockoutThreshold = 5
+	lockoutBase      = 30 * time.Second
+	lockoutMax       = time.Hour
+	failureWindow    = 24 * time.Hour
+)
+
+var loginFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "subcult_auth_login_failures_total",
+	Help: "Failed login attempts by reason (credentials, mfa, locked).",
+}, []string{"reason"})
+
+// dummyHash is compared against when the username does not exist so that
+// unknown users take as long to reject as wrong passwords.
+var dummyHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("subcult-timing-equalizer"), bcrypt.DefaultCost)
+	return hash
+})
+
+// lockoutDuration returns how long an account stays locked after
+// failedCount consecutive failures.
+func lockoutDuration(failedCount int) time.Duration {
+	if failedCount < lockoutThreshold {
+		return 0
+	}
+	d := lockoutBase
+	for i := lockoutThreshold; i < failedCount && d < lockoutMax; i++ {
+		d *= 2
+	}
+	if d > lockoutMax {
+		d = lockoutMax
+	}
+	return d
+}
+
+// throttleKey normalizes a username into a login_failures key.
+func throttleKey(username string) string {
+	key := strings.ToLower(strings.TrimSpace(username))
+	if r := []rune(key); len(r) > 50 {
+		key = string(r[:50])
+	}
+	return key
+}
+
+// loginLockedFor returns how much longer username is locked out, or zero.
+func (h *Handler) loginLockedFor(ctx context.Context, username string) (time.Duration, error) {
+	var lockedUntil *time.Time
+	err := h.DB.QueryRow(ctx,
+		`SELECT locked_until FROM login_failures WHERE username = $1`, throttleKey(username),
+	).Scan(&lockedUntil)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if lockedUntil == nil {
+		return 0, nil
+	}
+	if remaining := time.Until(*lockedUntil); remaining > 0 {
+		return remaining, nil
+	}
+	return 0, nil
+}
+
+// recordLoginFailure counts a failed attempt against username and locks it
+// once the threshold is reached.
+func (h *Handler) recordLoginFailure(ctx context.Context, username, reason string) {
+	loginFailures.WithLabelValues(reason).Inc()
+
+	var count int
+	err := h.DB.QueryRow(ctx,
+		`INSERT INTO login_failures (username, failed_count, last_failed_at)
+		 VALUES ($1, 1, NOW())
+		 ON CONFLICT (username) DO UPDATE SET
+		   failed_count = CASE
+		     WHEN login_failures.last_failed_at < NOW() - make_interval(secs => $2) THEN 1
+		     ELSE login_failures.failed_count + 1
+		   END,
+		   last_failed_at = NOW()
+		 RETURNING failed_count`, throttleKey(username), failureWindow.Seconds(),
+	).Scan(&count)
+	if err != nil {
+		slog.Error("record login failure", "error", err)
+		return
+	}
+
+	if d := lockoutDuration(count); d > 0 {
+		if _, err := h.DB.Exec(ctx,
+			`UPDATE login_failures SET locked_until = NOW() + make_interval(secs => $2)
+			 WHERE username = $1`, throttleKey(username), d.Seconds(),
+		); err != nil {
+			slog.Error("lock account", "error", err)
+		}
+	}
+}
+
+// clearLoginFailures resets the failure count after a successful login.
+func (h *Handler) clearLoginFailures(ctx context.Context, username string) {
+	if _, err := h.DB.Exec(ctx,
+		`DELETE FROM login_failures WHERE username = $1`, throttleKey(username),
+	); err != nil {
+		slog.Error("clear login failures", "error", err)
+	}
+}
+
+// writeLocked responds to a login attempt on a locked account. Unknown
+// usernames are locked the same way, so this reveals nothing.
+func writeLocked(w http.ResponseWriter, remaining time.Duration) {
+	loginFailures.WithLabelValues("locked").Inc()
+	w.Header().Set("Retry-After", strconv.Itoa(int(remaining.Seconds())+1))
+	writeError(w, http.StatusTooManyRequests, "too many failed attempts, try again later")
+}
+
+// UnlockUser clears a user's failed login attempts and lockout (admin only).
+func (h *Handler) UnlockUser(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var username string
+	if err := h.DB.QueryRow(r.Context(), `SELECT username FROM users WHERE id = $1`, id).Scan(&username); err != nil {
+		writeError(w, http.StatusNotFound, "user not found")
+		return
+	}
+
+	if _, err := h.DB.Exec(r.Context(),
+		`DELETE FROM login_failures WHERE username = $1`, throttleKey(username),
+	); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to unlock user")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
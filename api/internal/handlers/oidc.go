@@ -0,0 +1,357 @@
+package handlers
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/subculture-collective/subcult-tv/api/internal/middleware"
+	"github.com/subculture-collective/subcult-tv/api/internal/models"
+	"github.com/subculture-collective/subcult-tv/api/internal/oidc"
+)
+
+const (
+	// oidcLoginTTL bounds how long a user may spend at the provider.
+	oidcLoginTTL = 10 * time.Minute
+	// oidcHandoffTTL bounds how long the frontend has to redeem a login.
+	oidcHandoffTTL = time.Minute
+	// oidcStateCookie binds the authorization request to the browser that
+	// started it, so a callback URL cannot be replayed in another browser.
+	oidcStateCookie = "subcult_oidc_state"
+	oidcCookiePath  = "/api/v1/auth/oidc"
+)
+
+var (
+	errOIDCNoAccount = errors.New("no account for this identity")
+	errOIDCConflict  = errors.New("email is linked to another identity")
+	errOIDCDisabled  = errors.New("account disabled")
+)
+
+// roleRank orders roles from least to most privileged.
+var roleRank = map[string]int{
+	middleware.RoleModerator: 1,
+	middleware.RoleEditor:    2,
+	middleware.RoleAdmin:     3,
+}
+
+// roleForGroups returns the most privileged role mapped from any of groups,
+// or "" when none is mapped.
+func roleForGroups(groups []string, mapping map[string]string) string {
+	role := ""
+	for _, g := range groups {
+		if r := mapping[g]; roleRank[r] > roleRank[role] {
+			role = r
+		}
+	}
+	return role
+}
+
+// OIDCStatus reports whether single sign-on is available.
+func (h *Handler) OIDCStatus(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, models.OIDCStatusResponse{Enabled: h.OIDC != nil})
+}
+
+// OIDCLogin starts an authorization code + PKCE login by redirecting to the
+// identity provider.
+func (h *Handler) OIDCLogin(w http.ResponseWriter, r *http.Request) {
+	if h.OIDC == nil {
+		writeError(w, http.StatusNotFound, "single sign-on is not configured")
+		return
+	}
+
+	state, stateHash, err := newOpaqueToken()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to generate state")
+		return
+	}
+	nonce, _, err := newOpaqueToken()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to generate nonce")
+		return
+	}
+	verifier, err := oidc.NewVerifier()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to generate verifier")
+		return
+	}
+
+	authURL, err := h.OIDC.AuthURL(r.Context(), state, nonce, verifier)
+	if err != nil {
+		slog.Error("oidc login", "error", err)
+		writeError(w, http.StatusBadGateway, "identity provider unavailable")
+		return
+	}
+
+	if _, err := h.DB.Exec(r.Context(),
+		`DELETE FROM oidc_logins WHERE expires_at < NOW()`,
+	); err != nil {
+		slog.Error("purge oidc logins", "error", err)
+	}
+	if _, err := h.DB.Exec(r.Context(),
+		`INSERT INTO oidc_logins (state_hash, nonce, code_verifier, expires_at) VALUES ($1, $2, $3, $4)`,
+		stateHash, nonce, verifier, time.Now().Add(oidcLoginTTL),
+	); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to start login")
+		return
+	}
+
+	// Lax, not Strict: the cookie must survive the top-level redirect back
+	// from the provider.
+	cookie := h.newCookie(oidcStateCookie, state, oidcCookiePath, int(oidcLoginTTL.Seconds()), true)
+	cookie.SameSite = http.SameSiteLaxMode
+	http.SetCookie(w, cookie)
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// OIDCCallback completes the provider redirect. It maps the verified
+// identity to a local user and hands the frontend a one-time code, in the
+// URL fragment, to exchange for a session at OIDCExchange.
+func (h *Handler) OIDCCallback(w http.ResponseWriter, r *http.Request) {
+	if h.OIDC == nil {
+		writeError(w, http.StatusNotFound, "single sign-on is not configured")
+		return
+	}
+
+	fail := func(reason string) {
+		http.Redirect(w, r, h.AppURL+"/admin/login#oidc_error="+url.QueryEscape(reason), http.StatusFound)
+	}
+
+	expired := h.newCookie(oidcStateCookie, "", oidcCookiePath, -1, true)
+	expired.SameSite = http.SameSiteLaxMode
+	http.SetCookie(w, expired)
+
+	q := r.URL.Query()
+	if e := q.Get("error"); e != "" {
+		fail(e)
+		return
+	}
+	state := q.Get("state")
+	cookie, err := r.Cookie(oidcStateCookie)
+	if state == "" || err != nil || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(state)) != 1 {
+		fail("invalid_state")
+		return
+	}
+
+	var nonce, verifier string
+	err = h.DB.QueryRow(r.Context(),
+		`DELETE FROM oidc_logins WHERE state_hash = $1 AND expires_at > NOW()
+		 RETURNING nonce, code_verifier`, hashToken(state),
+	).Scan(&nonce, &verifier)
+	if err != nil {
+		fail("invalid_state")
+		return
+	}
+
+	claims, err := h.OIDC.Exchange(r.Context(), q.Get("code"), verifier, nonce)
+	if err != nil {
+		slog.Warn("oidc exchange", "error", err)
+		fail("invalid_grant")
+		return
+	}
+
+	user, err := h.resolveOIDCUser(r.Context(), claims)
+	switch {
+	case errors.Is(err, errOIDCNoAccount), errors.Is(err, errOIDCConflict), errors.Is(err, errOIDCDisabled):
+		slog.Warn("oidc login refused", "sub", claims.Subject, "reason", err)
+		fail("access_denied")
+		return
+	case err != nil:
+		slog.Error("oidc resolve user", "error", err)
+		fail("server_error")
+		return
+	}
+
+	code, codeHash, err := newOpaqueToken()
+	if err != nil {
+		fail("server_error")
+		return
+	}
+	if _, err := h.DB.Exec(r.Context(),
+		`INSERT INTO oidc_handoffs (code_hash, user_id, expires_at) VALUES ($1, $2, $3)`,
+		codeHash, user.ID, time.Now().Add(oidcHandoffTTL),
+	); err != nil {
+		fail("server_error")
+		return
+	}
+
+	http.Redirect(w, r, h.AppURL+"/admin/login#oidc_code="+url.QueryEscape(code), http.StatusFound)
+}
+
+// OIDCExchange redeems a one-time code from OIDCCallback for a session,
+// returned like Login's. Users who need a second factor get the same MFA
+// challenge as after a password, to finish at /auth/login/2fa.
+func (h *Handler) OIDCExchange(w http.ResponseWriter, r *http.Request) {
+	var req models.OIDCExchangeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Code == "" {
+		writeError(w, http.StatusBadRequest, "code is required")
+		return
+	}
+
+	var user models.User
+	err := h.DB.QueryRow(r.Context(),
+		`WITH redeemed AS (
+		   DELETE FROM oidc_handoffs WHERE code_hash = $1 AND expires_at > NOW() RETURNING user_id
+		 )
+		 SELECT u.id, u.username, u.email, u.role, u.totp_enabled_at IS NOT NULL, u.created_at, u.updated_at
+		 FROM users u JOIN redeemed ON redeemed.user_id = u.id
+		 WHERE u.disabled_at IS NULL`, hashToken(req.Code),
+	).Scan(&user.ID, &user.Username, &user.Email, &user.Role, &user.TOTPEnabled, &user.CreatedAt, &user.UpdatedAt)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "invalid or expired code")
+		return
+	}
+
+	h.loginOrChallenge(w, r, user, req.Mode == loginModeCookie)
+}
+
+// resolveOIDCUser finds the local user for a verified identity: by linked
+// subject, then by verified email (linking it), then by provisioning a new
+// user when the groups map to a role. Mapped group roles are applied on
+// every login.
+func (h *Handler) resolveOIDCUser(ctx context.Context, claims *oidc.Claims) (models.User, error) {
+	role := roleForGroups(claims.Groups, h.OIDCGroupRoles)
+
+	tx, err := h.DB.Begin(ctx)
+	if err != nil {
+		return models.User{}, err
+	}
+	defer tx.Rollback(ctx)
+
+	user, err := scanUser(tx.QueryRow(ctx,
+		`SELECT id, username, email, role, totp_enabled_at IS NOT NULL, disabled_at, created_at, updated_at
+		 FROM users WHERE oidc_subject = $1 FOR UPDATE`, claims.Subject,
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		if !claims.EmailVerified || claims.Email == "" {
+			return models.User{}, errOIDCNoAccount
+		}
+		user, err = scanUser(tx.QueryRow(ctx,
+			`UPDATE users SET oidc_subject = $2, updated_at = NOW()
+			 WHERE lower(email) = lower($1) AND oidc_subject IS NULL
+			 RETURNING id, username, email, role, totp_enabled_at IS NOT NULL, disabled_at, created_at, updated_at`,
+			claims.Email, claims.Subject,
+		))
+		if errors.Is(err, pgx.ErrNoRows) {
+			if role == "" {
+				return models.User{}, errOIDCNoAccount
+			}
+			user, err = provisionOIDCUser(ctx, tx, claims, role)
+		}
+	}
+	if err != nil {
+		return models.User{}, err
+	}
+	if user.DisabledAt != nil {
+		return models.User{}, errOIDCDisabled
+	}
+
+	if role != "" && role != user.Role {
+		if user.Role == middleware.RoleAdmin {
+			err := ensureNotLastAdmin(ctx, tx, user.ID.String())
+			if errors.Is(err, errLastAdmin) {
+				slog.Warn("oidc role sync skipped for last admin", "user_id", user.ID)
+				role = user.Role
+			} else if err != nil {
+				return models.User{}, err
+			}
+		}
+		if role != user.Role {
+			if _, err := tx.Exec(ctx,
+				`UPDATE users SET role = $2, updated_at = NOW() WHERE id = $1`, user.ID, role,
+			); err != nil {
+				return models.User{}, err
+			}
+			if _, err := tx.Exec(ctx,
+				`UPDATE sessions SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL`, user.ID,
+			); err != nil {
+				return models.User{}, err
+			}
+			user.Role = role
+		}
+	}
+
+	return user, tx.Commit(ctx)
+}
+
+// provisionOIDCUser creates a user for a new identity. The password is
+// random and never shown; the user can set one via password reset.
+func provisionOIDCUser(ctx context.Context, tx pgx.Tx, claims *oidc.Claims, role string) (models.User, error) {
+	password, err := GeneratePassword()
+	if err != nil {
+		return models.User{}, err
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	base := oidcUsername(claims)
+	username := base
+	for i := 2; ; i++ {
+		var taken bool
+		if err := tx.QueryRow(ctx,
+			`SELECT EXISTS (SELECT 1 FROM users WHERE lower(username) = lower($1))`, username,
+		).Scan(&taken); err != nil {
+			return models.User{}, err
+		}
+		if !taken {
+			break
+		}
+		suffix := fmt.Sprintf("-%d", i)
+		username = truncateRunes(base, 50-len(suffix)) + suffix
+	}
+
+	user, err := scanUser(tx.QueryRow(ctx,
+		`INSERT INTO users (username, email, password_hash, role, oidc_subject)
+		 VALUES ($1, $2, $3, $4, $5)
+		 RETURNING id, username, email, role, totp_enabled_at IS NOT NULL, disabled_at, created_at, updated_at`,
+		username, claims.Email, string(hash), role, claims.Subject,
+	))
+	if isUniqueViolation(err) {
+		return models.User{}, errOIDCConflict
+	}
+	if err == nil {
+		slog.Info("oidc user provisioned", "user_id", user.ID, "username", user.Username, "role", role)
+	}
+	return user, err
+}
+
+// oidcUsername derives a username from the identity's claims.
+func oidcUsername(claims *oidc.Claims) string {
+	name := claims.PreferredUsername
+	if name == "" {
+		name, _, _ = strings.Cut(claims.Email, "@")
+	}
+	name = strings.Map(func(r rune) rune {
+		if r == ' ' {
+			return '-'
+		}
+		return r
+	}, strings.TrimSpace(name))
+	if name == "" {
+		name = "member"
+	}
+	return truncateRunes(name, 50)
+}
+
+// truncateRunes shortens s to at most n runes.
+func truncateRunes(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n])
+	}
+	return s
+}
@@ -0,0 +1,288 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/subculture-collective/subcult-tv/api/internal/mail"
+	"github.com/subculture-collective/subcult-tv/api/internal/middleware"
+	"github.com/subculture-collective/subcult-tv/api/internal/models"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// MinPasswordLength is the shortest password accepted for any account.
+	MinPasswordLength = 12
+	// maxPasswordBytes is bcrypt's input limit; longer passwords are
+	// silently truncated by bcrypt, so they are rejected instead.
+	maxPasswordBytes = 72
+	// passwordResetTTL is how long an emailed reset link stays valid.
+	passwordResetTTL = time.Hour
+)
+
+// commonPasswords are rejected outright even when long enough.
+var commonPasswords = map[string]bool{
+	"password1234":     true,
+	"123456789012":     true,
+	"qwertyuiop123":    true,
+	"letmein123456":    true,
+	"administrator":    true,
+	"passwordpassword": true,
+	"subcultsubcult":   true,
+	"changeme1234":     true,
+}
+
+// ValidatePassword enforces the password policy: a minimum length, bcrypt's
+// maximum, at least two character classes, and nothing trivially guessable
+// such as the username or a well-known password.
+func ValidatePassword(password, username string) error {
+	if len(password) < MinPasswordLength {
+		return fmt.Errorf("password must be at least %d characters", MinPasswordLength)
+	}
+	if len(password) > maxPasswordBytes {
+		return fmt.Errorf("password must be at most %d bytes", maxPasswordBytes)
+	}
+
+	var lower, upper, digit, other bool
+	for _, c := range password {
+		switch {
+		case unicode.IsLower(c):
+			lower = true
+		case unicode.IsUpper(c):
+			upper = true
+		case unicode.IsDigit(c):
+			digit = true
+		default:
+			other = true
+		}
+	}
+	classes := 0
+	for _, ok := range []bool{lower, upper, digit, other} {
+		if ok {
+			classes++
+		}
+	}
+	if classes < 2 {
+		return errors.New("password must mix at least two of: lowercase, uppercase, digits, symbols")
+	}
+
+	lowered := strings.ToLower(password)
+	if username != "" && strings.Contains(lowered, strings.ToLower(username)) {
+		return errors.New("password must not contain the username")
+	}
+	if commonPasswords[lowered] {
+		return errors.New("password is too common")
+	}
+	return nil
+}
+
+// ChangePassword changes the current user's password after verifying the
+// old one. Every other session of the user is revoked. Personal access
+// tokens are kept: the caller is signed in and knows the old password, so
+// this is not a recovery, and automation should not break on a routine
+// change. A compromised account is recovered with a reset instead.
+func (h *Handler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value(middleware.UserIDKey).(string)
+	sessionID, _ := r.Context().Value(middleware.SessionIDKey).(string)
+
+	var req models.ChangePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	var username, hash string
+	if err := h.DB.QueryRow(r.Context(),
+		`SELECT username, password_hash FROM users WHERE id = $1`, userID,
+	).Scan(&username, &hash); err != nil {
+		writeError(w, http.StatusNotFound, "user not found")
+		return
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(hash), []byte(req.CurrentPassword)) != nil {
+		writeError(w, http.StatusUnauthorized, "current password is incorrect")
+		return
+	}
+	if err := ValidatePassword(req.NewPassword, username); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	newHash, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to hash password")
+		return
+	}
+
+	ctx := r.Context()
+	tx, err := h.DB.Begin(ctx)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to change password")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx,
+		`UPDATE users SET password_hash = $1, updated_at = NOW() WHERE id = $2`, string(newHash), userID,
+	); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to change password")
+		return
+	}
+	if _, err := tx.Exec(ctx,
+		`UPDATE sessions SET revoked_at = NOW()
+		 WHERE user_id = $1 AND id <> $2 AND revoked_at IS NULL`, userID, sessionID,
+	); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to revoke sessions")
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to change password")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ForgotPassword emails a single-use reset link (public). The response is
+// the same whether or not the address belongs to a user, and the email is
+// sent in the background so timing does not reveal it either.
+func (h *Handler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var req models.ForgotPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	email := strings.TrimSpace(req.Email)
+	if email == "" {
+		writeError(w, http.StatusBadRequest, "email is required")
+		return
+	}
+
+	go h.sendPasswordReset(email)
+
+	writeJSON(w, http.StatusAccepted, map[string]string{
+		"message": "if that address belongs to an account, a reset link is on its way",
+	})
+}
+
+// sendPasswordReset creates a reset token for the user with the given email
+// (if any) and mails the link. Errors are logged, never returned to the
+// requester.
+func (h *Handler) sendPasswordReset(email string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var userID, username string
+	err := h.DB.QueryRow(ctx,
+		`SELECT id, username FROM users WHERE lower(email) = lower($1) AND disabled_at IS NULL`, email,
+	).Scan(&userID, &username)
+	if err != nil {
+		return // unknown address: nothing to send
+	}
+
+	token, hash, err := newOpaqueToken()
+	if err != nil {
+		slog.Error("password reset token", "error", err)
+		return
+	}
+
+	if _, err := h.DB.Exec(ctx,
+		`INSERT INTO password_resets (user_id, token_hash, expires_at) VALUES ($1, $2, $3)`,
+		userID, hash, time.Now().Add(passwordResetTTL),
+	); err != nil {
+		slog.Error("password reset insert", "error", err)
+		return
+	}
+
+	link := h.AppURL + "/admin/reset-password?token=" + url.QueryEscape(token)
+	err = h.Mailer.Send(ctx, mail.Message{
+		To:      email,
+		Subject: "Reset your SUBCULT password",
+		Body: fmt.Sprintf("Hi %s,\n\n"+
+			"Someone asked to reset the password for your SUBCULT account.\n"+
+			"Open this link within %d minutes to choose a new one:\n\n%s\n\n"+
+			"If this wasn't you, ignore this email; your password is unchanged.\n",
+			username, int(passwordResetTTL.Minutes()), link),
+	})
+	if err != nil {
+		slog.Error("password reset email", "error", err)
+	}
+}
+
+// ResetPassword sets a new password using an emailed reset token (public).
+// The token and any other outstanding tokens for the user are consumed, and
+// all of the user's sessions and personal access tokens are revoked.
+func (h *Handler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req models.CompletePasswordResetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Token == "" || req.NewPassword == "" {
+		writeError(w, http.StatusBadRequest, "token and new_password are required")
+		return
+	}
+
+	ctx := r.Context()
+	tx, err := h.DB.Begin(ctx)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to reset password")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	var userID, username string
+	err = tx.QueryRow(ctx,
+		`SELECT u.id, u.username FROM password_resets p JOIN users u ON u.id = p.user_id
+		 WHERE p.token_hash = $1 AND p.used_at IS NULL AND p.expires_at > NOW()
+		   AND u.disabled_at IS NULL
+		 FOR UPDATE OF p`, hashToken(req.Token),
+	).Scan(&userID, &username)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid or expired reset token")
+		return
+	}
+
+	if err := ValidatePassword(req.NewPassword, username); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to hash password")
+		return
+	}
+
+	statements := []struct {
+		sql  string
+		args []interface{}
+	}{
+		{`UPDATE users SET password_hash = $1, updated_at = NOW() WHERE id = $2`, []interface{}{string(hash), userID}},
+		{`UPDATE password_resets SET used_at = NOW() WHERE user_id = $1 AND used_at IS NULL`, []interface{}{userID}},
+		{`UPDATE sessions SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL`, []interface{}{userID}},
+		{`UPDATE api_tokens SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL`, []interface{}{userID}},
+	}
+	for _, s := range statements {
+		if _, err := tx.Exec(ctx, s.sql, s.args...); err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to reset password")
+			return
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to reset password")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -3,47 +3,92 @@ package handlers
 import (
 	"encoding/json"
 	"fmt"
+	"html"
 	"net/http"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/subculture-collective/subcult-tv/api/internal/models"
+	"github.com/subculture-collective/subcult-tv/api/internal/render"
 )
 
+// postColumns is the column list scanPost expects. Authors come as a JSON
+// array in byline order.
+const postColumns = `id, slug, title, excerpt, content, content_html, toc, word_count,
+	tags, author, published, date, publish_at, published_at, created_at, updated_at,
+	(SELECT COALESCE(json_agg(json_build_object(
+	   'id', a.id, 'slug', a.slug, 'name', a.name, 'avatar_url', a.avatar_url) ORDER BY pa.position), '[]')
+	 FROM post_authors pa JOIN authors a ON a.id = pa.author_id WHERE pa.post_id = posts.id)`
+
+// reservedPostSlugs would be shadowed by fixed routes under /posts and
+// /zine.
+var reservedPostSlugs = map[string]bool{"search": true, "preview": true}
+
+// postIsPublic matches posts that are published and whose scheduled time,
+// if any, has passed.
+const postIsPublic = `published = true AND (publish_at IS NULL OR publish_at <= NOW())`
+
 // scanPost scans a full post row into a models.Post.
 func scanPost(s scanner) (models.Post, error) {
 	var p models.Post
 	err := s.Scan(
-		&p.ID, &p.Slug, &p.Title, &p.Excerpt, &p.Content,
+		&p.ID, &p.Slug, &p.Title, &p.Excerpt, &p.Content, &p.ContentHTML, &p.TOC, &p.WordCount,
 		&p.Tags, &p.Author, &p.Published, &p.Date,
-		&p.CreatedAt, &p.UpdatedAt,
+		&p.PublishAt, &p.PublishedAt, &p.CreatedAt, &p.UpdatedAt, &p.Authors,
 	)
+	p.ReadingTime = render.ReadingTime(p.WordCount)
 	return p, err
 }
 
-// ListPosts returns published posts (public) or all posts (admin).
+// ListPosts returns public posts, newest first. ?tag= filters by tag; give
+// several (repeated or comma-separated) to match posts with any of them,
+// or all of them with ?match=all.
 func (h *Handler) ListPosts(w http.ResponseWriter, r *http.Request) {
+	h.listPosts(w, r, false)
+}
+
+// ListAllPosts is ListPosts including drafts and scheduled posts (admin
+// only).
+func (h *Handler) ListAllPosts(w http.ResponseWriter, r *http.Request) {
+	h.listPosts(w, r, true)
+}
+
+func (h *Handler) listPosts(w http.ResponseWriter, r *http.Request, includeUnpublished bool) {
 	page, perPage, offset := pagination(r)
-	onlyPublished := r.URL.Query().Get("all") != "true"
 
-	var total int64
-	countQuery := `SELECT COUNT(*) FROM posts`
-	if onlyPublished {
-		countQuery += ` WHERE published = true`
+	var where []string
+	var args []interface{}
+	if !includeUnpublished {
+		where = append(where, postIsPublic)
 	}
-	if err := h.DB.QueryRow(r.Context(), countQuery).Scan(&total); err != nil {
-		writeError(w, http.StatusInternalServerError, "failed to count posts")
-		return
+
+	tags := queryList(r, "tag")
+	if len(tags) > 0 {
+		switch r.URL.Query().Get("match") {
+		case "", "any":
+			where = append(where, fmt.Sprintf(`tags && $%d::text[]`, len(args)+1))
+		case "all":
+			where = append(where, fmt.Sprintf(`tags @> $%d::text[]`, len(args)+1))
+		default:
+			writeError(w, http.StatusBadRequest, "match must be any or all")
+			return
+		}
+		args = append(args, tags)
 	}
 
-	query := `SELECT id, slug, title, excerpt, content, tags, author, published, date,
-	           created_at, updated_at FROM posts`
-	args := []interface{}{}
-	argN := 1
+	filter := ""
+	if len(where) > 0 {
+		filter = ` WHERE ` + strings.Join(where, ` AND `)
+	}
 
-	if onlyPublished {
-		query += ` WHERE published = true`
+	var total int64
+	if err := h.DB.QueryRow(r.Context(), `SELECT COUNT(*) FROM posts`+filter, args...).Scan(&total); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to count posts")
+		return
 	}
-	query += fmt.Sprintf(` ORDER BY date DESC LIMIT $%d OFFSET $%d`, argN, argN+1)
+
+	query := `SELECT ` + postColumns + ` FROM posts` + filter +
+		fmt.Sprintf(` ORDER BY date DESC LIMIT $%d OFFSET $%d`, len(args)+1, len(args)+2)
 	args = append(args, perPage, offset)
 
 	rows, err := h.DB.Query(r.Context(), query, args...)
@@ -76,25 +121,124 @@ func (h *Handler) ListPosts(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// GetPost returns a single post by slug.
+const (
+	// maxSearchQueryLen bounds the search string accepted from readers.
+	maxSearchQueryLen = 200
+	// Search hits are delimited with these markers by ts_headline and
+	// turned into <mark> tags after escaping, so post text can never
+	// inject markup into a snippet.
+	hitStart = "\uE000"
+	hitStop  = "\uE001"
+)
+
+// highlightSnippet escapes a ts_headline fragment for HTML and wraps hits
+// in <mark>.
+func highlightSnippet(raw string) string {
+	escaped := html.EscapeString(raw)
+	escaped = strings.ReplaceAll(escaped, hitStart, "<mark>")
+	return strings.ReplaceAll(escaped, hitStop, "</mark>")
+}
+
+// SearchPosts runs a full-text search over public posts, ?q= in web search
+// syntax ("quoted phrases", -excluded, or), best matches first.
+func (h *Handler) SearchPosts(w http.ResponseWriter, r *http.Request) {
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	if q == "" {
+		writeError(w, http.StatusBadRequest, "q is required")
+		return
+	}
+	if len([]rune(q)) > maxSearchQueryLen {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("q must be at most %d characters", maxSearchQueryLen))
+		return
+	}
+	page, perPage, offset := pagination(r)
+
+	var total int64
+	if err := h.DB.QueryRow(r.Context(),
+		`SELECT COUNT(*) FROM posts
+		 WHERE search_vector @@ websearch_to_tsquery('english', $1) AND `+postIsPublic, q,
+	).Scan(&total); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to count posts")
+		return
+	}
+
+	rows, err := h.DB.Query(r.Context(),
+		`SELECT id, slug, title, excerpt, tags, author, date::text,
+		  ts_rank_cd(search_vector, query) AS rank,
+		  ts_headline('english', excerpt || E'\n' || content, query,
+		    'StartSel=`+hitStart+`, StopSel=`+hitStop+`, MaxFragments=2, MaxWords=30, MinWords=10')
+		 FROM posts, websearch_to_tsquery('english', $1) query
+		 WHERE search_vector @@ query AND `+postIsPublic+`
+		 ORDER BY rank DESC, date DESC
+		 LIMIT $2 OFFSET $3`,
+		q, perPage, offset,
+	)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to search posts")
+		return
+	}
+	defer rows.Close()
+
+	results := []models.PostSearchResult{}
+	for rows.Next() {
+		var p models.PostSearchResult
+		var snippet string
+		if err := rows.Scan(&p.ID, &p.Slug, &p.Title, &p.Excerpt, &p.Tags, &p.Author, &p.Date,
+			&p.Rank, &snippet); err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to scan post")
+			return
+		}
+		p.Snippet = highlightSnippet(snippet)
+		results = append(results, p)
+	}
+
+	writeJSON(w, http.StatusOK, models.PaginatedResponse[models.PostSearchResult]{
+		Data:       results,
+		Total:      total,
+		Page:       page,
+		PerPage:    perPage,
+		TotalPages: totalPages(total, perPage),
+	})
+}
+
+// GetPost returns a single public post by slug. Drafts and scheduled posts
+// are not found; share them with a preview link instead. A slug the post
+// was renamed from redirects to the current one. A post in an issue links
+// to its neighbours there, and every post carries related posts to read
+// next.
 func (h *Handler) GetPost(w http.ResponseWriter, r *http.Request) {
 	slug := chi.URLParam(r, "slug")
 
 	var p models.Post
 	row := h.DB.QueryRow(r.Context(),
-		`SELECT id, slug, title, excerpt, content, tags, author, published, date,
-		 created_at, updated_at FROM posts WHERE slug = $1`, slug,
+		`SELECT `+postColumns+` FROM posts WHERE slug = $1 AND `+postIsPublic, slug,
 	)
 	p, err := scanPost(row)
 	if err != nil {
+		if h.redirectSlug(w, r,
+			`SELECT slug FROM posts
+			 WHERE id = (SELECT post_id FROM post_slug_history WHERE slug = $1) AND `+postIsPublic,
+			slug,
+		) {
+			return
+		}
 		writeError(w, http.StatusNotFound, "post not found")
 		return
 	}
+	if p.Issue, err = h.postIssue(r.Context(), p.ID); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to load post issue")
+		return
+	}
+	if p.Related, err = h.relatedPosts(r.Context(), p.ID); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to load related posts")
+		return
+	}
 
 	writeJSON(w, http.StatusOK, p)
 }
 
-// CreatePost creates a new post (admin only).
+// CreatePost creates a new post and records its first revision (admin
+// only). Authors are given by id or, failing that, by byline.
 func (h *Handler) CreatePost(w http.ResponseWriter, r *http.Request) {
 	var req models.CreatePostRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -106,29 +250,58 @@ func (h *Handler) CreatePost(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusBadRequest, "slug and title are required")
 		return
 	}
+	if reservedPostSlugs[req.Slug] {
+		writeError(w, http.StatusBadRequest, "slug is reserved: "+req.Slug)
+		return
+	}
 	if req.Tags == nil {
 		req.Tags = []string{}
 	}
 
-	var p models.Post
-	row := h.DB.QueryRow(r.Context(),
-		`INSERT INTO posts (slug, title, excerpt, content, tags, author, published, date)
-		 VALUES ($1,$2,$3,$4,$5,$6,$7,$8)
-		 RETURNING id, slug, title, excerpt, content, tags, author, published, date,
-		  created_at, updated_at`,
+	ctx := r.Context()
+	tx, err := h.DB.Begin(ctx)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to create post")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	rendered := render.Markdown(req.Content)
+	p, err := scanPost(tx.QueryRow(ctx,
+		`INSERT INTO posts (slug, title, excerpt, content, tags, author, published, date,
+		  publish_at, published_at, content_html, toc, word_count, render_version)
+		 VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,
+		  CASE WHEN $7 AND ($9::timestamptz IS NULL OR $9 <= NOW()) THEN NOW() END,
+		  $10,$11,$12,$13)
+		 RETURNING `+postColumns,
 		req.Slug, req.Title, req.Excerpt, req.Content, req.Tags,
-		req.Author, req.Published, req.Date,
-	)
-	p, err := scanPost(row)
+		req.Author, req.Published, req.Date, req.PublishAt,
+		rendered.HTML, rendered.TOC, rendered.WordCount, render.Version,
+	))
+	if isUniqueViolation(err) {
+		writeError(w, http.StatusConflict, slugConflict)
+		return
+	}
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "failed to create post: "+err.Error())
 		return
 	}
+	if !h.setPostAuthors(w, r, tx, &p, req) {
+		return
+	}
+	if err := h.recordRevision(r, tx, p.ID.String()); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to create post")
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to create post")
+		return
+	}
 
 	writeJSON(w, http.StatusCreated, p)
 }
 
-// UpdatePost updates an existing post (admin only).
+// UpdatePost updates an existing post and records a revision (admin only).
 func (h *Handler) UpdatePost(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 
@@ -137,26 +310,56 @@ func (h *Handler) UpdatePost(w http.ResponseWriter, r *http.Request) {
 		writeError(w, http.StatusBadRequest, "invalid request body")
 		return
 	}
+	if reservedPostSlugs[req.Slug] {
+		writeError(w, http.StatusBadRequest, "slug is reserved: "+req.Slug)
+		return
+	}
 	if req.Tags == nil {
 		req.Tags = []string{}
 	}
 
-	var p models.Post
-	row := h.DB.QueryRow(r.Context(),
+	ctx := r.Context()
+	tx, err := h.DB.Begin(ctx)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to update post")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	rendered := render.Markdown(req.Content)
+	p, err := scanPost(tx.QueryRow(ctx,
 		`UPDATE posts SET
 		  slug=$1, title=$2, excerpt=$3, content=$4, tags=$5,
-		  author=$6, published=$7, date=$8, updated_at=NOW()
+		  author=$6, published=$7, date=$8, publish_at=$10,
+		  published_at = CASE WHEN $7 AND ($10::timestamptz IS NULL OR $10 <= NOW())
+		    THEN COALESCE(published_at, NOW()) END,
+		  content_html=$11, toc=$12, word_count=$13, render_version=$14,
+		  updated_at=NOW()
 		 WHERE id=$9
-		 RETURNING id, slug, title, excerpt, content, tags, author, published, date,
-		  created_at, updated_at`,
+		 RETURNING `+postColumns,
 		req.Slug, req.Title, req.Excerpt, req.Content, req.Tags,
-		req.Author, req.Published, req.Date, id,
-	)
-	p, err := scanPost(row)
+		req.Author, req.Published, req.Date, id, req.PublishAt,
+		rendered.HTML, rendered.TOC, rendered.WordCount, render.Version,
+	))
+	if isUniqueViolation(err) {
+		writeError(w, http.StatusConflict, slugConflict)
+		return
+	}
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "failed to update post: "+err.Error())
 		return
 	}
+	if !h.setPostAuthors(w, r, tx, &p, req) {
+		return
+	}
+	if err := h.recordRevision(r, tx, id); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to update post")
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to update post")
+		return
+	}
 
 	writeJSON(w, http.StatusOK, p)
 }
@@ -173,3 +376,30 @@ func (h *Handler) DeletePost(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// ListScheduledPosts returns published posts whose publish time is still in
+// the future, soonest first (admin only).
+func (h *Handler) ListScheduledPosts(w http.ResponseWriter, r *http.Request) {
+	rows, err := h.DB.Query(r.Context(),
+		`SELECT `+postColumns+` FROM posts
+		 WHERE published = true AND publish_at > NOW()
+		 ORDER BY publish_at ASC`,
+	)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to query posts")
+		return
+	}
+	defer rows.Close()
+
+	posts := []models.Post{}
+	for rows.Next() {
+		p, err := scanPost(rows)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to scan post")
+			return
+		}
+		posts = append(posts, p)
+	}
+
+	writeJSON(w, http.StatusOK, posts)
+}
@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/subculture-collective/subcult-tv/api/internal/middleware"
+	"github.com/subculture-collective/subcult-tv/api/internal/models"
+)
+
+const (
+	// previewTokenType marks preview JWTs so they cannot be confused with
+	// access tokens.
+	previewTokenType = "preview"
+	// defaultPreviewHours is used when a preview is created without an expiry.
+	defaultPreviewHours = 72
+	// maxPreviewHours caps how long a preview link may live.
+	maxPreviewHours = 30 * 24
+	// PreviewTokenHeader carries a preview token to GetPostPreview.
+	PreviewTokenHeader = "X-Preview-Token"
+)
+
+var errInvalidPreview = errors.New("invalid or expired preview link")
+
+// scanPostPreview scans a post_previews row joined with its creator.
+func scanPostPreview(s scanner) (models.PostPreview, error) {
+	var p models.PostPreview
+	err := s.Scan(&p.ID, &p.PostID, &p.Label, &p.CreatedBy,
+		&p.ExpiresAt, &p.RevokedAt, &p.LastUsedAt, &p.CreatedAt)
+	return p, err
+}
+
+const postPreviewColumns = `v.id, v.post_id, v.label, u.username,
+	v.expires_at, v.revoked_at, v.last_used_at, v.created_at`
+
+// issuePreviewToken signs a token naming the preview row and its post.
+func (h *Handler) issuePreviewToken(p models.PostPreview) (string, error) {
+	return h.InternalKeys.Sign(jwt.MapClaims{
+		"typ":  previewTokenType,
+		"jti":  p.ID.String(),
+		"post": p.PostID.String(),
+		"iat":  p.CreatedAt.Unix(),
+		"exp":  p.ExpiresAt.Unix(),
+	})
+}
+
+// parsePreviewToken validates a preview token's signature, expiry, and type
+// and returns the preview and post ids it names.
+func (h *Handler) parsePreviewToken(tokenStr string) (previewID, postID string, err error) {
+	claims, err := h.InternalKeys.Parse(tokenStr)
+	if err != nil {
+		return "", "", errInvalidPreview
+	}
+	typ, _ := claims["typ"].(string)
+	previewID, _ = claims["jti"].(string)
+	postID, _ = claims["post"].(string)
+	if typ != previewTokenType || previewID == "" || postID == "" {
+		return "", "", errInvalidPreview
+	}
+	return previewID, postID, nil
+}
+
+// ListPostPreviews returns a post's preview links, newest first, including
+// revoked and expired ones (admin only).
+func (h *Handler) ListPostPreviews(w http.ResponseWriter, r *http.Request) {
+	rows, err := h.DB.Query(r.Context(),
+		`SELECT `+postPreviewColumns+`
+		 FROM post_previews v LEFT JOIN users u ON u.id = v.created_by
+		 WHERE v.post_id = $1
+		 ORDER BY v.created_at DESC`, chi.URLParam(r, "id"),
+	)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to query previews")
+		return
+	}
+	defer rows.Close()
+
+	previews := []models.PostPreview{}
+	for rows.Next() {
+		p, err := scanPostPreview(rows)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to scan preview")
+			return
+		}
+		previews = append(previews, p)
+	}
+
+	writeJSON(w, http.StatusOK, previews)
+}
+
+// CreatePostPreview mints a signed preview link for one post (admin only).
+// The token is returned once and is not stored.
+func (h *Handler) CreatePostPreview(w http.ResponseWriter, r *http.Request) {
+	postID := chi.URLParam(r, "id")
+	userID, _ := r.Context().Value(middleware.UserIDKey).(string)
+
+	var req models.CreatePostPreviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	req.Label = strings.TrimSpace(req.Label)
+	if len([]rune(req.Label)) > 100 {
+		writeError(w, http.StatusBadRequest, "label must be at most 100 characters")
+		return
+	}
+	hours := req.ExpiresInHours
+	if hours == 0 {
+		hours = defaultPreviewHours
+	}
+	if hours < 0 || hours > maxPreviewHours {
+		writeError(w, http.StatusBadRequest, "expires_in_hours must be between 1 and 720")
+		return
+	}
+
+	p, err := scanPostPreview(h.DB.QueryRow(r.Context(),
+		`WITH v AS (
+		   INSERT INTO post_previews (post_id, label, created_by, expires_at)
+		   SELECT id, $2::text, $3::uuid, NOW() + make_interval(hours => $4::int) FROM posts WHERE id = $1
+		   RETURNING *
+		 )
+		 SELECT `+postPreviewColumns+` FROM v LEFT JOIN users u ON u.id = v.created_by`,
+		postID, req.Label, userID, hours,
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		writeError(w, http.StatusNotFound, "post not found")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to create preview")
+		return
+	}
+
+	token, err := h.issuePreviewToken(p)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to create preview")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, models.CreatePostPreviewResponse{
+		PostPreview: p,
+		Token:       token,
+		// The token travels in the fragment so it stays out of server logs
+		// and Referer headers.
+		URL: h.AppURL + "/zine/preview#token=" + token,
+	})
+}
+
+// RevokePostPreview disables a preview link before it expires (admin only).
+func (h *Handler) RevokePostPreview(w http.ResponseWriter, r *http.Request) {
+	tag, err := h.DB.Exec(r.Context(),
+		`UPDATE post_previews SET revoked_at = NOW()
+		 WHERE id = $1 AND post_id = $2 AND revoked_at IS NULL`,
+		chi.URLParam(r, "previewID"), chi.URLParam(r, "id"),
+	)
+	if err != nil || tag.RowsAffected() == 0 {
+		writeError(w, http.StatusNotFound, "preview not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetPostPreview returns the post named by the preview token in the
+// X-Preview-Token header, whatever its publication state. The token must be
+// unexpired, unrevoked, and still match the post it was minted for.
+func (h *Handler) GetPostPreview(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Robots-Tag", "noindex")
+
+	previewID, postID, err := h.parsePreviewToken(r.Header.Get(PreviewTokenHeader))
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	p, err := scanPost(h.DB.QueryRow(r.Context(),
+		`WITH v AS (
+		   UPDATE post_previews SET last_used_at = NOW()
+		   WHERE id = $1 AND post_id = $2 AND revoked_at IS NULL AND expires_at > NOW()
+		   RETURNING post_id
+		 )
+		 SELECT `+postColumns+` FROM posts WHERE id = (SELECT post_id FROM v)`,
+		previewID, postID,
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		writeError(w, http.StatusUnauthorized, errInvalidPreview.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to load preview")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, p)
+}
@@ -76,7 +76,8 @@ func (h *Handler) ListProjects(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, projects)
 }
 
-// GetProject returns a single project by slug.
+// GetProject returns a single project by slug. A slug the project was
+// renamed from redirects to the current one.
 func (h *Handler) GetProject(w http.ResponseWriter, r *http.Request) {
 	slug := chi.URLParam(r, "slug")
 
@@ -90,6 +91,13 @@ func (h *Handler) GetProject(w http.ResponseWriter, r *http.Request) {
 	)
 	p, err := scanProject(row)
 	if err != nil {
+		if h.redirectSlug(w, r,
+			`SELECT slug FROM projects
+			 WHERE id = (SELECT project_id FROM project_slug_history WHERE slug = $1)`,
+			slug,
+		) {
+			return
+		}
 		writeError(w, http.StatusNotFound, "project not found")
 		return
 	}
@@ -131,6 +139,10 @@ func (h *Handler) CreateProject(w http.ResponseWriter, r *http.Request) {
 		req.CoverPattern, req.CoverColor, req.Featured, req.SortOrder,
 	)
 	p, err := scanProject(row)
+	if isUniqueViolation(err) {
+		writeError(w, http.StatusConflict, slugConflict)
+		return
+	}
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "failed to create project: "+err.Error())
 		return
@@ -171,6 +183,10 @@ func (h *Handler) UpdateProject(w http.ResponseWriter, r *http.Request) {
 		req.CoverPattern, req.CoverColor, req.Featured, req.SortOrder, id,
 	)
 	p, err := scanProject(row)
+	if isUniqueViolation(err) {
+		writeError(w, http.StatusConflict, slugConflict)
+		return
+	}
 	if err != nil {
 		writeError(w, http.StatusInternalServerError, "failed to update project: "+err.Error())
 		return
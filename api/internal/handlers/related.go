@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/subculture-collective/subcult-tv/api/internal/models"
+	"github.com/subculture-collective/subcult-tv/api/internal/render"
+)
+
+// RelatedSize is the number of related posts returned with a post.
+const RelatedSize = 4
+
+// relatedQuery ranks public posts against post $1: one point per shared tag
+// plus the full-text rank of the candidate against the source's title, tag,
+// and excerpt terms, normalised below 1.
+const relatedQuery = `
+	WITH src AS (
+	  SELECT id, tags,
+	    (SELECT string_agg(quote_literal(lexeme), ' | ')::tsquery
+	     FROM unnest(search_vector)
+	     WHERE weights && '{A,B,C}'::text[] AND strpos(lexeme, E'\\') = 0) AS query
+	  FROM posts WHERE id = $1
+	), scored AS (
+	  SELECT p.slug, p.title, p.excerpt, p.date::text AS date, p.tags, p.word_count,
+	    cardinality(ARRAY(SELECT unnest(p.tags) INTERSECT SELECT unnest(src.tags)))
+	      + COALESCE(ts_rank(p.search_vector, src.query, 32), 0)::float8 AS score
+	  FROM posts p, src
+	  WHERE p.id <> src.id AND ` + postIsPublic + `
+	    AND (p.tags && src.tags OR p.search_vector @@ src.query)
+	)
+	SELECT slug, title, excerpt, date, tags, word_count, score FROM scored
+	ORDER BY score DESC, date DESC
+	LIMIT $2`
+
+// relatedCache holds related posts by post until any post changes. The
+// zero value is ready to use.
+type relatedCache struct {
+	mu    sync.Mutex
+	stamp string
+	posts map[uuid.UUID][]models.RelatedPost
+}
+
+// get returns the cached related posts for id if the cache was filled at
+// stamp.
+func (c *relatedCache) get(stamp string, id uuid.UUID) ([]models.RelatedPost, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stamp != stamp {
+		return nil, false
+	}
+	related, ok := c.posts[id]
+	return related, ok
+}
+
+// put caches related posts computed at stamp, dropping everything cached
+// at an older one.
+func (c *relatedCache) put(stamp string, id uuid.UUID, related []models.RelatedPost) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stamp != stamp || c.posts == nil {
+		c.stamp = stamp
+		c.posts = map[uuid.UUID][]models.RelatedPost{}
+	}
+	c.posts[id] = related
+}
+
+// postsStamp identifies the current state of the posts table for caching:
+// any edit moves the latest updated_at, and publishing, scheduled go-lives,
+// and deletions change the public count or latest publication time.
+func (h *Handler) postsStamp(ctx context.Context) (string, error) {
+	var (
+		count             int64
+		edited, published *time.Time
+	)
+	err := h.DB.QueryRow(ctx,
+		`SELECT COUNT(*) FILTER (WHERE `+postIsPublic+`), MAX(updated_at),
+		  MAX(GREATEST(published_at, publish_at)) FILTER (WHERE `+postIsPublic+`)
+		 FROM posts`,
+	).Scan(&count, &edited, &published)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d/%s/%s", count, formatStamp(edited), formatStamp(published)), nil
+}
+
+func formatStamp(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// relatedPosts returns the posts to read after post id, computed once per
+// state of the posts table.
+func (h *Handler) relatedPosts(ctx context.Context, id uuid.UUID) ([]models.RelatedPost, error) {
+	stamp, err := h.postsStamp(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if related, ok := h.related.get(stamp, id); ok {
+		return related, nil
+	}
+
+	rows, err := h.DB.Query(ctx, relatedQuery, id, RelatedSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	related := []models.RelatedPost{}
+	for rows.Next() {
+		var (
+			p     models.RelatedPost
+			words int
+		)
+		if err := rows.Scan(&p.Slug, &p.Title, &p.Excerpt, &p.Date, &p.Tags, &words, &p.Score); err != nil {
+			return nil, err
+		}
+		p.ReadingTime = render.ReadingTime(words)
+		related = append(related, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	h.related.put(stamp, id, related)
+	return related, nil
+}
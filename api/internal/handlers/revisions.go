@@ -0,0 +1,261 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/subculture-collective/subcult-tv/api/internal/authors"
+	"github.com/subculture-collective/subcult-tv/api/internal/middleware"
+	"github.com/subculture-collective/subcult-tv/api/internal/models"
+	"github.com/subculture-collective/subcult-tv/api/internal/render"
+	"github.com/subculture-collective/subcult-tv/api/internal/revisions"
+	"github.com/subculture-collective/subcult-tv/api/internal/textdiff"
+)
+
+// diffContext is the number of unchanged lines shown around each change.
+const diffContext = 3
+
+// recordRevision snapshots a post inside the transaction that saved it,
+// attributed to the authenticated user.
+func (h *Handler) recordRevision(r *http.Request, tx pgx.Tx, postID string) error {
+	editorID, _ := r.Context().Value(middleware.UserIDKey).(string)
+	return revisions.Record(r.Context(), tx, postID, editorID, h.RevisionsKeep)
+}
+
+// loadRevision fetches one revision of a post.
+func (h *Handler) loadRevision(r *http.Request, postID string, number int) (models.PostRevision, error) {
+	return revisions.Scan(h.DB.QueryRow(r.Context(),
+		`SELECT `+revisions.Columns+`
+		 FROM post_revisions r LEFT JOIN users u ON u.id = r.editor_id
+		 WHERE r.post_id = $1 AND r.number = $2`, postID, number,
+	))
+}
+
+// revisionNumber parses the {number} URL parameter.
+func revisionNumber(r *http.Request) (int, bool) {
+	n, err := strconv.Atoi(chi.URLParam(r, "number"))
+	return n, err == nil && n > 0
+}
+
+// ListPostRevisions returns a post's revisions, newest first, without their
+// content (admin only).
+func (h *Handler) ListPostRevisions(w http.ResponseWriter, r *http.Request) {
+	postID := chi.URLParam(r, "id")
+	page, perPage, offset := pagination(r)
+
+	var total int64
+	if err := h.DB.QueryRow(r.Context(),
+		`SELECT COUNT(*) FROM post_revisions WHERE post_id = $1`, postID,
+	).Scan(&total); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to count revisions")
+		return
+	}
+
+	rows, err := h.DB.Query(r.Context(),
+		`SELECT `+revisions.Columns+`
+		 FROM post_revisions r LEFT JOIN users u ON u.id = r.editor_id
+		 WHERE r.post_id = $1
+		 ORDER BY r.number DESC LIMIT $2 OFFSET $3`,
+		postID, perPage, offset,
+	)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to query revisions")
+		return
+	}
+	defer rows.Close()
+
+	revs := []models.PostRevision{}
+	for rows.Next() {
+		rev, err := revisions.Scan(rows)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to scan revision")
+			return
+		}
+		rev.Content = ""
+		revs = append(revs, rev)
+	}
+
+	writeJSON(w, http.StatusOK, models.PaginatedResponse[models.PostRevision]{
+		Data:       revs,
+		Total:      total,
+		Page:       page,
+		PerPage:    perPage,
+		TotalPages: totalPages(total, perPage),
+	})
+}
+
+// GetPostRevision returns one revision including its content (admin only).
+func (h *Handler) GetPostRevision(w http.ResponseWriter, r *http.Request) {
+	number, ok := revisionNumber(r)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid revision number")
+		return
+	}
+
+	rev, err := h.loadRevision(r, chi.URLParam(r, "id"), number)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "revision not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, rev)
+}
+
+// DiffPostRevisions returns a unified diff between two revisions of a post,
+// ?from=N&to=M. to defaults to the latest revision and from to the one
+// before it (admin only).
+func (h *Handler) DiffPostRevisions(w http.ResponseWriter, r *http.Request) {
+	postID := chi.URLParam(r, "id")
+	q := r.URL.Query()
+
+	to, err := optionalRevision(q.Get("to"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid to revision")
+		return
+	}
+	from, err := optionalRevision(q.Get("from"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid from revision")
+		return
+	}
+
+	if to == 0 {
+		err := h.DB.QueryRow(r.Context(),
+			`SELECT COALESCE(MAX(number), 0) FROM post_revisions WHERE post_id = $1`, postID,
+		).Scan(&to)
+		if err != nil || to == 0 {
+			writeError(w, http.StatusNotFound, "revision not found")
+			return
+		}
+	}
+	if from == 0 {
+		err := h.DB.QueryRow(r.Context(),
+			`SELECT COALESCE(MAX(number), 0) FROM post_revisions WHERE post_id = $1 AND number < $2`,
+			postID, to,
+		).Scan(&from)
+		if err != nil || from == 0 {
+			writeError(w, http.StatusNotFound, "no earlier revision to compare with")
+			return
+		}
+	}
+
+	fromRev, err := h.loadRevision(r, postID, from)
+	if err != nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("revision %d not found", from))
+		return
+	}
+	toRev, err := h.loadRevision(r, postID, to)
+	if err != nil {
+		writeError(w, http.StatusNotFound, fmt.Sprintf("revision %d not found", to))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.PostRevisionDiff{
+		From: from,
+		To:   to,
+		Diff: textdiff.Unified(
+			fmt.Sprintf("revision %d", from), fmt.Sprintf("revision %d", to),
+			revisions.Text(fromRev), revisions.Text(toRev), diffContext,
+		),
+	})
+}
+
+// optionalRevision parses a revision number query parameter; "" means 0.
+func optionalRevision(raw string) (int, error) {
+	if raw == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 1 {
+		return 0, errors.New("invalid revision number")
+	}
+	return n, nil
+}
+
+// RestorePostRevision copies a revision's title, excerpt, content, tags,
+// authors, and date back onto the post and records the result as a new
+// revision. Authors are restored by id, under their current names; only
+// revisions without ids, or whose authors were deleted since, fall back to
+// their byline. The slug and publication state are left as they are
+// (admin only).
+func (h *Handler) RestorePostRevision(w http.ResponseWriter, r *http.Request) {
+	postID := chi.URLParam(r, "id")
+	number, ok := revisionNumber(r)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid revision number")
+		return
+	}
+
+	ctx := r.Context()
+	tx, err := h.DB.Begin(ctx)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to restore revision")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	var authorIDs []uuid.UUID
+	if err := tx.QueryRow(ctx,
+		`SELECT author_ids FROM post_revisions WHERE post_id = $1 AND number = $2`, postID, number,
+	).Scan(&authorIDs); errors.Is(err, pgx.ErrNoRows) {
+		writeError(w, http.StatusNotFound, "revision not found")
+		return
+	} else if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to restore revision")
+		return
+	}
+
+	p, err := scanPost(tx.QueryRow(ctx,
+		`UPDATE posts SET
+		  (title, excerpt, content, tags, author, date) = (
+		    SELECT title, excerpt, content, tags, author, date
+		    FROM post_revisions WHERE post_id = $1 AND number = $2
+		  ),
+		  updated_at = NOW()
+		 WHERE id = $1
+		   AND EXISTS (SELECT 1 FROM post_revisions WHERE post_id = $1 AND number = $2)
+		 RETURNING `+postColumns,
+		postID, number,
+	))
+	if errors.Is(err, pgx.ErrNoRows) {
+		writeError(w, http.StatusNotFound, "revision not found")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to restore revision")
+		return
+	}
+	rendered, err := render.Store(ctx, tx, postID, p.Content)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to restore revision")
+		return
+	}
+	p.ContentHTML, p.TOC, p.WordCount = rendered.HTML, rendered.TOC, rendered.WordCount
+	if authorIDs != nil {
+		p.Authors, err = authors.Set(ctx, tx, postID, authorIDs)
+	}
+	if authorIDs == nil || errors.Is(err, authors.ErrUnknownAuthor) {
+		p.Authors, err = authors.Link(ctx, tx, postID, p.Author)
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to restore revision")
+		return
+	}
+	p.Author = authors.Byline(p.Authors)
+	if err := h.recordRevision(r, tx, postID); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to restore revision")
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to restore revision")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, p)
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/subculture-collective/subcult-tv/api/internal/models"
+)
+
+// maxUserAgentLen matches the sessions.user_agent column width.
+const maxUserAgentLen = 500
+
+// newOpaqueToken returns a random URL-safe token and its SHA-256 hex digest.
+// Only the digest is stored; the token itself is handed to the client once.
+func newOpaqueToken() (token, hash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", fmt.Errorf("generate token: %w", err)
+	}
+	token = base64.RawURLEncoding.EncodeToString(b)
+	return token, hashToken(token), nil
+}
+
+// hashToken returns the SHA-256 hex digest used to look up opaque tokens.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// createSession stores a new login session and returns its id and the
+// refresh token the client must present to rotate it.
+func (h *Handler) createSession(ctx context.Context, userID string, r *http.Request) (sessionID, refreshToken string, err error) {
+	refreshToken, hash, err := newOpaqueToken()
+	if err != nil {
+		return "", "", err
+	}
+
+	userAgent := r.UserAgent()
+	if len(userAgent) > maxUserAgentLen {
+		userAgent = userAgent[:maxUserAgentLen]
+	}
+
+	err = h.DB.QueryRow(ctx,
+		`INSERT INTO sessions (user_id, refresh_token_hash, user_agent, ip, expires_at)
+		 VALUES ($1, $2, $3, $4, $5)
+		 RETURNING id`,
+		userID, hash, userAgent, r.RemoteAddr, time.Now().Add(h.RefreshTokenTTL),
+	).Scan(&sessionID)
+	if err != nil {
+		return "", "", fmt.Errorf("insert session: %w", err)
+	}
+	return sessionID, refreshToken, nil
+}
+
+// issueAccessToken signs a short-lived JWT bound to the given session with
+// the current signing key.
+func (h *Handler) issueAccessToken(user models.User, sessionID string) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(h.AccessTokenTTL)
+	tokenStr, err := h.Keys.Sign(jwt.MapClaims{
+		"sub":      user.ID.String(),
+		"username": user.Username,
+		"role":     user.Role,
+		"sid":      sessionID,
+		"iat":      now.Unix(),
+		"exp":      expiresAt.Unix(),
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return tokenStr, expiresAt, nil
+}
+
+// JWKS publishes the public keys that verify access tokens, so other
+// services can check our tokens without sharing a secret.
+func (h *Handler) JWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	writeJSON(w, http.StatusOK, h.Keys.JWKS())
+}
+
+// SessionActive implements middleware.AuthStore.
+func (h *Handler) SessionActive(ctx context.Context, sessionID string) (bool, error) {
+	var active bool
+	err := h.DB.QueryRow(ctx,
+		`SELECT EXISTS(
+		   SELECT 1 FROM sessions s JOIN users u ON u.id = s.user_id
+		   WHERE s.id = $1 AND s.revoked_at IS NULL AND s.expires_at > NOW()
+		     AND u.disabled_at IS NULL
+		 )`, sessionID,
+	).Scan(&active)
+	return active, err
+}
+
+// ListUserSessions returns the active sessions of a user (admin only).
+func (h *Handler) ListUserSessions(w http.ResponseWriter, r *http.Request) {
+	userID := chi.URLParam(r, "id")
+
+	rows, err := h.DB.Query(r.Context(),
+		`SELECT id, user_id, user_agent, ip, created_at, last_used_at, expires_at, revoked_at
+		 FROM sessions
+		 WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+		 ORDER BY last_used_at DESC`, userID,
+	)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to query sessions")
+		return
+	}
+	defer rows.Close()
+
+	var sessions []models.Session
+	for rows.Next() {
+		var s models.Session
+		if err := rows.Scan(&s.ID, &s.UserID, &s.UserAgent, &s.IP,
+			&s.CreatedAt, &s.LastUsedAt, &s.ExpiresAt, &s.RevokedAt); err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to scan session")
+			return
+		}
+		sessions = append(sessions, s)
+	}
+
+	if sessions == nil {
+		sessions = []models.Session{}
+	}
+	writeJSON(w, http.StatusOK, sessions)
+}
+
+// RevokeSession revokes a single session (admin only).
+func (h *Handler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	tag, err := h.DB.Exec(r.Context(),
+		`UPDATE sessions SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL`, id,
+	)
+	if err != nil || tag.RowsAffected() == 0 {
+		writeError(w, http.StatusNotFound, "session not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RevokeUserSessions revokes every active session of a user (admin only).
+func (h *Handler) RevokeUserSessions(w http.ResponseWriter, r *http.Request) {
+	userID := chi.URLParam(r, "id")
+
+	tag, err := h.DB.Exec(r.Context(),
+		`UPDATE sessions SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL`, userID,
+	)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to revoke sessions")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]int64{"revoked": tag.RowsAffected()})
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/subculture-collective/subcult-tv/api/internal/models"
+)
+
+// settingSecurity is the settings row holding models.SecuritySettings.
+const settingSecurity = "security"
+
+// securitySettings loads the security settings, returning defaults when
+// none have been saved yet.
+func (h *Handler) securitySettings(ctx context.Context) (models.SecuritySettings, error) {
+	var s models.SecuritySettings
+	var raw []byte
+	err := h.DB.QueryRow(ctx, `SELECT value FROM settings WHERE key = $1`, settingSecurity).Scan(&raw)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return s, nil
+	}
+	if err != nil {
+		return s, err
+	}
+	err = json.Unmarshal(raw, &s)
+	return s, err
+}
+
+// GetSecuritySettings returns the security settings (admin only).
+func (h *Handler) GetSecuritySettings(w http.ResponseWriter, r *http.Request) {
+	s, err := h.securitySettings(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to load settings")
+		return
+	}
+	writeJSON(w, http.StatusOK, s)
+}
+
+// UpdateSecuritySettings replaces the security settings (admin only).
+func (h *Handler) UpdateSecuritySettings(w http.ResponseWriter, r *http.Request) {
+	var req models.SecuritySettings
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	raw, err := json.Marshal(req)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to encode settings")
+		return
+	}
+
+	_, err = h.DB.Exec(r.Context(),
+		`INSERT INTO settings (key, value) VALUES ($1, $2)
+		 ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value, updated_at = NOW()`,
+		settingSecurity, raw,
+	)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to save settings")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, req)
+}
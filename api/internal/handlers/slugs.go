@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"path"
+
+	"github.com/subculture-collective/subcult-tv/api/internal/models"
+)
+
+// slugConflict is the error for a slug that is current or redirects to
+// another post or project.
+const slugConflict = "slug is already in use or redirects elsewhere"
+
+// redirectSlug answers a request for an old slug with a 301 to the record's
+// current slug, found by query given the requested one. It reports whether
+// a redirect was written.
+func (h *Handler) redirectSlug(w http.ResponseWriter, r *http.Request, query, slug string) bool {
+	var current string
+	if err := h.DB.QueryRow(r.Context(), query, slug).Scan(&current); err != nil {
+		return false
+	}
+
+	location := canonicalURL(r.URL, current)
+	w.Header().Set("Location", location)
+	// Slugs can be renamed back, so the redirect is not cached for good.
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	writeJSON(w, http.StatusMovedPermanently, models.SlugRedirect{Slug: current, Location: location})
+	return true
+}
+
+// canonicalURL is u with its last path segment replaced by slug.
+func canonicalURL(u *url.URL, slug string) string {
+	loc := path.Dir(u.EscapedPath()) + "/" + url.PathEscape(slug)
+	if u.RawQuery != "" {
+		loc += "?" + u.RawQuery
+	}
+	return loc
+}
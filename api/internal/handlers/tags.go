@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/subculture-collective/subcult-tv/api/internal/models"
+)
+
+// ListTags returns every tag on a public post with the number of public
+// posts carrying it, most used first.
+func (h *Handler) ListTags(w http.ResponseWriter, r *http.Request) {
+	rows, err := h.DB.Query(r.Context(),
+		`SELECT tag, COUNT(*) FROM posts, unnest(tags) AS tag
+		 WHERE `+postIsPublic+`
+		 GROUP BY tag
+		 ORDER BY COUNT(*) DESC, tag ASC`,
+	)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to query tags")
+		return
+	}
+	defer rows.Close()
+
+	tags := []models.TagCount{}
+	for rows.Next() {
+		var t models.TagCount
+		if err := rows.Scan(&t.Tag, &t.Count); err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to scan tag")
+			return
+		}
+		tags = append(tags, t)
+	}
+
+	writeJSON(w, http.StatusOK, tags)
+}
+
+// RenameTag renames a tag on every post, drafts included (admin only). If
+// a post already has the new name the two are merged.
+func (h *Handler) RenameTag(w http.ResponseWriter, r *http.Request) {
+	from, err := url.PathUnescape(chi.URLParam(r, "tag"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid tag")
+		return
+	}
+
+	var req models.RenameTagRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	h.mergeTags(w, r, []string{from}, req.Name)
+}
+
+// MergeTags replaces several tags with one on every post, drafts included
+// (admin only).
+func (h *Handler) MergeTags(w http.ResponseWriter, r *http.Request) {
+	var req models.MergeTagsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	h.mergeTags(w, r, req.From, req.To)
+}
+
+// mergeTags rewrites each affected post's tags in one transaction, keeping
+// their order and dropping duplicates, and records a revision per post.
+func (h *Handler) mergeTags(w http.ResponseWriter, r *http.Request, from []string, to string) {
+	to = strings.TrimSpace(to)
+	var sources []string
+	for _, t := range from {
+		if t = strings.TrimSpace(t); t != "" {
+			sources = append(sources, t)
+		}
+	}
+	if len(sources) == 0 || to == "" {
+		writeError(w, http.StatusBadRequest, "source and target tags are required")
+		return
+	}
+
+	ctx := r.Context()
+	tx, err := h.DB.Begin(ctx)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to update tags")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx,
+		`UPDATE posts SET
+		  tags = ARRAY(
+		    SELECT t FROM (
+		      SELECT CASE WHEN u.t = ANY($1::text[]) THEN $2 ELSE u.t END AS t, MIN(u.ord) AS ord
+		      FROM unnest(tags) WITH ORDINALITY AS u(t, ord)
+		      GROUP BY 1
+		    ) merged ORDER BY ord
+		  ),
+		  updated_at = NOW()
+		 WHERE tags && $1::text[]
+		 RETURNING id::text`,
+		sources, to,
+	)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to update tags")
+		return
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			writeError(w, http.StatusInternalServerError, "failed to update tags")
+			return
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to update tags")
+		return
+	}
+
+	for _, id := range ids {
+		if err := h.recordRevision(r, tx, id); err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to update tags")
+			return
+		}
+	}
+	if err := tx.Commit(ctx); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to update tags")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.MergeTagsResponse{PostsUpdated: len(ids)})
+}
@@ -0,0 +1,494 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/subculture-collective/subcult-tv/api/internal/middleware"
+	"github.com/subculture-collective/subcult-tv/api/internal/models"
+	"github.com/subculture-collective/subcult-tv/api/internal/totp"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// mfaTokenTTL bounds how long a user has to enter their code after
+	// passing the password step.
+	mfaTokenTTL = 5 * time.Minute
+	// mfaTokenType marks "mfa pending" JWTs so they cannot be confused
+	// with access tokens.
+	mfaTokenType = "mfa"
+	// totpIssuer is shown in authenticator apps.
+	totpIssuer = "SUBCULT"
+	// recoveryCodeCount is how many recovery codes are issued at once.
+	recoveryCodeCount = 10
+)
+
+var errInvalidCode = errors.New("invalid verification code")
+
+// totpState is the two-factor state of a user.
+type totpState struct {
+	Secret   *string
+	Enabled  bool
+	LastStep int64
+}
+
+// loadTOTPState reads and locks a user's two-factor state.
+func loadTOTPState(ctx context.Context, tx pgx.Tx, userID string) (totpState, error) {
+	var s totpState
+	err := tx.QueryRow(ctx,
+		`SELECT totp_secret, totp_enabled_at IS NOT NULL, totp_last_step
+		 FROM users WHERE id = $1 FOR UPDATE`, userID,
+	).Scan(&s.Secret, &s.Enabled, &s.LastStep)
+	return s, err
+}
+
+// mfaRequirement reports whether user must pass a second factor at login,
+// and whether they still have to enroll because an admin policy demands it.
+func (h *Handler) mfaRequirement(ctx context.Context, user models.User) (required, enroll bool, err error) {
+	if user.TOTPEnabled {
+		return true, false, nil
+	}
+	if user.Role != middleware.RoleAdmin {
+		return false, false, nil
+	}
+	settings, err := h.securitySettings(ctx)
+	if err != nil {
+		return false, false, err
+	}
+	return settings.RequireAdmin2FA, settings.RequireAdmin2FA, nil
+}
+
+// issueMFAToken signs a short-lived token proving the password step passed.
+func (h *Handler) issueMFAToken(userID string) (string, error) {
+	now := time.Now()
+	return h.InternalKeys.Sign(jwt.MapClaims{
+		"sub": userID,
+		"typ": mfaTokenType,
+		"iat": now.Unix(),
+		"exp": now.Add(mfaTokenTTL).Unix(),
+	})
+}
+
+// parseMFAToken validates an "mfa pending" token and returns its user id.
+func (h *Handler) parseMFAToken(tokenStr string) (string, error) {
+	claims, err := h.InternalKeys.Parse(tokenStr)
+	if err != nil {
+		return "", errors.New("invalid or expired mfa token")
+	}
+	typ, _ := claims["typ"].(string)
+	sub, _ := claims["sub"].(string)
+	if typ != mfaTokenType || sub == "" {
+		return "", errors.New("invalid or expired mfa token")
+	}
+	return sub, nil
+}
+
+// verifyTOTP checks code against the user's secret and records the step so
+// the same code cannot be replayed.
+func verifyTOTP(ctx context.Context, tx pgx.Tx, userID string, state totpState, code string) error {
+	if state.Secret == nil {
+		return errInvalidCode
+	}
+	step, ok := totp.Validate(*state.Secret, code, time.Now())
+	if !ok || step <= state.LastStep {
+		return errInvalidCode
+	}
+	_, err := tx.Exec(ctx, `UPDATE users SET totp_last_step = $1 WHERE id = $2`, step, userID)
+	return err
+}
+
+// consumeRecoveryCode marks a recovery code as used.
+func consumeRecoveryCode(ctx context.Context, tx pgx.Tx, userID, code string) error {
+	tag, err := tx.Exec(ctx,
+		`UPDATE recovery_codes SET used_at = NOW()
+		 WHERE id = (
+		   SELECT id FROM recovery_codes
+		   WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+		   LIMIT 1
+		 )`, userID, hashToken(normalizeRecoveryCode(code)),
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return errInvalidCode
+	}
+	return nil
+}
+
+// normalizeRecoveryCode strips formatting so "ABCD-EFGH" matches "abcdefgh".
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
+
+// replaceRecoveryCodes discards a user's recovery codes and issues new ones.
+func replaceRecoveryCodes(ctx context.Context, tx pgx.Tx, userID string) ([]string, error) {
+	if _, err := tx.Exec(ctx, `DELETE FROM recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return nil, err
+	}
+
+	enc := base32.StdEncoding.WithPadding(base32.NoPadding)
+	codes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, fmt.Errorf("generate recovery code: %w", err)
+		}
+		raw := strings.ToLower(enc.EncodeToString(b))
+		if _, err := tx.Exec(ctx,
+			`INSERT INTO recovery_codes (user_id, code_hash) VALUES ($1, $2)`,
+			userID, hashToken(raw),
+		); err != nil {
+			return nil, err
+		}
+		codes = append(codes, raw[:4]+"-"+raw[4:])
+	}
+	return codes, nil
+}
+
+// beginEnrollment stores a fresh pending secret for a user who does not yet
+// have 2FA enabled.
+func (h *Handler) beginEnrollment(ctx context.Context, userID string) (models.MFASetupResponse, error) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return models.MFASetupResponse{}, err
+	}
+
+	var username string
+	err = h.DB.QueryRow(ctx,
+		`UPDATE users SET totp_secret = $1, updated_at = NOW()
+		 WHERE id = $2 AND totp_enabled_at IS NULL
+		 RETURNING username`, secret, userID,
+	).Scan(&username)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.MFASetupResponse{}, errors.New("two-factor authentication is already enabled")
+	}
+	if err != nil {
+		return models.MFASetupResponse{}, err
+	}
+
+	return models.MFASetupResponse{
+		Secret: secret,
+		URI:    totp.URI(totpIssuer, username, secret),
+	}, nil
+}
+
+// LoginMFA completes a login by exchanging an mfa token and a TOTP or
+// recovery code for an access token. Users who must enroll confirm their
+// new authenticator here and receive their recovery codes.
+func (h *Handler) LoginMFA(w http.ResponseWriter, r *http.Request) {
+	var req models.MFALoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.MFAToken == "" || (req.Code == "" && req.RecoveryCode == "") {
+		writeError(w, http.StatusBadRequest, "mfa_token and code or recovery_code are required")
+		return
+	}
+
+	userID, err := h.parseMFAToken(req.MFAToken)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	ctx := r.Context()
+	user, err := scanUser(h.DB.QueryRow(ctx,
+		`SELECT id, username, email, role, totp_enabled_at IS NOT NULL, disabled_at, created_at, updated_at
+		 FROM users WHERE id = $1 AND disabled_at IS NULL`, userID,
+	))
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, "invalid or expired mfa token")
+		return
+	}
+
+	locked, err := h.loginLockedFor(ctx, user.Username)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to check login attempts")
+		return
+	}
+	if locked > 0 {
+		writeLocked(w, locked)
+		return
+	}
+
+	_, enroll, err := h.mfaRequirement(ctx, user)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to load settings")
+		return
+	}
+
+	tx, err := h.DB.Begin(ctx)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to verify code")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	state, err := loadTOTPState(ctx, tx, userID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to verify code")
+		return
+	}
+
+	var recoveryCodes []string
+	switch {
+	case state.Enabled && req.RecoveryCode != "":
+		err = consumeRecoveryCode(ctx, tx, userID, req.RecoveryCode)
+	case state.Enabled:
+		err = verifyTOTP(ctx, tx, userID, state, req.Code)
+	case enroll && req.Code != "":
+		if err = verifyTOTP(ctx, tx, userID, state, req.Code); err == nil {
+			if _, err = tx.Exec(ctx, `UPDATE users SET totp_enabled_at = NOW() WHERE id = $1`, userID); err == nil {
+				recoveryCodes, err = replaceRecoveryCodes(ctx, tx, userID)
+			}
+		}
+	default:
+		err = errInvalidCode
+	}
+	if errors.Is(err, errInvalidCode) {
+		h.recordLoginFailure(ctx, user.Username, "mfa")
+		writeError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to verify code")
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to verify code")
+		return
+	}
+	h.clearLoginFailures(ctx, user.Username)
+
+	user.TOTPEnabled = true
+	h.completeLogin(w, r, user, recoveryCodes, req.Mode == loginModeCookie)
+}
+
+// LoginMFASetup starts enrollment for a user whose login is blocked until
+// they enable 2FA. It is authorized by the mfa token from Login.
+func (h *Handler) LoginMFASetup(w http.ResponseWriter, r *http.Request) {
+	var req models.MFASetupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	userID, err := h.parseMFAToken(req.MFAToken)
+	if err != nil {
+		writeError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	setup, err := h.beginEnrollment(r.Context(), userID)
+	if err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, setup)
+}
+
+// SetupMFA starts 2FA enrollment for the current user, returning the
+// secret and otpauth URI to show as a QR code.
+func (h *Handler) SetupMFA(w http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value(middleware.UserIDKey).(string)
+
+	setup, err := h.beginEnrollment(r.Context(), userID)
+	if err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, setup)
+}
+
+// EnableMFA confirms enrollment with a code from the authenticator app and
+// returns the user's recovery codes.
+func (h *Handler) EnableMFA(w http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value(middleware.UserIDKey).(string)
+
+	var req models.MFACodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	ctx := r.Context()
+	tx, err := h.DB.Begin(ctx)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to enable two-factor authentication")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	state, err := loadTOTPState(ctx, tx, userID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "user not found")
+		return
+	}
+	if state.Enabled {
+		writeError(w, http.StatusConflict, "two-factor authentication is already enabled")
+		return
+	}
+	if err := verifyTOTP(ctx, tx, userID, state, req.Code); err != nil {
+		writeError(w, http.StatusBadRequest, errInvalidCode.Error())
+		return
+	}
+
+	if _, err := tx.Exec(ctx, `UPDATE users SET totp_enabled_at = NOW(), updated_at = NOW() WHERE id = $1`, userID); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to enable two-factor authentication")
+		return
+	}
+	codes, err := replaceRecoveryCodes(ctx, tx, userID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to generate recovery codes")
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to enable two-factor authentication")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// DisableMFA turns off 2FA for the current user after re-checking their
+// password. Admins cannot disable it while the admin policy requires it.
+func (h *Handler) DisableMFA(w http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value(middleware.UserIDKey).(string)
+	role, _ := r.Context().Value(middleware.RoleKey).(string)
+
+	var req models.MFADisableRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if role == middleware.RoleAdmin {
+		settings, err := h.securitySettings(r.Context())
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to load settings")
+			return
+		}
+		if settings.RequireAdmin2FA {
+			writeError(w, http.StatusForbidden, "two-factor authentication is required for admins")
+			return
+		}
+	}
+
+	var hash string
+	if err := h.DB.QueryRow(r.Context(),
+		`SELECT password_hash FROM users WHERE id = $1`, userID,
+	).Scan(&hash); err != nil {
+		writeError(w, http.StatusNotFound, "user not found")
+		return
+	}
+	if bcrypt.CompareHashAndPassword([]byte(hash), []byte(req.Password)) != nil {
+		writeError(w, http.StatusUnauthorized, "invalid credentials")
+		return
+	}
+
+	if err := h.clearMFA(r.Context(), userID); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to disable two-factor authentication")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RegenerateRecoveryCodes replaces the current user's recovery codes after
+// checking a TOTP code.
+func (h *Handler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	userID, _ := r.Context().Value(middleware.UserIDKey).(string)
+
+	var req models.MFACodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	ctx := r.Context()
+	tx, err := h.DB.Begin(ctx)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to generate recovery codes")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	state, err := loadTOTPState(ctx, tx, userID)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "user not found")
+		return
+	}
+	if !state.Enabled {
+		writeError(w, http.StatusConflict, "two-factor authentication is not enabled")
+		return
+	}
+	if err := verifyTOTP(ctx, tx, userID, state, req.Code); err != nil {
+		writeError(w, http.StatusBadRequest, errInvalidCode.Error())
+		return
+	}
+
+	codes, err := replaceRecoveryCodes(ctx, tx, userID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to generate recovery codes")
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to generate recovery codes")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// ResetUserMFA removes a user's 2FA enrollment so they can enroll again,
+// e.g. after losing their device and recovery codes (admin only).
+func (h *Handler) ResetUserMFA(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	if err := h.clearMFA(r.Context(), id); err != nil {
+		writeError(w, http.StatusNotFound, "user not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// clearMFA removes a user's TOTP secret and recovery codes.
+func (h *Handler) clearMFA(ctx context.Context, userID string) error {
+	tx, err := h.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx,
+		`UPDATE users SET totp_secret = NULL, totp_enabled_at = NULL, updated_at = NOW()
+		 WHERE id = $1`, userID,
+	)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,410 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/subculture-collective/subcult-tv/api/internal/middleware"
+	"github.com/subculture-collective/subcult-tv/api/internal/models"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var errLastAdmin = errors.New("cannot remove the last active admin")
+
+// scanUser scans a user row (without the password hash) into a models.User.
+func scanUser(s scanner) (models.User, error) {
+	var u models.User
+	err := s.Scan(&u.ID, &u.Username, &u.Email, &u.Role, &u.TOTPEnabled, &u.DisabledAt, &u.CreatedAt, &u.UpdatedAt)
+	return u, err
+}
+
+// GeneratePassword returns a random temporary password.
+func GeneratePassword() (string, error) {
+	b := make([]byte, 18)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// ensureNotLastAdmin returns errLastAdmin when userID is the only enabled
+// admin. It locks the admin rows so concurrent demotions cannot race.
+func ensureNotLastAdmin(ctx context.Context, tx pgx.Tx, userID string) error {
+	rows, err := tx.Query(ctx,
+		`SELECT id::text FROM users WHERE role = 'admin' AND disabled_at IS NULL FOR UPDATE`,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	others := 0
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return err
+		}
+		if id != userID {
+			others++
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if others == 0 {
+		return errLastAdmin
+	}
+	return nil
+}
+
+// ListUsers returns all users (admin only).
+func (h *Handler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	page, perPage, offset := pagination(r)
+
+	var total int64
+	if err := h.DB.QueryRow(r.Context(), `SELECT COUNT(*) FROM users`).Scan(&total); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to count users")
+		return
+	}
+
+	rows, err := h.DB.Query(r.Context(),
+		`SELECT id, username, email, role, totp_enabled_at IS NOT NULL, disabled_at, created_at, updated_at
+		 FROM users ORDER BY username ASC LIMIT $1 OFFSET $2`, perPage, offset,
+	)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to query users")
+		return
+	}
+	defer rows.Close()
+
+	var users []models.User
+	for rows.Next() {
+		u, err := scanUser(rows)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to scan user")
+			return
+		}
+		users = append(users, u)
+	}
+
+	if users == nil {
+		users = []models.User{}
+	}
+
+	writeJSON(w, http.StatusOK, models.PaginatedResponse[models.User]{
+		Data:       users,
+		Total:      total,
+		Page:       page,
+		PerPage:    perPage,
+		TotalPages: totalPages(total, perPage),
+	})
+}
+
+// GetUser returns a single user by id (admin only).
+func (h *Handler) GetUser(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	u, err := scanUser(h.DB.QueryRow(r.Context(),
+		`SELECT id, username, email, role, totp_enabled_at IS NOT NULL, disabled_at, created_at, updated_at
+		 FROM users WHERE id = $1`, id,
+	))
+	if err != nil {
+		writeError(w, http.StatusNotFound, "user not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, u)
+}
+
+// CreateUser invites a new user (admin only). When no password is given a
+// temporary one is generated and returned once.
+func (h *Handler) CreateUser(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	req.Username = strings.TrimSpace(req.Username)
+	req.Email = strings.TrimSpace(req.Email)
+	if req.Username == "" || req.Email == "" {
+		writeError(w, http.StatusBadRequest, "username and email are required")
+		return
+	}
+	if req.Role == "" {
+		req.Role = middleware.RoleEditor
+	}
+	if !middleware.ValidRole(req.Role) {
+		writeError(w, http.StatusBadRequest, "invalid role")
+		return
+	}
+
+	var tempPassword string
+	if req.Password == "" {
+		generated, err := GeneratePassword()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to generate password")
+			return
+		}
+		req.Password = generated
+		tempPassword = generated
+	} else if err := ValidatePassword(req.Password, req.Username); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to hash password")
+		return
+	}
+
+	u, err := scanUser(h.DB.QueryRow(r.Context(),
+		`INSERT INTO users (username, email, password_hash, role)
+		 VALUES ($1, $2, $3, $4)
+		 RETURNING id, username, email, role, totp_enabled_at IS NOT NULL, disabled_at, created_at, updated_at`,
+		req.Username, req.Email, string(hash), req.Role,
+	))
+	if isUniqueViolation(err) {
+		writeError(w, http.StatusConflict, "username or email already in use")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to create user")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, models.UserCredentialsResponse{
+		User:              u,
+		TemporaryPassword: tempPassword,
+	})
+}
+
+// UpdateUser changes a user's email, role, or disabled state (admin only).
+// Role changes and disabling revoke the user's sessions so they take effect
+// immediately. The last active admin cannot be demoted or disabled.
+func (h *Handler) UpdateUser(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var req models.UpdateUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if req.Role != nil && !middleware.ValidRole(*req.Role) {
+		writeError(w, http.StatusBadRequest, "invalid role")
+		return
+	}
+	if req.Email != nil && strings.TrimSpace(*req.Email) == "" {
+		writeError(w, http.StatusBadRequest, "email cannot be empty")
+		return
+	}
+
+	ctx := r.Context()
+	tx, err := h.DB.Begin(ctx)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to update user")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	current, err := scanUser(tx.QueryRow(ctx,
+		`SELECT id, username, email, role, totp_enabled_at IS NOT NULL, disabled_at, created_at, updated_at
+		 FROM users WHERE id = $1 FOR UPDATE`, id,
+	))
+	if err != nil {
+		writeError(w, http.StatusNotFound, "user not found")
+		return
+	}
+
+	email, role := current.Email, current.Role
+	disabled := current.DisabledAt != nil
+	if req.Email != nil {
+		email = strings.TrimSpace(*req.Email)
+	}
+	if req.Role != nil {
+		role = *req.Role
+	}
+	if req.Disabled != nil {
+		disabled = *req.Disabled
+	}
+
+	wasActiveAdmin := current.Role == middleware.RoleAdmin && current.DisabledAt == nil
+	if wasActiveAdmin && (role != middleware.RoleAdmin || disabled) {
+		if err := ensureNotLastAdmin(ctx, tx, id); err != nil {
+			if errors.Is(err, errLastAdmin) {
+				writeError(w, http.StatusConflict, err.Error())
+				return
+			}
+			writeError(w, http.StatusInternalServerError, "failed to update user")
+			return
+		}
+	}
+
+	u, err := scanUser(tx.QueryRow(ctx,
+		`UPDATE users SET
+		  email = $1, role = $2,
+		  disabled_at = CASE WHEN $3 THEN COALESCE(disabled_at, NOW()) ELSE NULL END,
+		  updated_at = NOW()
+		 WHERE id = $4
+		 RETURNING id, username, email, role, totp_enabled_at IS NOT NULL, disabled_at, created_at, updated_at`,
+		email, role, disabled, id,
+	))
+	if isUniqueViolation(err) {
+		writeError(w, http.StatusConflict, "email already in use")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to update user")
+		return
+	}
+
+	if role != current.Role || disabled {
+		if _, err := tx.Exec(ctx,
+			`UPDATE sessions SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL`, id,
+		); err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to revoke sessions")
+			return
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to update user")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, u)
+}
+
+// ResetUserPassword sets a new password for a user and revokes their
+// sessions and personal access tokens (admin only). When no password is
+// given a temporary one is generated and returned once.
+func (h *Handler) ResetUserPassword(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var req models.ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	var username string
+	if err := h.DB.QueryRow(r.Context(), `SELECT username FROM users WHERE id = $1`, id).Scan(&username); err != nil {
+		writeError(w, http.StatusNotFound, "user not found")
+		return
+	}
+
+	var tempPassword string
+	if req.Password == "" {
+		generated, err := GeneratePassword()
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, "failed to generate password")
+			return
+		}
+		req.Password = generated
+		tempPassword = generated
+	} else if err := ValidatePassword(req.Password, username); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to hash password")
+		return
+	}
+
+	ctx := r.Context()
+	tx, err := h.DB.Begin(ctx)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to reset password")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	u, err := scanUser(tx.QueryRow(ctx,
+		`UPDATE users SET password_hash = $1, updated_at = NOW() WHERE id = $2
+		 RETURNING id, username, email, role, totp_enabled_at IS NOT NULL, disabled_at, created_at, updated_at`,
+		string(hash), id,
+	))
+	if err != nil {
+		writeError(w, http.StatusNotFound, "user not found")
+		return
+	}
+
+	if _, err := tx.Exec(ctx,
+		`UPDATE sessions SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL`, id,
+	); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to revoke sessions")
+		return
+	}
+	if _, err := tx.Exec(ctx,
+		`UPDATE api_tokens SET revoked_at = NOW() WHERE user_id = $1 AND revoked_at IS NULL`, id,
+	); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to revoke api tokens")
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to reset password")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, models.UserCredentialsResponse{
+		User:              u,
+		TemporaryPassword: tempPassword,
+	})
+}
+
+// DeleteUser deletes a user and their sessions (admin only). The last
+// active admin cannot be deleted.
+func (h *Handler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	ctx := r.Context()
+
+	tx, err := h.DB.Begin(ctx)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to delete user")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	current, err := scanUser(tx.QueryRow(ctx,
+		`SELECT id, username, email, role, totp_enabled_at IS NOT NULL, disabled_at, created_at, updated_at
+		 FROM users WHERE id = $1 FOR UPDATE`, id,
+	))
+	if err != nil {
+		writeError(w, http.StatusNotFound, "user not found")
+		return
+	}
+
+	if current.Role == middleware.RoleAdmin && current.DisabledAt == nil {
+		if err := ensureNotLastAdmin(ctx, tx, id); err != nil {
+			if errors.Is(err, errLastAdmin) {
+				writeError(w, http.StatusConflict, err.Error())
+				return
+			}
+			writeError(w, http.StatusInternalServerError, "failed to delete user")
+			return
+		}
+	}
+
+	if _, err := tx.Exec(ctx, `DELETE FROM users WHERE id = $1`, id); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to delete user")
+		return
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to delete user")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,312 @@
+// Package jwtkeys manages the keys used to sign and verify access tokens.
+//
+// Keys are PEM files in a directory, one per key, named <kid>.pem. Private
+// keys (PKCS#8 Ed25519 or RSA, or PKCS#1 RSA) can sign; public keys (PKIX)
+// only verify. Exactly one private key signs new tokens; every key verifies
+// and is published in the JWKS. To rotate: add the new key, switch
+// JWT_SIGNING_KID to it, and delete the old key once the tokens it signed
+// have expired (one ACCESS_TOKEN_TTL later). Replacing the old private key
+// with its public half keeps it verifying without letting it sign.
+//
+// Without a key directory the set falls back to HS256 with JWT_SECRET, which
+// other services can only verify by sharing the secret.
+//
+// Tokens that only this API reads, such as MFA-pending and preview tokens,
+// are signed by a separate Internal set, so that services verifying access
+// tokens through the JWKS or the shared secret never accept them.
+package jwtkeys
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Key is one signing or verification key.
+type Key struct {
+	ID      string
+	Method  jwt.SigningMethod
+	Public  crypto.PublicKey
+	Private crypto.Signer // nil for verification-only keys
+}
+
+// JWK is a public key in JSON Web Key form.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set.
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// Set holds the active keys. It is safe for concurrent use and can be
+// reloaded in place.
+type Set struct {
+	dir        string
+	signingKID string
+	secret     []byte
+
+	mu      sync.RWMutex
+	signing *Key
+	keys    map[string]*Key
+}
+
+// NewHMAC returns a set that signs and verifies with a shared HS256 secret.
+func NewHMAC(secret string) *Set {
+	return &Set{secret: []byte(secret)}
+}
+
+// internalKeyLabel separates the Internal key from the shared secret.
+const internalKeyLabel = "subcult-tv internal tokens"
+
+// Internal returns an HS256 set for tokens that only this API verifies. Its
+// key is derived from secret, so it differs from the NewHMAC(secret) key
+// shared with other services, and it is never published in a JWKS.
+func Internal(secret string) *Set {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(internalKeyLabel))
+	return &Set{secret: mac.Sum(nil)}
+}
+
+// Load returns a set backed by the PEM files in dir, signing with the key
+// named signingKID. signingKID may be empty when dir holds a single private
+// key. An empty dir falls back to NewHMAC(secret).
+func Load(dir, signingKID, secret string) (*Set, error) {
+	if dir == "" {
+		return NewHMAC(secret), nil
+	}
+	s := &Set{dir: dir, signingKID: signingKID}
+	if err := s.Reload(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// New returns a set of in-memory keys signing with signingKID. It is meant
+// for tests and tools that generate keys on the fly.
+func New(signingKID string, keys ...*Key) (*Set, error) {
+	s := &Set{signingKID: signingKID}
+	if err := s.install(keys); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Reload re-reads the key directory. On error the current keys stay in use.
+// HMAC sets have nothing to reload.
+func (s *Set) Reload() error {
+	if s.dir == "" {
+		return nil
+	}
+	paths, err := filepath.Glob(filepath.Join(s.dir, "*.pem"))
+	if err != nil {
+		return err
+	}
+	keys := make([]*Key, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		kid := strings.TrimSuffix(filepath.Base(path), ".pem")
+		key, err := ParsePEM(kid, data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		keys = append(keys, key)
+	}
+	return s.install(keys)
+}
+
+// install validates keys and swaps them in.
+func (s *Set) install(keys []*Key) error {
+	byID := make(map[string]*Key, len(keys))
+	var private []*Key
+	for _, k := range keys {
+		if _, dup := byID[k.ID]; dup {
+			return fmt.Errorf("duplicate key id %q", k.ID)
+		}
+		byID[k.ID] = k
+		if k.Private != nil {
+			private = append(private, k)
+		}
+	}
+
+	var signing *Key
+	switch {
+	case s.signingKID != "":
+		signing = byID[s.signingKID]
+		if signing == nil || signing.Private == nil {
+			return fmt.Errorf("no private key with id %q", s.signingKID)
+		}
+	case len(private) == 1:
+		signing = private[0]
+	case len(private) == 0:
+		return errors.New("no private key to sign with")
+	default:
+		return errors.New("several private keys, set JWT_SIGNING_KID to choose one")
+	}
+
+	s.mu.Lock()
+	s.signing = signing
+	s.keys = byID
+	s.mu.Unlock()
+	return nil
+}
+
+// Sign signs claims with the current signing key, setting the kid header.
+func (s *Set) Sign(claims jwt.Claims) (string, error) {
+	if s.secret != nil {
+		return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.secret)
+	}
+
+	s.mu.RLock()
+	signing := s.signing
+	s.mu.RUnlock()
+
+	token := jwt.NewWithClaims(signing.Method, claims)
+	token.Header["kid"] = signing.ID
+	return token.SignedString(signing.Private)
+}
+
+// Keyfunc resolves the verification key for a token by its kid header,
+// rejecting tokens whose algorithm does not match the key.
+func (s *Set) Keyfunc(t *jwt.Token) (interface{}, error) {
+	if s.secret != nil {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, jwt.ErrSignatureInvalid
+		}
+		return s.secret, nil
+	}
+
+	kid, _ := t.Header["kid"].(string)
+	s.mu.RLock()
+	key := s.keys[kid]
+	s.mu.RUnlock()
+	if key == nil {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	if t.Method.Alg() != key.Method.Alg() {
+		return nil, jwt.ErrSignatureInvalid
+	}
+	return key.Public, nil
+}
+
+// Parse verifies tokenStr and returns its claims.
+func (s *Set) Parse(tokenStr string) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(tokenStr, s.Keyfunc)
+	if err != nil {
+		return nil, err
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, jwt.ErrTokenInvalidClaims
+	}
+	return claims, nil
+}
+
+// JWKS returns the public half of every key, sorted by kid. HMAC sets
+// publish nothing.
+func (s *Set) JWKS() JWKS {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	set := JWKS{Keys: []JWK{}}
+	for _, k := range s.keys {
+		jwk := JWK{Kid: k.ID, Use: "sig", Alg: k.Method.Alg()}
+		switch pub := k.Public.(type) {
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].Kid < set.Keys[j].Kid })
+	return set
+}
+
+// ParsePEM decodes a private or public Ed25519 or RSA key.
+func ParsePEM(kid string, data []byte) (*Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	var parsed any
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	key := &Key{ID: kid}
+	switch k := parsed.(type) {
+	case ed25519.PrivateKey:
+		key.Method, key.Public, key.Private = jwt.SigningMethodEdDSA, k.Public(), k
+	case ed25519.PublicKey:
+		key.Method, key.Public = jwt.SigningMethodEdDSA, k
+	case *rsa.PrivateKey:
+		key.Method, key.Public, key.Private = jwt.SigningMethodRS256, k.Public(), k
+	case *rsa.PublicKey:
+		key.Method, key.Public = jwt.SigningMethodRS256, k
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", parsed)
+	}
+	if rsaKey, ok := key.Public.(*rsa.PublicKey); ok && rsaKey.N.BitLen() < 2048 {
+		return nil, errors.New("RSA keys must be at least 2048 bits")
+	}
+	return key, nil
+}
+
+// GenerateEd25519 creates a new Ed25519 key and returns it with its PKCS#8
+// PEM encoding.
+func GenerateEd25519(kid string) (*Key, []byte, error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return nil, nil, err
+	}
+	key := &Key{ID: kid, Method: jwt.SigningMethodEdDSA, Public: pub, Private: priv}
+	return key, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
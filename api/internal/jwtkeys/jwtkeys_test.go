@@ -0,0 +1,193 @@
+package jwtkeys
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func claims() jwt.MapClaims {
+	return jwt.MapClaims{"sub": "u1", "exp": time.Now().Add(time.Minute).Unix()}
+}
+
+func generate(t *testing.T, kid string) (*Key, []byte) {
+	t.Helper()
+	key, data, err := GenerateEd25519(kid)
+	if err != nil {
+		t.Fatalf("GenerateEd25519: %v", err)
+	}
+	return key, data
+}
+
+// TestSignSetsKid tests that tokens carry the signing key id and verify.
+func TestSignSetsKid(t *testing.T) {
+	key, _ := generate(t, "k1")
+	set, err := New("k1", key)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	tokenStr, err := set.Sign(claims())
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	token, _, err := jwt.NewParser().ParseUnverified(tokenStr, jwt.MapClaims{})
+	if err != nil {
+		t.Fatalf("ParseUnverified: %v", err)
+	}
+	if token.Header["kid"] != "k1" || token.Header["alg"] != "EdDSA" {
+		t.Errorf("unexpected header %v", token.Header)
+	}
+	if _, err := set.Parse(tokenStr); err != nil {
+		t.Errorf("Parse: %v", err)
+	}
+}
+
+// TestRotation tests that tokens signed by a retired key keep verifying
+// while it is still in the set, and stop once it is removed.
+func TestRotation(t *testing.T) {
+	oldKey, _ := generate(t, "old")
+	newKey, _ := generate(t, "new")
+
+	before, _ := New("old", oldKey)
+	oldToken, err := before.Sign(claims())
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+
+	during, err := New("new", oldKey, newKey)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if _, err := during.Parse(oldToken); err != nil {
+		t.Errorf("old token rejected during rotation: %v", err)
+	}
+	newToken, _ := during.Sign(claims())
+	if _, err := before.Parse(newToken); err == nil {
+		t.Error("expected unknown kid to be rejected")
+	}
+
+	after, _ := New("new", newKey)
+	if _, err := after.Parse(oldToken); err == nil {
+		t.Error("expected token from removed key to be rejected")
+	}
+}
+
+// TestRejectsOtherAlgorithms tests that a token cannot pick a different
+// algorithm than its key, e.g. HS256 keyed with the public key.
+func TestRejectsOtherAlgorithms(t *testing.T) {
+	key, _ := generate(t, "k1")
+	set, _ := New("k1", key)
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims())
+	token.Header["kid"] = "k1"
+	forged, err := token.SignedString([]byte(key.Public.(ed25519.PublicKey)))
+	if err != nil {
+		t.Fatalf("SignedString: %v", err)
+	}
+	if _, err := set.Parse(forged); err == nil {
+		t.Error("expected HS256 token to be rejected")
+	}
+
+	hmac := NewHMAC("secret")
+	if _, err := hmac.Parse(forged); err == nil {
+		t.Error("expected token signed with another secret to be rejected")
+	}
+	hmacToken, _ := hmac.Sign(claims())
+	if _, err := set.Parse(hmacToken); err == nil {
+		t.Error("expected kid-less HS256 token to be rejected by asymmetric set")
+	}
+}
+
+// TestInternal tests that internal tokens verify only with an internal set
+// built from the same secret, and not with the shared HS256 secret.
+func TestInternal(t *testing.T) {
+	internal := Internal("secret")
+	token, err := internal.Sign(claims())
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	if _, err := Internal("secret").Parse(token); err != nil {
+		t.Errorf("Parse: %v", err)
+	}
+	if _, err := NewHMAC("secret").Parse(token); err == nil {
+		t.Error("expected internal token to be rejected by the shared secret")
+	}
+	shared, _ := NewHMAC("secret").Sign(claims())
+	if _, err := internal.Parse(shared); err == nil {
+		t.Error("expected shared-secret token to be rejected by the internal set")
+	}
+	if keys := internal.JWKS().Keys; len(keys) != 0 {
+		t.Errorf("internal set publishes %v", keys)
+	}
+}
+
+// TestLoadDir tests loading keys from disk, including verification-only
+// public keys and RSA keys, and the resulting JWKS.
+func TestLoadDir(t *testing.T) {
+	dir := t.TempDir()
+	_, edPEM := generate(t, "ed")
+	write := func(name string, data []byte) {
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("ed.pem", edPEM)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, _ := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	write("retired.pem", pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+
+	set, err := Load(dir, "", "unused")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	jwks := set.JWKS()
+	if len(jwks.Keys) != 2 {
+		t.Fatalf("expected 2 keys, got %d", len(jwks.Keys))
+	}
+	if k := jwks.Keys[0]; k.Kid != "ed" || k.Kty != "OKP" || k.Crv != "Ed25519" || k.X == "" {
+		t.Errorf("unexpected Ed25519 JWK %+v", k)
+	}
+	if k := jwks.Keys[1]; k.Kid != "retired" || k.Kty != "RSA" || k.Alg != "RS256" || k.E != "AQAB" {
+		t.Errorf("unexpected RSA JWK %+v", k)
+	}
+
+	if _, err := Load(dir, "retired", ""); err == nil {
+		t.Error("expected public-only key to be refused for signing")
+	}
+}
+
+// TestReloadKeepsKeysOnError tests that a bad reload leaves the old keys active.
+func TestReloadKeepsKeysOnError(t *testing.T) {
+	dir := t.TempDir()
+	_, data := generate(t, "k1")
+	if err := os.WriteFile(filepath.Join(dir, "k1.pem"), data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	set, err := Load(dir, "k1", "")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	tokenStr, _ := set.Sign(claims())
+
+	if err := os.WriteFile(filepath.Join(dir, "k2.pem"), []byte("garbage"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := set.Reload(); err == nil {
+		t.Error("expected reload to fail")
+	}
+	if _, err := set.Parse(tokenStr); err != nil {
+		t.Errorf("token rejected after failed reload: %v", err)
+	}
+}
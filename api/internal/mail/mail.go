@@ -0,0 +1,81 @@
+// Package mail sends transactional email (password resets, invites)
+// through a pluggable Sender so it can be pointed at a real SMTP relay in
+// production and a local catcher such as Mailpit in development.
+package mail
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// Message is a plain-text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender delivers a Message.
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// SMTPSender delivers mail through an SMTP server. STARTTLS is used when
+// the server offers it; authentication is only attempted when Username is
+// set.
+type SMTPSender struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+// Send implements Sender.
+func (s *SMTPSender) Send(_ context.Context, msg Message) error {
+	var auth smtp.Auth
+	if s.Username != "" {
+		auth = smtp.PlainAuth("", s.Username, s.Password, s.Host)
+	}
+	addr := net.JoinHostPort(s.Host, s.Port)
+	if err := smtp.SendMail(addr, auth, s.From, []string{msg.To}, buildMessage(s.From, msg, time.Now())); err != nil {
+		return fmt.Errorf("send mail to %s: %w", msg.To, err)
+	}
+	return nil
+}
+
+// LogSender is used when no SMTP server is configured. It records that a
+// message would have been sent without logging its body, which may carry
+// secrets such as reset tokens.
+type LogSender struct{}
+
+// Send implements Sender.
+func (LogSender) Send(_ context.Context, msg Message) error {
+	slog.Warn("smtp not configured, email not sent", "to", msg.To, "subject", msg.Subject)
+	return nil
+}
+
+// buildMessage renders msg as an RFC 5322 message with CRLF line endings.
+func buildMessage(from string, msg Message, date time.Time) []byte {
+	var b strings.Builder
+	header := func(k, v string) {
+		// Strip CR/LF so user-controlled values cannot inject headers.
+		v = strings.NewReplacer("\r", "", "\n", "").Replace(v)
+		b.WriteString(k + ": " + v + "\r\n")
+	}
+	header("From", from)
+	header("To", msg.To)
+	header("Subject", msg.Subject)
+	header("Date", date.Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+	header("Content-Type", "text/plain; charset=UTF-8")
+	b.WriteString("\r\n")
+	body := strings.ReplaceAll(msg.Body, "\r\n", "\n")
+	b.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	return []byte(b.String())
+}
@@ -0,0 +1,105 @@
+package mail
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestBuildMessage tests header rendering and header injection stripping.
+func TestBuildMessage(t *testing.T) {
+	raw := string(buildMessage("noreply@subcult.tv", Message{
+		To:      "reader@example.com",
+		Subject: "hello\r\nBcc: evil@example.com",
+		Body:    "line one\nline two",
+	}, time.Unix(0, 0)))
+
+	if !strings.Contains(raw, "Subject: helloBcc: evil@example.com\r\n") {
+		t.Errorf("expected CR/LF stripped from subject, got %q", raw)
+	}
+	if strings.Contains(raw, "\r\nBcc:") {
+		t.Error("header injection was not prevented")
+	}
+	if !strings.HasSuffix(raw, "\r\n\r\nline one\r\nline two") {
+		t.Errorf("expected CRLF body, got %q", raw)
+	}
+}
+
+// TestSMTPSender tests delivery against a minimal in-process SMTP catcher.
+func TestSMTPSender(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+
+	received := make(chan string, 1)
+	go serveOneMessage(t, ln, received)
+
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+	sender := &SMTPSender{Host: host, Port: port, From: "noreply@subcult.tv"}
+	err = sender.Send(context.Background(), Message{
+		To:      "reader@example.com",
+		Subject: "reset your password",
+		Body:    "token: abc",
+	})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	select {
+	case data := <-received:
+		if !strings.Contains(data, "Subject: reset your password") || !strings.Contains(data, "token: abc") {
+			t.Errorf("unexpected message data: %q", data)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for message")
+	}
+}
+
+// serveOneMessage speaks just enough SMTP to accept a single message.
+func serveOneMessage(t *testing.T, ln net.Listener, received chan<- string) {
+	conn, err := ln.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	reply := func(s string) { conn.Write([]byte(s + "\r\n")) }
+	reply("220 localhost ESMTP test")
+
+	var data strings.Builder
+	inData := false
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		if inData {
+			if line == ".\r\n" {
+				inData = false
+				received <- data.String()
+				reply("250 OK")
+				continue
+			}
+			data.WriteString(line)
+			continue
+		}
+		switch cmd := strings.ToUpper(strings.TrimSpace(line)); {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(cmd, "DATA"):
+			inData = true
+			reply("354 go ahead")
+		case strings.HasPrefix(cmd, "QUIT"):
+			reply("221 bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
@@ -5,55 +5,132 @@ import (
 	"net/http"
 	"strings"
 
-	"github.com/golang-jwt/jwt/v5"
+	"github.com/subculture-collective/subcult-tv/api/internal/jwtkeys"
 )
 
 type contextKey string
 
 const UserIDKey contextKey = "user_id"
 const UsernameKey contextKey = "username"
+const SessionIDKey contextKey = "session_id"
+const RoleKey contextKey = "role"
+const ScopesKey contextKey = "scopes"
 
-// Auth returns middleware that validates JWT tokens.
-func Auth(secret string) func(http.Handler) http.Handler {
+// APITokenPrefix marks personal access tokens so Auth can tell them apart
+// from JWTs without trying to parse them.
+const APITokenPrefix = "sct_"
+
+// TokenIdentity is the user and scopes behind a personal access token.
+type TokenIdentity struct {
+	UserID   string
+	Username string
+	Role     string
+	Scopes   []Permission
+}
+
+// AuthStore backs Auth with the state that cannot live in the token itself.
+type AuthStore interface {
+	// SessionActive reports whether the login session behind an access
+	// token is still valid (not revoked, not expired, user not disabled).
+	SessionActive(ctx context.Context, sessionID string) (bool, error)
+	// LookupAPIToken resolves a personal access token, returning nil when
+	// it is unknown, expired, or revoked.
+	LookupAPIToken(ctx context.Context, token string) (*TokenIdentity, error)
+}
+
+// Auth returns middleware that accepts either a JWT access token, rejecting
+// those whose session has been revoked, or a personal access token. Tokens
+// come from the Authorization header or, in cookie mode, SessionCookie. JWTs
+// are verified with the key named by their kid header.
+func Auth(keys *jwtkeys.Set, store AuthStore) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Get token from Authorization header
-			authHeader := r.Header.Get("Authorization")
-			if authHeader == "" {
+			// Get token from Authorization header, falling back to the
+			// session cookie. Cookie-authenticated writes need a CSRF token.
+			var tokenStr string
+			if authHeader := r.Header.Get("Authorization"); authHeader != "" {
+				parts := strings.SplitN(authHeader, " ", 2)
+				if len(parts) != 2 || !strings.EqualFold(parts[0], "bearer") {
+					http.Error(w, `{"error":"invalid authorization format","code":401}`, http.StatusUnauthorized)
+					return
+				}
+				tokenStr = parts[1]
+			} else if cookie, err := r.Cookie(SessionCookie); err == nil && cookie.Value != "" {
+				if !ValidCSRF(r) {
+					http.Error(w, `{"error":"invalid csrf token","code":403}`, http.StatusForbidden)
+					return
+				}
+				tokenStr = cookie.Value
+			} else {
 				http.Error(w, `{"error":"missing authorization header","code":401}`, http.StatusUnauthorized)
 				return
 			}
 
-			parts := strings.SplitN(authHeader, " ", 2)
-			if len(parts) != 2 || !strings.EqualFold(parts[0], "bearer") {
-				http.Error(w, `{"error":"invalid authorization format","code":401}`, http.StatusUnauthorized)
+			if strings.HasPrefix(tokenStr, APITokenPrefix) {
+				identity, err := store.LookupAPIToken(r.Context(), tokenStr)
+				if err != nil {
+					http.Error(w, `{"error":"failed to verify token","code":500}`, http.StatusInternalServerError)
+					return
+				}
+				if identity == nil {
+					http.Error(w, `{"error":"invalid or expired token","code":401}`, http.StatusUnauthorized)
+					return
+				}
+
+				ctx := context.WithValue(r.Context(), UserIDKey, identity.UserID)
+				ctx = context.WithValue(ctx, UsernameKey, identity.Username)
+				ctx = context.WithValue(ctx, RoleKey, identity.Role)
+				ctx = context.WithValue(ctx, ScopesKey, identity.Scopes)
+				next.ServeHTTP(w, r.WithContext(ctx))
 				return
 			}
 
-			tokenStr := parts[1]
-			token, err := jwt.Parse(tokenStr, func(t *jwt.Token) (interface{}, error) {
-				if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-					return nil, jwt.ErrSignatureInvalid
-				}
-				return []byte(secret), nil
-			})
-			if err != nil || !token.Valid {
+			claims, err := keys.Parse(tokenStr)
+			if err != nil {
 				http.Error(w, `{"error":"invalid or expired token","code":401}`, http.StatusUnauthorized)
 				return
 			}
 
-			claims, ok := token.Claims.(jwt.MapClaims)
-			if !ok {
+			userID, _ := claims["sub"].(string)
+			username, _ := claims["username"].(string)
+			role, _ := claims["role"].(string)
+			sessionID, _ := claims["sid"].(string)
+
+			if sessionID == "" {
 				http.Error(w, `{"error":"invalid token claims","code":401}`, http.StatusUnauthorized)
 				return
 			}
-
-			userID, _ := claims["sub"].(string)
-			username, _ := claims["username"].(string)
+			active, err := store.SessionActive(r.Context(), sessionID)
+			if err != nil {
+				http.Error(w, `{"error":"failed to verify session","code":500}`, http.StatusInternalServerError)
+				return
+			}
+			if !active {
+				http.Error(w, `{"error":"session revoked","code":401}`, http.StatusUnauthorized)
+				return
+			}
 
 			ctx := context.WithValue(r.Context(), UserIDKey, userID)
 			ctx = context.WithValue(ctx, UsernameKey, username)
+			ctx = context.WithValue(ctx, RoleKey, role)
+			ctx = context.WithValue(ctx, SessionIDKey, sessionID)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
+
+// RequireSession rejects requests authenticated with a personal access
+// token. It guards account self-service routes (passwords, 2FA, token
+// management) so a leaked automation token cannot escalate. It must run
+// after Auth.
+func RequireSession(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if sid, _ := r.Context().Value(SessionIDKey).(string); sid == "" {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"error":"this endpoint requires an interactive login","code":403}`))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+)
+
+// Cookie-mode authentication. The access and refresh tokens live in
+// HttpOnly cookies the frontend cannot read; the CSRF cookie is readable so
+// the frontend can echo it back in CSRFHeader (double-submit pattern).
+const (
+	SessionCookie = "subcult_session"
+	RefreshCookie = "subcult_refresh"
+	CSRFCookie    = "subcult_csrf"
+	CSRFHeader    = "X-CSRF-Token"
+)
+
+// ValidCSRF reports whether the request's CSRF header matches its CSRF
+// cookie. Safe methods (GET, HEAD, OPTIONS) always pass.
+func ValidCSRF(r *http.Request) bool {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	cookie, err := r.Cookie(CSRFCookie)
+	if err != nil || cookie.Value == "" {
+		return false
+	}
+	header := r.Header.Get(CSRFHeader)
+	return subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(header)) == 1
+}
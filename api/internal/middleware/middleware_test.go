@@ -1,132 +1,288 @@
 package middleware
-package middleware
 
 import (
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-}	}		t.Errorf("GET request: expected status %d, got %d", http.StatusOK, recorder.Code)	if recorder.Code != http.StatusOK {	handler.ServeHTTP(recorder, req)	recorder := httptest.NewRecorder()	req := httptest.NewRequest(http.MethodGet, "/", nil)	// GET request should pass through unchanged	}))		w.WriteHeader(http.StatusOK)	handler := LimitBody(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {func TestLimitBodyMiddleware(t *testing.T) {// TestLimitBodyMiddleware tests the body size limit middleware.}	}		t.Error("expected Retry-After: 60 header")	if recorder.Header().Get("Retry-After") != "60" {	// Check Retry-After header	}		t.Errorf("expected status %d, got %d", http.StatusTooManyRequests, recorder.Code)	if recorder.Code != http.StatusTooManyRequests {	handler.ServeHTTP(recorder, req)	recorder := httptest.NewRecorder()	req.RemoteAddr = "192.168.1.100:12345"	req := httptest.NewRequest(http.MethodGet, "/", nil)	// 3rd request should be rate limited	}		}			t.Errorf("request %d: expected status %d, got %d", i+1, http.StatusOK, recorder.Code)		if recorder.Code != http.StatusOK {		handler.ServeHTTP(recorder, req)		recorder := httptest.NewRecorder()		req.RemoteAddr = "192.168.1.100:12345"		req := httptest.NewRequest(http.MethodGet, "/", nil)	for i := 0; i < 2; i++ {	// First 2 requests should pass	}))		w.WriteHeader(http.StatusOK)	handler := RateLimit(limiter)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {	limiter := NewRateLimiter(2, time.Minute)func TestRateLimitMiddleware(t *testing.T) {// TestRateLimitMiddleware tests the HTTP middleware wrapper.}	}		t.Error("should be allowed after window reset")	if !limiter.Allow("10.0.0.1") {	// Should be allowed again	time.Sleep(150 * time.Millisecond)	// Wait for window to reset	}		t.Error("should be blocked after limit")	if limiter.Allow("10.0.0.1") {	// Should be blocked	limiter.Allow("10.0.0.1")	limiter.Allow("10.0.0.1")	// Use up the limit	limiter := NewRateLimiter(2, 100*time.Millisecond)	// Use a very short window for testingfunc TestRateLimiterWindowReset(t *testing.T) {// TestRateLimiterWindowReset tests that the rate limit resets after the window.}	}		t.Error("different IP should be allowed")	if !limiter.Allow("192.168.1.2") {	// Different IP should be allowed	}		t.Error("4th request should be blocked")	if limiter.Allow("192.168.1.1") {	// 4th request should be blocked	}		}			t.Errorf("request %d should be allowed", i+1)		if !limiter.Allow("192.168.1.1") {	for i := 0; i < 3; i++ {	// First 3 requests should pass	limiter := NewRateLimiter(3, time.Minute)func TestRateLimiterBasic(t *testing.T) {// TestRateLimiterBasic tests basic rate limiting functionality.}	}		}			t.Errorf("expected %s: %s, got: %s", header, expected, actual)		if actual != expected {		actual := recorder.Header().Get(header)	for header, expected := range expectedHeaders {	}		"Referrer-Policy":        "strict-origin-when-cross-origin",		"X-XSS-Protection":       "1; mode=block",		"X-Content-Type-Options": "nosniff",		"X-Frame-Options":        "DENY",	expectedHeaders := map[string]string{	handler.ServeHTTP(recorder, req)	recorder := httptest.NewRecorder()	req := httptest.NewRequest(http.MethodGet, "/", nil)	}))		w.WriteHeader(http.StatusOK)	handler := SecurityHeaders(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {func TestSecurityHeaders(t *testing.T) {// TestSecurityHeaders tests that all security headers are set correctly.)	"time"	"testing"	"net/http/httptest"	"net/http"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/subculture-collective/subcult-tv/api/internal/jwtkeys"
+)
+
+// TestSecurityHeaders tests that all security headers are set correctly.
+func TestSecurityHeaders(t *testing.T) {
+	handler := SecurityHeaders(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	expectedHeaders := map[string]string{
+		"X-Frame-Options":        "DENY",
+		"X-Content-Type-Options": "nosniff",
+		"X-XSS-Protection":       "1; mode=block",
+		"Referrer-Policy":        "strict-origin-when-cross-origin",
+	}
+	for header, expected := range expectedHeaders {
+		actual := recorder.Header().Get(header)
+		if actual != expected {
+			t.Errorf("expected %s: %s, got: %s", header, expected, actual)
+		}
+	}
+}
+
+// TestRateLimiterBasic tests basic rate limiting functionality.
+func TestRateLimiterBasic(t *testing.T) {
+	limiter := NewRateLimiter(3, time.Minute)
+	// First 3 requests should pass
+	for i := 0; i < 3; i++ {
+		if !limiter.Allow("192.168.1.1") {
+			t.Errorf("request %d should be allowed", i+1)
+		}
+	}
+	// 4th request should be blocked
+	if limiter.Allow("192.168.1.1") {
+		t.Error("4th request should be blocked")
+	}
+	// Different IP should be allowed
+	if !limiter.Allow("192.168.1.2") {
+		t.Error("different IP should be allowed")
+	}
+}
+
+// TestRateLimiterWindowReset tests that the rate limit resets after the window.
+func TestRateLimiterWindowReset(t *testing.T) {
+	// Use a very short window for testing
+	limiter := NewRateLimiter(2, 100*time.Millisecond)
+	// Use up the limit
+	limiter.Allow("10.0.0.1")
+	limiter.Allow("10.0.0.1")
+	// Should be blocked
+	if limiter.Allow("10.0.0.1") {
+		t.Error("should be blocked after limit")
+	}
+	// Wait for window to reset
+	time.Sleep(150 * time.Millisecond)
+	// Should be allowed again
+	if !limiter.Allow("10.0.0.1") {
+		t.Error("should be allowed after window reset")
+	}
+}
+
+// TestRateLimitMiddleware tests the HTTP middleware wrapper.
+func TestRateLimitMiddleware(t *testing.T) {
+	limiter := NewRateLimiter(2, time.Minute)
+	handler := RateLimit(limiter)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	// First 2 requests should pass
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = "192.168.1.100:12345"
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+		if recorder.Code != http.StatusOK {
+			t.Errorf("request %d: expected status %d, got %d", i+1, http.StatusOK, recorder.Code)
+		}
+	}
+	// 3rd request should be rate limited
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = "192.168.1.100:12345"
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	if recorder.Code != http.StatusTooManyRequests {
+		t.Errorf("expected status %d, got %d", http.StatusTooManyRequests, recorder.Code)
+	}
+	// Check Retry-After header
+	if recorder.Header().Get("Retry-After") != "60" {
+		t.Error("expected Retry-After: 60 header")
+	}
+}
+
+// TestLimitBodyMiddleware tests the body size limit middleware.
+func TestLimitBodyMiddleware(t *testing.T) {
+	handler := LimitBody(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	// GET request should pass through unchanged
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+	if recorder.Code != http.StatusOK {
+		t.Errorf("GET request: expected status %d, got %d", http.StatusOK, recorder.Code)
+	}
+}
+
+// fakeStore is an in-memory AuthStore for auth tests.
+type fakeStore struct {
+	sessions map[string]bool
+	tokens   map[string]*TokenIdentity
+}
+
+func (f fakeStore) SessionActive(_ context.Context, id string) (bool, error) {
+	return f.sessions[id], nil
+}
+
+func (f fakeStore) LookupAPIToken(_ context.Context, token string) (*TokenIdentity, error) {
+	return f.tokens[token], nil
+}
+
+func signTestToken(t *testing.T, secret string, claims jwt.MapClaims) string {
+	t.Helper()
+	s, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secret))
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return s
+}
+
+// TestAuthSessionRevocation tests that tokens for revoked sessions are rejected.
+func TestAuthSessionRevocation(t *testing.T) {
+	const secret = "test-secret"
+	store := fakeStore{sessions: map[string]bool{"live": true, "revoked": false}}
+	handler := Auth(jwtkeys.NewHMAC(secret), store)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Context().Value(SessionIDKey) != "live" {
+			t.Errorf("expected session id in context, got %v", r.Context().Value(SessionIDKey))
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	exp := time.Now().Add(time.Minute).Unix()
+	tests := []struct {
+		name     string
+		claims   jwt.MapClaims
+		expected int
+	}{
+		{"active session", jwt.MapClaims{"sub": "u1", "sid": "live", "exp": exp}, http.StatusOK},
+		{"revoked session", jwt.MapClaims{"sub": "u1", "sid": "revoked", "exp": exp}, http.StatusUnauthorized},
+		{"missing session claim", jwt.MapClaims{"sub": "u1", "exp": exp}, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Authorization", "Bearer "+signTestToken(t, secret, tt.claims))
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, req)
+			if recorder.Code != tt.expected {
+				t.Errorf("expected status %d, got %d", tt.expected, recorder.Code)
+			}
+		})
+	}
+}
+
+// TestRoleHas tests the role to permission mapping.
+func TestRoleHas(t *testing.T) {
+	tests := []struct {
+		role     string
+		perm     Permission
+		expected bool
+	}{
+		{RoleAdmin, PermSubscribersRead, true},
+		{RoleAdmin, PermUsersManage, true},
+		{RoleEditor, PermPostsWrite, true},
+		{RoleEditor, PermProjectsWrite, true},
+		{RoleEditor, PermContactsRead, false},
+		{RoleEditor, PermSubscribersRead, false},
+		{RoleModerator, PermContactsWrite, true},
+		{RoleModerator, PermCommentsWrite, true},
+		{RoleEditor, PermCommentsWrite, false},
+		{RoleModerator, PermPostsWrite, false},
+		{"", PermStatsRead, false},
+		{"superuser", PermStatsRead, false},
+	}
+	for _, tt := range tests {
+		if result := RoleHas(tt.role, tt.perm); result != tt.expected {
+			t.Errorf("RoleHas(%q, %q) = %v, expected %v", tt.role, tt.perm, result, tt.expected)
+		}
+	}
+}
+
+// TestRequirePermission tests that missing permissions return 403.
+func TestRequirePermission(t *testing.T) {
+	handler := RequirePermission(PermSubscribersRead)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	for role, expected := range map[string]int{
+		RoleAdmin:  http.StatusOK,
+		RoleEditor: http.StatusForbidden,
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req = req.WithContext(context.WithValue(req.Context(), RoleKey, role))
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+		if recorder.Code != expected {
+			t.Errorf("role %s: expected status %d, got %d", role, expected, recorder.Code)
+		}
+	}
+}
+
+// TestAPITokenScopes tests that personal access tokens are limited to their scopes.
+func TestAPITokenScopes(t *testing.T) {
+	store := fakeStore{tokens: map[string]*TokenIdentity{
+		"sct_editor": {UserID: "u1", Role: RoleEditor, Scopes: []Permission{PermPostsWrite}},
+	}}
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	tests := []struct {
+		name     string
+		token    string
+		handler  http.Handler
+		expected int
+	}{
+		{"scoped permission", "sct_editor", RequirePermission(PermPostsWrite)(ok), http.StatusOK},
+		{"permission outside scopes", "sct_editor", RequirePermission(PermProjectsWrite)(ok), http.StatusForbidden},
+		{"session-only route", "sct_editor", RequireSession(ok), http.StatusForbidden},
+		{"unknown token", "sct_unknown", ok, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+			recorder := httptest.NewRecorder()
+			Auth(jwtkeys.NewHMAC("test-secret"), store)(tt.handler).ServeHTTP(recorder, req)
+			if recorder.Code != tt.expected {
+				t.Errorf("expected status %d, got %d", tt.expected, recorder.Code)
+			}
+		})
+	}
+}
+
+// TestAuthCookieCSRF tests that cookie sessions require a matching CSRF token
+// on unsafe methods.
+func TestAuthCookieCSRF(t *testing.T) {
+	const secret = "test-secret"
+	store := fakeStore{sessions: map[string]bool{"live": true}}
+	handler := Auth(jwtkeys.NewHMAC(secret), store)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	token := signTestToken(t, secret, jwt.MapClaims{"sub": "u1", "sid": "live", "exp": time.Now().Add(time.Minute).Unix()})
+	tests := []struct {
+		name     string
+		method   string
+		header   string
+		expected int
+	}{
+		{"safe method without csrf", http.MethodGet, "", http.StatusOK},
+		{"unsafe method with csrf", http.MethodPost, "csrf-value", http.StatusOK},
+		{"unsafe method without csrf", http.MethodPost, "", http.StatusForbidden},
+		{"unsafe method with wrong csrf", http.MethodDelete, "other", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/", nil)
+			req.AddCookie(&http.Cookie{Name: SessionCookie, Value: token})
+			req.AddCookie(&http.Cookie{Name: CSRFCookie, Value: "csrf-value"})
+			if tt.header != "" {
+				req.Header.Set(CSRFHeader, tt.header)
+			}
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, req)
+			if recorder.Code != tt.expected {
+				t.Errorf("expected status %d, got %d", tt.expected, recorder.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"net/http"
+)
+
+// Roles a user can hold (users.role).
+const (
+	RoleAdmin     = "admin"
+	RoleEditor    = "editor"
+	RoleModerator = "moderator"
+)
+
+// Permission names a single capability checked by RequirePermission.
+type Permission string
+
+const (
+	PermStatsRead       Permission = "stats:read"
+	PermPostsWrite      Permission = "posts:write"
+	PermProjectsWrite   Permission = "projects:write"
+	PermContactsRead    Permission = "contacts:read"
+	PermContactsWrite   Permission = "contacts:write"
+	PermCommentsWrite   Permission = "comments:write"
+	PermSubscribersRead Permission = "subscribers:read"
+	PermUsersManage     Permission = "users:manage"
+)
+
+// rolePermissions maps each role to the permissions it grants. Admins are
+// granted everything and are not listed here.
+var rolePermissions = map[string][]Permission{
+	RoleEditor: {
+		PermStatsRead,
+		PermPostsWrite,
+		PermProjectsWrite,
+	},
+	RoleModerator: {
+		PermStatsRead,
+		PermContactsRead,
+		PermContactsWrite,
+		PermCommentsWrite,
+	},
+}
+
+// AllPermissions lists every permission, e.g. to validate token scopes.
+var AllPermissions = []Permission{
+	PermStatsRead,
+	PermPostsWrite,
+	PermProjectsWrite,
+	PermContactsRead,
+	PermContactsWrite,
+	PermCommentsWrite,
+	PermSubscribersRead,
+	PermUsersManage,
+}
+
+// ValidPermission reports whether p is a known permission.
+func ValidPermission(p Permission) bool {
+	for _, known := range AllPermissions {
+		if known == p {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidRole reports whether role is one of the known roles.
+func ValidRole(role string) bool {
+	if role == RoleAdmin {
+		return true
+	}
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// RoleHas reports whether role grants perm.
+func RoleHas(role string, perm Permission) bool {
+	if role == RoleAdmin {
+		return true
+	}
+	for _, p := range rolePermissions[role] {
+		if p == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// RequirePermission returns middleware that rejects requests whose
+// authenticated role lacks perm. Requests made with a personal access
+// token additionally need perm among the token's scopes. It must run after
+// Auth.
+func RequirePermission(perm Permission) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			role, _ := r.Context().Value(RoleKey).(string)
+			allowed := RoleHas(role, perm)
+			if scopes, ok := r.Context().Value(ScopesKey).([]Permission); ok && allowed {
+				allowed = false
+				for _, s := range scopes {
+					if s == perm {
+						allowed = true
+						break
+					}
+				}
+			}
+			if !allowed {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(http.StatusForbidden)
+				w.Write([]byte(`{"error":"forbidden","code":403}`))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
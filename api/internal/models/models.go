@@ -9,23 +9,166 @@ import (
 // ── User ─────────────────────────────────────────────────────
 
 type User struct {
-	ID           uuid.UUID `json:"id"`
-	Username     string    `json:"username"`
-	Email        string    `json:"email"`
-	PasswordHash string    `json:"-"`
-	Role         string    `json:"role"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID           uuid.UUID  `json:"id"`
+	Username     string     `json:"username"`
+	Email        string     `json:"email"`
+	PasswordHash string     `json:"-"`
+	Role         string     `json:"role"`
+	TOTPEnabled  bool       `json:"totp_enabled"`
+	DisabledAt   *time.Time `json:"disabled_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+type CreateUserRequest struct {
+	Username string `json:"username"`
+	Email    string `json:"email"`
+	Role     string `json:"role"`
+	Password string `json:"password,omitempty"` // generated when empty
+}
+
+// UpdateUserRequest changes only the fields that are set.
+type UpdateUserRequest struct {
+	Email    *string `json:"email,omitempty"`
+	Role     *string `json:"role,omitempty"`
+	Disabled *bool   `json:"disabled,omitempty"`
+}
+
+type ResetPasswordRequest struct {
+	Password string `json:"password,omitempty"` // generated when empty
+}
+
+// UserCredentialsResponse returns a user together with a generated
+// password, which is only ever shown once.
+type UserCredentialsResponse struct {
+	User              User   `json:"user"`
+	TemporaryPassword string `json:"temporary_password,omitempty"`
 }
 
 type LoginRequest struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
+	Mode     string `json:"mode,omitempty"` // "bearer" (default) or "cookie"
 }
 
+// LoginResponse carries the token pair in bearer mode. In cookie mode the
+// tokens are set as HttpOnly cookies instead and only CSRFToken is returned.
 type LoginResponse struct {
+	Token         string    `json:"token,omitempty"`
+	RefreshToken  string    `json:"refresh_token,omitempty"`
+	CSRFToken     string    `json:"csrf_token,omitempty"`
+	ExpiresAt     time.Time `json:"expires_at"`
+	User          User      `json:"user"`
+	RecoveryCodes []string  `json:"recovery_codes,omitempty"` // set when 2FA was enrolled during login
+}
+
+// MFAChallengeResponse is returned by Login and OIDCExchange instead of a
+// LoginResponse when the user must complete a second factor. MFAToken is
+// exchanged, together with a code, at /auth/login/2fa.
+type MFAChallengeResponse struct {
+	MFARequired        bool   `json:"mfa_required"`
+	MFAToken           string `json:"mfa_token"`
+	EnrollmentRequired bool   `json:"enrollment_required"`
+}
+
+// OIDCStatusResponse tells the login page whether to offer single sign-on.
+type OIDCStatusResponse struct {
+	Enabled bool `json:"enabled"`
+}
+
+// OIDCExchangeRequest redeems the one-time code from the SSO callback.
+type OIDCExchangeRequest struct {
+	Code string `json:"code"`
+	Mode string `json:"mode,omitempty"` // see LoginRequest.Mode
+}
+
+type MFALoginRequest struct {
+	MFAToken     string `json:"mfa_token"`
+	Code         string `json:"code,omitempty"`
+	RecoveryCode string `json:"recovery_code,omitempty"`
+	Mode         string `json:"mode,omitempty"` // see LoginRequest.Mode
+}
+
+type MFASetupRequest struct {
+	MFAToken string `json:"mfa_token,omitempty"` // only during login enrollment
+}
+
+type MFASetupResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code"`
+}
+
+type MFADisableRequest struct {
+	Password string `json:"password"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email"`
+}
+
+type CompletePasswordResetRequest struct {
+	Token       string `json:"token"`
+	NewPassword string `json:"new_password"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type LogoutRequest = RefreshRequest
+
+// ── Session ──────────────────────────────────────────────────
+
+type Session struct {
+	ID         uuid.UUID  `json:"id"`
+	UserID     uuid.UUID  `json:"user_id"`
+	UserAgent  string     `json:"user_agent"`
+	IP         string     `json:"ip"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt time.Time  `json:"last_used_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// ── API token ────────────────────────────────────────────────
+
+type APIToken struct {
+	ID         uuid.UUID  `json:"id"`
+	UserID     uuid.UUID  `json:"user_id"`
+	Username   string     `json:"username"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type CreateAPITokenRequest struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expires_in_days,omitempty"`
+}
+
+// CreateAPITokenResponse carries the plaintext token, which is only ever
+// shown once.
+type CreateAPITokenResponse struct {
+	APIToken
 	Token string `json:"token"`
-	User  User   `json:"user"`
 }
 
 // ── Project ──────────────────────────────────────────────────
@@ -76,32 +219,308 @@ type UpdateProjectRequest = CreateProjectRequest
 // ── Post ─────────────────────────────────────────────────────
 
 type Post struct {
-	ID        uuid.UUID `json:"id"`
-	Slug      string    `json:"slug"`
-	Title     string    `json:"title"`
-	Excerpt   string    `json:"excerpt"`
-	Content   string    `json:"content"`
-	Tags      []string  `json:"tags"`
-	Author    *string   `json:"author,omitempty"`
-	Published bool      `json:"published"`
-	Date      string    `json:"date"` // YYYY-MM-DD
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID          uuid.UUID     `json:"id"`
+	Slug        string        `json:"slug"`
+	Title       string        `json:"title"`
+	Excerpt     string        `json:"excerpt"`
+	Content     string        `json:"content"`
+	ContentHTML string        `json:"content_html"` // sanitized render of Content
+	TOC         []TOCEntry    `json:"toc"`
+	WordCount   int           `json:"word_count"`
+	ReadingTime int           `json:"reading_time"` // minutes
+	Tags        []string      `json:"tags"`
+	Author      *string       `json:"author,omitempty"` // byline, kept in step with Authors
+	Authors     []AuthorRef   `json:"authors,omitempty"`
+	Published   bool          `json:"published"`
+	Date        string        `json:"date"`                   // YYYY-MM-DD
+	PublishAt   *time.Time    `json:"publish_at,omitempty"`   // scheduled go-live time
+	PublishedAt *time.Time    `json:"published_at,omitempty"` // when it actually went live
+	Issue       *PostIssue    `json:"issue,omitempty"`        // single-post responses only
+	Related     []RelatedPost `json:"related,omitempty"`      // single-post responses only
+	CreatedAt   time.Time     `json:"created_at"`
+	UpdatedAt   time.Time     `json:"updated_at"`
+}
+
+// TOCEntry is a heading in a rendered post; ID is its anchor.
+type TOCEntry struct {
+	Level int    `json:"level"`
+	ID    string `json:"id"`
+	Text  string `json:"text"`
 }
 
 type CreatePostRequest struct {
-	Slug      string   `json:"slug"`
-	Title     string   `json:"title"`
-	Excerpt   string   `json:"excerpt"`
-	Content   string   `json:"content"`
-	Tags      []string `json:"tags"`
-	Author    *string  `json:"author,omitempty"`
-	Published bool     `json:"published"`
-	Date      string   `json:"date"`
+	Slug      string      `json:"slug"`
+	Title     string      `json:"title"`
+	Excerpt   string      `json:"excerpt"`
+	Content   string      `json:"content"`
+	Tags      []string    `json:"tags"`
+	Author    *string     `json:"author,omitempty"`     // byline; ignored when AuthorIDs is set
+	AuthorIDs []uuid.UUID `json:"author_ids,omitempty"` // authors in order
+	Published bool        `json:"published"`
+	Date      string      `json:"date"`
+	PublishAt *time.Time  `json:"publish_at,omitempty"` // future time holds a published post back
 }
 
 type UpdatePostRequest = CreatePostRequest
 
+// PostIssue places a post in its issue: its position among the issue's
+// public posts and its neighbours there.
+type PostIssue struct {
+	Number   int       `json:"number"`
+	Title    string    `json:"title"`
+	Position int       `json:"position"` // 1-based
+	Previous *PostLink `json:"previous"`
+	Next     *PostLink `json:"next"`
+}
+
+// PostLink is enough of a post to link to it.
+type PostLink struct {
+	Slug  string `json:"slug"`
+	Title string `json:"title"`
+}
+
+// RelatedPost is a public post suggested after another. Score is the number
+// of shared tags plus a full-text similarity below 1, so tags rank first.
+type RelatedPost struct {
+	Slug        string   `json:"slug"`
+	Title       string   `json:"title"`
+	Excerpt     string   `json:"excerpt"`
+	Date        string   `json:"date"`
+	Tags        []string `json:"tags"`
+	ReadingTime int      `json:"reading_time"`
+	Score       float64  `json:"score"`
+}
+
+// SlugRedirect answers a request for a post or project by a slug it was
+// renamed from; Slug is the current one and Location its URL.
+type SlugRedirect struct {
+	Slug     string `json:"slug"`
+	Location string `json:"location"`
+}
+
+// TagCount is a tag and how many public posts carry it.
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int64  `json:"count"`
+}
+
+type RenameTagRequest struct {
+	Name string `json:"name"`
+}
+
+// MergeTagsRequest replaces every tag in From with To across all posts.
+type MergeTagsRequest struct {
+	From []string `json:"from"`
+	To   string   `json:"to"`
+}
+
+type MergeTagsResponse struct {
+	PostsUpdated int `json:"posts_updated"`
+}
+
+// PostSearchResult is a post matching a search, without its content.
+// Snippet is HTML: the matched text escaped, with hits wrapped in <mark>.
+type PostSearchResult struct {
+	ID      uuid.UUID `json:"id"`
+	Slug    string    `json:"slug"`
+	Title   string    `json:"title"`
+	Excerpt string    `json:"excerpt"`
+	Tags    []string  `json:"tags"`
+	Author  *string   `json:"author,omitempty"`
+	Date    string    `json:"date"`
+	Rank    float32   `json:"rank"`
+	Snippet string    `json:"snippet"`
+}
+
+// PostRevision is a snapshot of a post taken when it was saved.
+type PostRevision struct {
+	PostID    uuid.UUID   `json:"post_id"`
+	Number    int         `json:"number"`
+	Slug      string      `json:"slug"`
+	Title     string      `json:"title"`
+	Excerpt   string      `json:"excerpt"`
+	Content   string      `json:"content,omitempty"` // omitted from listings
+	Tags      []string    `json:"tags"`
+	Author    *string     `json:"author,omitempty"`
+	AuthorIDs []uuid.UUID `json:"author_ids,omitempty"` // nil for revisions that predate them
+	Published bool        `json:"published"`
+	Date      string      `json:"date"`
+	PublishAt *time.Time  `json:"publish_at,omitempty"`
+	EditorID  *uuid.UUID  `json:"editor_id,omitempty"`
+	Editor    *string     `json:"editor,omitempty"` // editor's username
+	CreatedAt time.Time   `json:"created_at"`
+}
+
+// PostRevisionDiff is a unified diff between two revisions of a post.
+type PostRevisionDiff struct {
+	From int    `json:"from"`
+	To   int    `json:"to"`
+	Diff string `json:"diff"` // empty when the revisions match
+}
+
+// PostPreview is a revocable, expiring link that shows one post to people
+// outside the admin team before it is public.
+type PostPreview struct {
+	ID         uuid.UUID  `json:"id"`
+	PostID     uuid.UUID  `json:"post_id"`
+	Label      string     `json:"label"`
+	CreatedBy  *string    `json:"created_by,omitempty"` // username
+	ExpiresAt  time.Time  `json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+type CreatePostPreviewRequest struct {
+	Label          string `json:"label"`
+	ExpiresInHours int    `json:"expires_in_hours,omitempty"`
+}
+
+// CreatePostPreviewResponse carries the signed token and the shareable
+// link, which are only ever shown once.
+type CreatePostPreviewResponse struct {
+	PostPreview
+	Token string `json:"token"`
+	URL   string `json:"url"`
+}
+
+// ── Author ───────────────────────────────────────────────────
+
+// Author is a writer's public profile. PostCount counts public posts;
+// UserID is returned to admins only.
+type Author struct {
+	ID        uuid.UUID    `json:"id"`
+	Slug      string       `json:"slug"`
+	Name      string       `json:"name"`
+	Bio       string       `json:"bio"`
+	AvatarURL *string      `json:"avatar_url,omitempty"`
+	Links     []AuthorLink `json:"links"`
+	UserID    *uuid.UUID   `json:"user_id,omitempty"`
+	PostCount int          `json:"post_count"`
+	CreatedAt time.Time    `json:"created_at"`
+	UpdatedAt time.Time    `json:"updated_at"`
+}
+
+// AuthorLink is a link on an author's profile.
+type AuthorLink struct {
+	Label string `json:"label"`
+	URL   string `json:"url"`
+}
+
+// AuthorRef is an author as listed on a post.
+type AuthorRef struct {
+	ID        uuid.UUID `json:"id"`
+	Slug      string    `json:"slug"`
+	Name      string    `json:"name"`
+	AvatarURL *string   `json:"avatar_url,omitempty"`
+}
+
+// AuthorDetail is an author with their public posts, newest first.
+type AuthorDetail struct {
+	Author
+	Posts []Post `json:"posts"`
+}
+
+// CreateAuthorRequest sets an author's profile. An empty slug is derived
+// from the name.
+type CreateAuthorRequest struct {
+	Slug      string       `json:"slug"`
+	Name      string       `json:"name"`
+	Bio       string       `json:"bio"`
+	AvatarURL *string      `json:"avatar_url,omitempty"`
+	Links     []AuthorLink `json:"links"`
+	UserID    *uuid.UUID   `json:"user_id,omitempty"`
+}
+
+type UpdateAuthorRequest = CreateAuthorRequest
+
+// ── Issue ────────────────────────────────────────────────────
+
+// Issue is a numbered zine issue. PostCount counts public posts; PostIDs,
+// in reading order, are returned to admins only.
+type Issue struct {
+	ID          uuid.UUID   `json:"id"`
+	Number      int         `json:"number"`
+	Title       string      `json:"title"`
+	Description string      `json:"description"`
+	CoverURL    *string     `json:"cover_url,omitempty"`
+	PostCount   int         `json:"post_count"`
+	PostIDs     []uuid.UUID `json:"post_ids,omitempty"`
+	CreatedAt   time.Time   `json:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at"`
+}
+
+// IssueDetail is an issue with its public posts in reading order.
+type IssueDetail struct {
+	Issue
+	Posts []Post `json:"posts"`
+}
+
+// CreateIssueRequest sets an issue and, in order, all of its posts.
+type CreateIssueRequest struct {
+	Number      int         `json:"number"`
+	Title       string      `json:"title"`
+	Description string      `json:"description"`
+	CoverURL    *string     `json:"cover_url,omitempty"`
+	PostIDs     []uuid.UUID `json:"post_ids"`
+}
+
+type UpdateIssueRequest = CreateIssueRequest
+
+// ── Comment ──────────────────────────────────────────────────
+
+// Comment statuses. New and edited comments are pending until a moderator
+// approves them.
+const (
+	CommentPending  = "pending"
+	CommentApproved = "approved"
+	CommentRejected = "rejected"
+	CommentSpam     = "spam"
+)
+
+// Comment is a reader comment. Public listings nest replies and leave the
+// moderation fields empty; a deleted comment keeps its place in a thread
+// without its author or body.
+type Comment struct {
+	ID          uuid.UUID  `json:"id"`
+	PostID      uuid.UUID  `json:"post_id"`
+	ParentID    *uuid.UUID `json:"parent_id,omitempty"`
+	AuthorName  string     `json:"author_name"`
+	Body        string     `json:"body"`
+	Deleted     bool       `json:"deleted,omitempty"`
+	Hidden      bool       `json:"hidden,omitempty"` // not approved; listed only to hold its approved replies
+	EditedAt    *time.Time `json:"edited_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	Replies     []Comment  `json:"replies,omitempty"`
+	AuthorEmail *string    `json:"author_email,omitempty"` // moderators only
+	Status      string     `json:"status,omitempty"`       // moderators only
+	PostSlug    string     `json:"post_slug,omitempty"`    // moderators only
+	PostTitle   string     `json:"post_title,omitempty"`   // moderators only
+	ModeratedAt *time.Time `json:"moderated_at,omitempty"` // moderators only
+}
+
+type CreateCommentRequest struct {
+	AuthorName  string     `json:"author_name"`
+	AuthorEmail *string    `json:"author_email,omitempty"`
+	Body        string     `json:"body"`
+	ParentID    *uuid.UUID `json:"parent_id,omitempty"`
+}
+
+// CreateCommentResponse carries the token that edits or deletes the
+// comment; it is shown only once.
+type CreateCommentResponse struct {
+	Comment
+	Token string `json:"token"`
+}
+
+type UpdateCommentRequest struct {
+	Body string `json:"body"`
+}
+
+type ModerateCommentRequest struct {
+	Status string `json:"status"` // approved, rejected, or spam
+}
+
 // ── Contact ──────────────────────────────────────────────────
 
 type Contact struct {
@@ -156,6 +575,10 @@ type PaginatedResponse[T any] struct {
 	TotalPages int   `json:"total_pages"`
 }
 
+type SecuritySettings struct {
+	RequireAdmin2FA bool `json:"require_admin_2fa"`
+}
+
 type DashboardStats struct {
 	TotalProjects    int64 `json:"total_projects"`
 	TotalPosts       int64 `json:"total_posts"`
@@ -0,0 +1,199 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// mockIdP is a minimal OpenID provider: it serves discovery and JWKS and
+// redeems codes issued by authorize, checking the PKCE verifier.
+type mockIdP struct {
+	t      *testing.T
+	srv    *httptest.Server
+	key    *rsa.PrivateKey
+	claims jwt.MapClaims // extra claims for issued ID tokens
+	codes  map[string]url.Values
+}
+
+func newMockIdP(t *testing.T) *mockIdP {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &mockIdP{t: t, key: key, codes: map[string]url.Values{}, claims: jwt.MapClaims{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 m.srv.URL,
+			"authorization_endpoint": m.srv.URL + "/authorize",
+			"token_endpoint":         m.srv.URL + "/token",
+			"jwks_uri":               m.srv.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA", "kid": "idp-1", "use": "sig", "alg": "RS256",
+			"n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if id, secret, _ := r.BasicAuth(); id != "client" || secret != "secret" {
+			http.Error(w, `{"error":"invalid_client"}`, http.StatusUnauthorized)
+			return
+		}
+		r.ParseForm()
+		auth, ok := m.codes[r.Form.Get("code")]
+		delete(m.codes, r.Form.Get("code"))
+		if !ok || Challenge(r.Form.Get("code_verifier")) != auth.Get("code_challenge") ||
+			r.Form.Get("redirect_uri") != auth.Get("redirect_uri") {
+			http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"id_token": m.idToken(auth.Get("nonce"))})
+	})
+	m.srv = httptest.NewServer(mux)
+	t.Cleanup(m.srv.Close)
+	return m
+}
+
+// authorize simulates the user approving the login at authURL and returns
+// the code the provider would redirect back with.
+func (m *mockIdP) authorize(authURL string) string {
+	u, err := url.Parse(authURL)
+	if err != nil {
+		m.t.Fatal(err)
+	}
+	q := u.Query()
+	if q.Get("code_challenge_method") != "S256" {
+		m.t.Errorf("expected S256 challenge, got %q", q.Get("code_challenge_method"))
+	}
+	code := "code-" + q.Get("state")
+	m.codes[code] = q
+	return code
+}
+
+func (m *mockIdP) idToken(nonce string) string {
+	claims := jwt.MapClaims{
+		"iss":            m.srv.URL,
+		"aud":            "client",
+		"sub":            "user-123",
+		"email":          "ada@example.com",
+		"email_verified": true,
+		"groups":         []string{"staff", "editors"},
+		"nonce":          nonce,
+		"iat":            time.Now().Unix(),
+		"exp":            time.Now().Add(time.Minute).Unix(),
+	}
+	for k, v := range m.claims {
+		claims[k] = v
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "idp-1"
+	s, err := token.SignedString(m.key)
+	if err != nil {
+		m.t.Fatal(err)
+	}
+	return s
+}
+
+func (m *mockIdP) provider() *Provider {
+	return New(Config{
+		Issuer:       m.srv.URL,
+		ClientID:     "client",
+		ClientSecret: "secret",
+		RedirectURL:  "https://api.example.com/callback",
+	})
+}
+
+// TestLoginFlow tests a full authorization code + PKCE login.
+func TestLoginFlow(t *testing.T) {
+	idp := newMockIdP(t)
+	p := idp.provider()
+	ctx := context.Background()
+
+	verifier, err := NewVerifier()
+	if err != nil {
+		t.Fatal(err)
+	}
+	authURL, err := p.AuthURL(ctx, "state-1", "nonce-1", verifier)
+	if err != nil {
+		t.Fatalf("AuthURL: %v", err)
+	}
+	if !strings.HasPrefix(authURL, idp.srv.URL+"/authorize?") {
+		t.Errorf("unexpected auth URL %s", authURL)
+	}
+
+	claims, err := p.Exchange(ctx, idp.authorize(authURL), verifier, "nonce-1")
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if claims.Subject != "user-123" || claims.Email != "ada@example.com" || !claims.EmailVerified {
+		t.Errorf("unexpected claims %+v", claims)
+	}
+	if len(claims.Groups) != 2 || claims.Groups[1] != "editors" {
+		t.Errorf("unexpected groups %v", claims.Groups)
+	}
+}
+
+// TestExchangeRejects tests that tampered flows fail.
+func TestExchangeRejects(t *testing.T) {
+	tests := []struct {
+		name     string
+		verifier string
+		nonce    string
+		claims   jwt.MapClaims
+	}{
+		{"wrong verifier", "other-verifier", "nonce-1", nil},
+		{"nonce mismatch", "", "nonce-2", nil},
+		{"wrong audience", "", "nonce-1", jwt.MapClaims{"aud": "someone-else"}},
+		{"wrong issuer", "", "nonce-1", jwt.MapClaims{"iss": "https://evil.example.com"}},
+		{"expired", "", "nonce-1", jwt.MapClaims{"exp": time.Now().Add(-time.Hour).Unix()}},
+		{"multiple audiences without azp", "", "nonce-1", jwt.MapClaims{"aud": []string{"client", "other"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idp := newMockIdP(t)
+			idp.claims = tt.claims
+			p := idp.provider()
+			ctx := context.Background()
+
+			verifier, _ := NewVerifier()
+			authURL, err := p.AuthURL(ctx, "state", "nonce-1", verifier)
+			if err != nil {
+				t.Fatalf("AuthURL: %v", err)
+			}
+			code := idp.authorize(authURL)
+			if tt.verifier != "" {
+				verifier = tt.verifier
+			}
+			if _, err := p.Exchange(ctx, code, verifier, tt.nonce); err == nil {
+				t.Error("expected exchange to fail")
+			}
+		})
+	}
+}
+
+// TestDiscoveryIssuerMismatch tests that a provider claiming another issuer
+// is refused.
+func TestDiscoveryIssuerMismatch(t *testing.T) {
+	idp := newMockIdP(t)
+	p := New(Config{Issuer: idp.srv.URL + "/", ClientID: "client"})
+	if _, err := p.AuthURL(context.Background(), "s", "n", "v"); err == nil {
+		t.Error("expected issuer mismatch to fail discovery")
+	}
+}
@@ -0,0 +1,215 @@
+// Package render turns post sources (Markdown, or MDX without code) into
+// sanitized HTML with a table of contents and a word count, and keeps the
+// rendered columns of posts in step with their content.
+package render
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	goldhtml "github.com/yuin/goldmark/renderer/html"
+	"github.com/yuin/goldmark/text"
+	"golang.org/x/net/html"
+
+	"github.com/subculture-collective/subcult-tv/api/internal/models"
+)
+
+// Version identifies the rendering rules. Bump it when they change so
+// stored HTML is regenerated at the next start.
+const Version = 1
+
+// Component is the HTML an allowlisted MDX component renders as.
+type Component struct {
+	Element string
+	Class   string
+}
+
+// Components are the MDX components posts may use. Any other component's
+// tags are dropped and its children kept.
+var Components = map[string]Component{
+	"Callout":   {"aside", "mdx-callout"},
+	"Note":      {"aside", "mdx-note"},
+	"PullQuote": {"blockquote", "mdx-pullquote"},
+}
+
+// Result is a rendered post.
+type Result struct {
+	HTML      string
+	TOC       []models.TOCEntry
+	WordCount int
+}
+
+// WordsPerMinute is the reading speed behind ReadingTime.
+const WordsPerMinute = 230
+
+// ReadingTime estimates the minutes it takes to read words words, rounded
+// up; any text takes at least a minute.
+func ReadingTime(words int) int {
+	if words <= 0 {
+		return 0
+	}
+	return (words + WordsPerMinute - 1) / WordsPerMinute
+}
+
+var markdown = goldmark.New(
+	goldmark.WithExtensions(extension.GFM, extension.Footnote),
+	goldmark.WithParserOptions(parser.WithAutoHeadingID()),
+	// Raw HTML is let through here so MDX components survive to
+	// mapComponents; the sanitizer has the final word.
+	goldmark.WithRendererOptions(goldhtml.WithUnsafe()),
+)
+
+var policy = func() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[\w+-]+$`)).OnElements("code")
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^mdx-[a-z-]+$`)).OnElements("aside", "blockquote")
+	p.AllowElements("aside")
+	p.AddTargetBlankToFullyQualifiedLinks(true)
+	return p
+}()
+
+// Markdown renders a post source.
+func Markdown(source string) Result {
+	src := []byte(stripMDX(source))
+	doc := markdown.Parser().Parse(text.NewReader(src))
+
+	toc := []models.TOCEntry{}
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		h, ok := n.(*ast.Heading)
+		if !ok || !entering {
+			return ast.WalkContinue, nil
+		}
+		id, _ := h.AttributeString("id")
+		idBytes, _ := id.([]byte)
+		toc = append(toc, models.TOCEntry{
+			Level: h.Level,
+			ID:    string(idBytes),
+			Text:  plainText(h, src),
+		})
+		return ast.WalkSkipChildren, nil
+	})
+
+	var buf bytes.Buffer
+	// Rendering into a bytes.Buffer cannot fail.
+	_ = markdown.Renderer().Render(&buf, src, doc)
+	out := policy.Sanitize(mapComponents(buf.String()))
+
+	return Result{HTML: out, TOC: toc, WordCount: countWords(out)}
+}
+
+// stripMDX removes MDX syntax that has no HTML meaning outside fenced code:
+// import/export statements and {/* comments */}.
+func stripMDX(source string) string {
+	lines := strings.Split(source, "\n")
+	out := lines[:0]
+	fence := ""
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) {
+				fence = ""
+			}
+			out = append(out, line)
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:3]
+			out = append(out, line)
+			continue
+		}
+		if strings.HasPrefix(line, "import ") || strings.HasPrefix(line, "export ") {
+			continue
+		}
+		out = append(out, jsxComment.ReplaceAllString(line, ""))
+	}
+	return strings.Join(out, "\n")
+}
+
+var jsxComment = regexp.MustCompile(`\{/\*.*?\*/\}`)
+
+// plainText concatenates the text under n.
+func plainText(n ast.Node, src []byte) string {
+	var sb strings.Builder
+	_ = ast.Walk(n, func(c ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch t := c.(type) {
+		case *ast.Text:
+			sb.Write(t.Segment.Value(src))
+			if t.SoftLineBreak() {
+				sb.WriteByte(' ')
+			}
+		case *ast.String:
+			sb.Write(t.Value)
+		}
+		return ast.WalkContinue, nil
+	})
+	return strings.TrimSpace(sb.String())
+}
+
+// mapComponents rewrites allowlisted MDX components to plain HTML and drops
+// the tags of any other component. Components are told apart from HTML
+// elements by their capitalised names.
+func mapComponents(in string) string {
+	z := html.NewTokenizer(strings.NewReader(in))
+	var sb strings.Builder
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			return sb.String()
+		}
+		raw := z.Raw()
+		name, isComponent := componentName(raw)
+		if !isComponent || (tt != html.StartTagToken && tt != html.EndTagToken && tt != html.SelfClosingTagToken) {
+			sb.Write(raw)
+			continue
+		}
+		c, ok := Components[name]
+		if !ok {
+			continue
+		}
+		switch tt {
+		case html.StartTagToken:
+			sb.WriteString(`<` + c.Element + ` class="` + c.Class + `">`)
+		case html.EndTagToken:
+			sb.WriteString(`</` + c.Element + `>`)
+		case html.SelfClosingTagToken:
+			sb.WriteString(`<` + c.Element + ` class="` + c.Class + `"></` + c.Element + `>`)
+		}
+	}
+}
+
+// componentName reads the tag name from a raw tag and reports whether it
+// names an MDX component.
+func componentName(raw []byte) (string, bool) {
+	s := strings.TrimPrefix(strings.TrimPrefix(string(raw), "<"), "/")
+	end := strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.')
+	})
+	if end >= 0 {
+		s = s[:end]
+	}
+	return s, s != "" && s[0] >= 'A' && s[0] <= 'Z'
+}
+
+// countWords counts whitespace-separated words in the text of an HTML
+// fragment.
+func countWords(fragment string) int {
+	z := html.NewTokenizer(strings.NewReader(fragment))
+	n := 0
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return n
+		case html.TextToken:
+			n += len(strings.Fields(string(z.Text())))
+		}
+	}
+}
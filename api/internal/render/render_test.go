@@ -0,0 +1,77 @@
+package render
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMarkdown(t *testing.T) {
+	src := "# Boot Sequence\n\nThere are already *too many* places to post.\n\n## Why `now`\n\n> Design is governance.\n"
+	res := Markdown(src)
+
+	for _, want := range []string{
+		`<h1 id="boot-sequence">Boot Sequence</h1>`,
+		`<em>too many</em>`,
+		`<h2 id="why-now">Why <code>now</code></h2>`,
+		`<blockquote>`,
+	} {
+		if !strings.Contains(res.HTML, want) {
+			t.Errorf("HTML missing %q:\n%s", want, res.HTML)
+		}
+	}
+
+	if len(res.TOC) != 2 {
+		t.Fatalf("TOC = %+v, want 2 entries", res.TOC)
+	}
+	if e := res.TOC[1]; e.Level != 2 || e.ID != "why-now" || e.Text != "Why now" {
+		t.Errorf("TOC[1] = %+v", e)
+	}
+	if res.WordCount != 15 {
+		t.Errorf("WordCount = %d, want 15", res.WordCount)
+	}
+}
+
+func TestMarkdownSanitizes(t *testing.T) {
+	src := "hello <script>alert(1)</script>\n\n<img src=x onerror=alert(1)>\n\n[click](javascript:alert(1))\n\n<iframe src=\"https://evil\"></iframe>"
+	res := Markdown(src)
+	for _, bad := range []string{"<script", "alert(1)</", "onerror", "javascript:", "<iframe"} {
+		if strings.Contains(res.HTML, bad) {
+			t.Errorf("HTML contains %q:\n%s", bad, res.HTML)
+		}
+	}
+}
+
+func TestMarkdownComponents(t *testing.T) {
+	src := "import Chart from './chart'\n\n{/* draft note */}\n\n<Callout>\n\nStay **calm**.\n\n</Callout>\n\n<Chart data={points} />\n\n<Widget>kept text</Widget>\n"
+	res := Markdown(src)
+
+	if strings.Contains(res.HTML, "import") || strings.Contains(res.HTML, "draft note") {
+		t.Errorf("MDX syntax leaked:\n%s", res.HTML)
+	}
+	if !strings.Contains(res.HTML, `<aside class="mdx-callout">`) || !strings.Contains(res.HTML, "<strong>calm</strong>") {
+		t.Errorf("Callout not rendered:\n%s", res.HTML)
+	}
+	if strings.Contains(res.HTML, "Chart") || strings.Contains(res.HTML, "Widget") {
+		t.Errorf("unknown component kept:\n%s", res.HTML)
+	}
+	if !strings.Contains(res.HTML, "kept text") {
+		t.Errorf("unknown component's children dropped:\n%s", res.HTML)
+	}
+}
+
+func TestStripMDXKeepsCode(t *testing.T) {
+	src := "```js\nimport x from 'y'\n{/* kept */}\n```\nimport gone from 'z'\n"
+	got := stripMDX(src)
+	want := "```js\nimport x from 'y'\n{/* kept */}\n```\n"
+	if got != want {
+		t.Errorf("stripMDX = %q, want %q", got, want)
+	}
+}
+
+func TestReadingTime(t *testing.T) {
+	for words, want := range map[int]int{0: 0, 1: 1, WordsPerMinute: 1, WordsPerMinute + 1: 2, 10 * WordsPerMinute: 10} {
+		if got := ReadingTime(words); got != want {
+			t.Errorf("ReadingTime(%d) = %d, want %d", words, got, want)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package render
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// execer is satisfied by *pgxpool.Pool and pgx.Tx.
+type execer interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+}
+
+// Store renders source and saves the result on the post.
+func Store(ctx context.Context, db execer, postID, source string) (Result, error) {
+	res := Markdown(source)
+	_, err := db.Exec(ctx,
+		`UPDATE posts SET content_html = $2, toc = $3, word_count = $4, render_version = $5
+		 WHERE id = $1`,
+		postID, res.HTML, res.TOC, res.WordCount, Version,
+	)
+	if err != nil {
+		return res, fmt.Errorf("store rendered post: %w", err)
+	}
+	return res, nil
+}
+
+// RefreshStale re-renders every post rendered by an older Version, and
+// returns how many it updated.
+func RefreshStale(ctx context.Context, db *pgxpool.Pool) (int, error) {
+	rows, err := db.Query(ctx,
+		`SELECT id::text, content FROM posts WHERE render_version < $1`, Version)
+	if err != nil {
+		return 0, fmt.Errorf("query stale posts: %w", err)
+	}
+	type stale struct{ id, content string }
+	posts, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (stale, error) {
+		var s stale
+		err := row.Scan(&s.id, &s.content)
+		return s, err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("scan stale posts: %w", err)
+	}
+
+	for i, p := range posts {
+		if _, err := Store(ctx, db, p.id, p.content); err != nil {
+			return i, err
+		}
+	}
+	return len(posts), nil
+}
@@ -0,0 +1,112 @@
+// Package revisions records post history. Every save of a post stores a
+// numbered snapshot in post_revisions so an edit can be inspected, diffed,
+// and rolled back.
+package revisions
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/subculture-collective/subcult-tv/api/internal/models"
+)
+
+// execer is satisfied by *pgxpool.Pool and pgx.Tx.
+type execer interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+}
+
+// Record snapshots the current state of a post, with the ids of its
+// authors, as its next revision, attributed to editorID (empty for changes made outside the API). A save
+// that changes nothing does not add a revision. When keep is positive, only
+// the newest keep revisions of the post survive.
+//
+// Call it in the same transaction as the write so the row lock on the post
+// serialises revision numbers.
+func Record(ctx context.Context, db execer, postID, editorID string, keep int) error {
+	var editor any
+	if editorID != "" {
+		editor = editorID
+	}
+
+	_, err := db.Exec(ctx,
+		`INSERT INTO post_revisions (post_id, number, slug, title, excerpt, content, tags,
+		  author, author_ids, published, date, publish_at, editor_id)
+		 SELECT p.id, COALESCE(prev.number, 0) + 1, p.slug, p.title, p.excerpt, p.content, p.tags,
+		  p.author, ARRAY(
+		    SELECT pa.author_id FROM post_authors pa WHERE pa.post_id = p.id ORDER BY pa.position
+		  ), p.published, p.date, p.publish_at, $2::uuid
+		 FROM posts p
+		 LEFT JOIN LATERAL (
+		   SELECT * FROM post_revisions r WHERE r.post_id = p.id ORDER BY r.number DESC LIMIT 1
+		 ) prev ON true
+		 WHERE p.id = $1
+		   AND (prev.number IS NULL OR
+		     (prev.slug, prev.title, prev.excerpt, prev.content, prev.tags,
+		      prev.author, prev.published, prev.date, prev.publish_at)
+		     IS DISTINCT FROM
+		     (p.slug, p.title, p.excerpt, p.content, p.tags,
+		      p.author, p.published, p.date, p.publish_at))`,
+		postID, editor,
+	)
+	if err != nil {
+		return fmt.Errorf("record revision: %w", err)
+	}
+
+	if keep > 0 {
+		_, err = db.Exec(ctx,
+			`DELETE FROM post_revisions
+			 WHERE post_id = $1 AND number <= (
+			   SELECT MAX(number) FROM post_revisions WHERE post_id = $1
+			 ) - $2`,
+			postID, keep,
+		)
+		if err != nil {
+			return fmt.Errorf("prune revisions: %w", err)
+		}
+	}
+	return nil
+}
+
+// Columns is the column list Scan expects, for post_revisions aliased as r
+// and users as u.
+const Columns = `r.post_id, r.number, r.slug, r.title, r.excerpt, r.content, r.tags,
+	r.author, r.author_ids, r.published, r.date::text, r.publish_at, r.editor_id, u.username, r.created_at`
+
+// Scan reads a revision selected with Columns.
+func Scan(row pgx.Row) (models.PostRevision, error) {
+	var rev models.PostRevision
+	err := row.Scan(
+		&rev.PostID, &rev.Number, &rev.Slug, &rev.Title, &rev.Excerpt, &rev.Content, &rev.Tags,
+		&rev.Author, &rev.AuthorIDs, &rev.Published, &rev.Date, &rev.PublishAt, &rev.EditorID, &rev.Editor,
+		&rev.CreatedAt,
+	)
+	return rev, err
+}
+
+// Text renders a revision as plain text for diffing: a header of metadata
+// fields followed by a blank line and the content.
+func Text(rev models.PostRevision) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "slug: %s\n", rev.Slug)
+	fmt.Fprintf(&sb, "title: %s\n", rev.Title)
+	fmt.Fprintf(&sb, "excerpt: %s\n", rev.Excerpt)
+	fmt.Fprintf(&sb, "tags: %s\n", strings.Join(rev.Tags, ", "))
+	if rev.Author != nil {
+		fmt.Fprintf(&sb, "author: %s\n", *rev.Author)
+	}
+	fmt.Fprintf(&sb, "date: %s\n", rev.Date)
+	fmt.Fprintf(&sb, "published: %t\n", rev.Published)
+	if rev.PublishAt != nil {
+		fmt.Fprintf(&sb, "publish_at: %s\n", rev.PublishAt.UTC().Format("2006-01-02T15:04:05Z"))
+	}
+	sb.WriteString("\n")
+	sb.WriteString(rev.Content)
+	if rev.Content != "" && !strings.HasSuffix(rev.Content, "\n") {
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
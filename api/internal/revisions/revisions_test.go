@@ -0,0 +1,47 @@
+package revisions
+
+import (
+	"testing"
+	"time"
+
+	"github.com/subculture-collective/subcult-tv/api/internal/models"
+)
+
+func TestText(t *testing.T) {
+	author := "ada"
+	at := time.Date(2026, 3, 1, 9, 30, 0, 0, time.FixedZone("CET", 3600))
+	rev := models.PostRevision{
+		Slug:      "signal",
+		Title:     "Signal",
+		Excerpt:   "short",
+		Content:   "line one\nline two",
+		Tags:      []string{"noise", "radio"},
+		Author:    &author,
+		Published: true,
+		Date:      "2026-03-01",
+		PublishAt: &at,
+	}
+	want := `slug: signal
+title: Signal
+excerpt: short
+tags: noise, radio
+author: ada
+date: 2026-03-01
+published: true
+publish_at: 2026-03-01T08:30:00Z
+
+line one
+line two
+`
+	if got := Text(rev); got != want {
+		t.Errorf("Text:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestTextOmitsUnsetFields(t *testing.T) {
+	rev := models.PostRevision{Slug: "s", Title: "T", Date: "2026-01-01"}
+	want := "slug: s\ntitle: T\nexcerpt: \ntags: \ndate: 2026-01-01\npublished: false\n\n"
+	if got := Text(rev); got != want {
+		t.Errorf("Text = %q, want %q", got, want)
+	}
+}
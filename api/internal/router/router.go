@@ -14,12 +14,21 @@ import (
 	"github.com/subculture-collective/subcult-tv/api/internal/middleware"
 )
 
-// Rate limit thresholds (requests per window).
+// Rate limit thresholds (requests per window, per IP). Each sign-in step
+// has its own limiter so one flow cannot use up another's budget; the
+// per-account lockout, not these limits, is the defense against password
+// guessing.
 const (
-	GeneralRateLimit = 100
-	LoginRateLimit   = 5
-	FormRateLimit    = 10
-	RateLimitWindow  = time.Minute
+	GeneralRateLimit       = 100
+	LoginRateLimit         = 10 // password step
+	MFARateLimit           = 10 // second factor and enrollment at login
+	SSORateLimit           = 20 // three requests per single sign-on
+	RefreshRateLimit       = 30
+	PasswordResetRateLimit = 5
+	AccountRateLimit       = 10 // password and 2FA changes while signed in
+	FormRateLimit          = 10
+	SearchRateLimit        = 30
+	RateLimitWindow        = time.Minute
 )
 
 // New creates a configured Chi router with all API routes.
@@ -29,7 +38,13 @@ func New(cfg *config.Config, h *handlers.Handler) *chi.Mux {
 	// Rate limiters for different endpoint types
 	generalLimiter := middleware.NewRateLimiter(GeneralRateLimit, RateLimitWindow)
 	loginLimiter := middleware.NewRateLimiter(LoginRateLimit, RateLimitWindow)
+	mfaLimiter := middleware.NewRateLimiter(MFARateLimit, RateLimitWindow)
+	ssoLimiter := middleware.NewRateLimiter(SSORateLimit, RateLimitWindow)
+	refreshLimiter := middleware.NewRateLimiter(RefreshRateLimit, RateLimitWindow)
+	passwordResetLimiter := middleware.NewRateLimiter(PasswordResetRateLimit, RateLimitWindow)
+	accountLimiter := middleware.NewRateLimiter(AccountRateLimit, RateLimitWindow)
 	publicFormLimiter := middleware.NewRateLimiter(FormRateLimit, RateLimitWindow)
+	searchLimiter := middleware.NewRateLimiter(SearchRateLimit, RateLimitWindow)
 
 	// ── Global middleware ────────────────────────────────────
 	r.Use(chimw.RealIP)
@@ -44,7 +59,7 @@ func New(cfg *config.Config, h *handlers.Handler) *chi.Mux {
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   cfg.CORSOrigins,
 		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", middleware.CSRFHeader, handlers.PreviewTokenHeader, handlers.CommentTokenHeader},
 		ExposedHeaders:   []string{"Link"},
 		AllowCredentials: true,
 		MaxAge:           300,
@@ -59,19 +74,51 @@ func New(cfg *config.Config, h *handlers.Handler) *chi.Mux {
 		w.Write([]byte(`{"status":"ok","service":"subcult-api"}`))
 	})
 
+	// ── Discovery ────────────────────────────────────────────
+	r.Get("/.well-known/jwks.json", h.JWKS)
+
+	// ── Feeds ────────────────────────────────────────────────
+	r.Get("/feed.xml", h.RSSFeed)
+	r.Get("/atom.xml", h.AtomFeed)
+	r.Get("/feed.json", h.JSONFeed)
+	r.Get("/tags/{tag}/feed.xml", h.RSSFeed)
+	r.Get("/tags/{tag}/atom.xml", h.AtomFeed)
+	r.Get("/tags/{tag}/feed.json", h.JSONFeed)
+
 	// ── API v1 ───────────────────────────────────────────────
 	r.Route("/api/v1", func(api chi.Router) {
 
 		// Public routes with stricter rate limiting for sensitive endpoints
 		api.With(middleware.RateLimit(loginLimiter)).Post("/auth/login", h.Login)
+		api.With(middleware.RateLimit(mfaLimiter)).Post("/auth/login/2fa", h.LoginMFA)
+		api.With(middleware.RateLimit(mfaLimiter)).Post("/auth/login/2fa/setup", h.LoginMFASetup)
+		api.Get("/auth/oidc", h.OIDCStatus)
+		api.With(middleware.RateLimit(ssoLimiter)).Get("/auth/oidc/login", h.OIDCLogin)
+		api.With(middleware.RateLimit(ssoLimiter)).Get("/auth/oidc/callback", h.OIDCCallback)
+		api.With(middleware.RateLimit(ssoLimiter)).Post("/auth/oidc/exchange", h.OIDCExchange)
+		api.With(middleware.RateLimit(refreshLimiter)).Post("/auth/refresh", h.Refresh)
+		api.Post("/auth/logout", h.Logout)
+		api.With(middleware.RateLimit(passwordResetLimiter)).Post("/auth/password/forgot", h.ForgotPassword)
+		api.With(middleware.RateLimit(passwordResetLimiter)).Post("/auth/password/reset", h.ResetPassword)
 
 		api.Get("/projects", h.ListProjects)
 		api.Get("/projects/{slug}", h.GetProject)
 
 		api.Get("/posts", h.ListPosts)
+		api.With(middleware.RateLimit(searchLimiter)).Get("/posts/search", h.SearchPosts)
 		api.Get("/posts/{slug}", h.GetPost)
+		api.Get("/preview", h.GetPostPreview)
+		api.Get("/tags", h.ListTags)
+		api.Get("/issues", h.ListIssues)
+		api.Get("/issues/{number}", h.GetIssue)
+		api.Get("/authors", h.ListAuthors)
+		api.Get("/authors/{slug}", h.GetAuthor)
+		api.Get("/posts/{slug}/comments", h.ListComments)
 
 		api.With(middleware.RateLimit(publicFormLimiter)).Post("/contacts", h.SubmitContact)
+		api.With(middleware.RateLimit(publicFormLimiter)).Post("/posts/{slug}/comments", h.SubmitComment)
+		api.With(middleware.RateLimit(publicFormLimiter)).Put("/comments/{id}", h.UpdateComment)
+		api.With(middleware.RateLimit(publicFormLimiter)).Delete("/comments/{id}", h.DeleteComment)
 
 		api.Get("/patreon/campaign", h.GetPatreonCampaign)
 
@@ -80,30 +127,111 @@ func New(cfg *config.Config, h *handlers.Handler) *chi.Mux {
 
 		// ── Protected (admin) routes ────────────────────────
 		api.Group(func(admin chi.Router) {
-			admin.Use(middleware.Auth(cfg.JWTSecret))
+			admin.Use(middleware.Auth(h.Keys, h))
 
 			admin.Get("/auth/me", h.Me)
 
+			// Account self-service; not reachable with a personal access token
+			admin.Group(func(account chi.Router) {
+				account.Use(middleware.RequireSession)
+				account.With(middleware.RateLimit(accountLimiter)).Post("/auth/password", h.ChangePassword)
+
+				// Two-factor authentication
+				account.Post("/auth/2fa/setup", h.SetupMFA)
+				account.With(middleware.RateLimit(accountLimiter)).Post("/auth/2fa/enable", h.EnableMFA)
+				account.With(middleware.RateLimit(accountLimiter)).Post("/auth/2fa/disable", h.DisableMFA)
+				account.With(middleware.RateLimit(accountLimiter)).Post("/auth/2fa/recovery-codes", h.RegenerateRecoveryCodes)
+
+				// Personal access tokens
+				account.Get("/auth/tokens", h.ListMyAPITokens)
+				account.Post("/auth/tokens", h.CreateAPIToken)
+				account.Delete("/auth/tokens/{id}", h.RevokeMyAPIToken)
+			})
+
+			// Permission guards, one per capability (see middleware.rolePermissions)
+			canReadStats := middleware.RequirePermission(middleware.PermStatsRead)
+			canWritePosts := middleware.RequirePermission(middleware.PermPostsWrite)
+			canWriteProjects := middleware.RequirePermission(middleware.PermProjectsWrite)
+			canReadContacts := middleware.RequirePermission(middleware.PermContactsRead)
+			canWriteContacts := middleware.RequirePermission(middleware.PermContactsWrite)
+			canWriteComments := middleware.RequirePermission(middleware.PermCommentsWrite)
+			canReadSubscribers := middleware.RequirePermission(middleware.PermSubscribersRead)
+			canManageUsers := middleware.RequirePermission(middleware.PermUsersManage)
+
 			// Admin dashboard
-			admin.Get("/admin/stats", h.DashboardStats)
+			admin.With(canReadStats).Get("/admin/stats", h.DashboardStats)
+
+			// User management
+			admin.With(canManageUsers).Get("/admin/users", h.ListUsers)
+			admin.With(canManageUsers).Post("/admin/users", h.CreateUser)
+			admin.With(canManageUsers).Get("/admin/users/{id}", h.GetUser)
+			admin.With(canManageUsers).Put("/admin/users/{id}", h.UpdateUser)
+			admin.With(canManageUsers).Delete("/admin/users/{id}", h.DeleteUser)
+			admin.With(canManageUsers).Post("/admin/users/{id}/password", h.ResetUserPassword)
+			admin.With(canManageUsers).Delete("/admin/users/{id}/2fa", h.ResetUserMFA)
+			admin.With(canManageUsers).Post("/admin/users/{id}/unlock", h.UnlockUser)
+
+			// Security settings
+			admin.With(canManageUsers).Get("/admin/settings/security", h.GetSecuritySettings)
+			admin.With(canManageUsers).Put("/admin/settings/security", h.UpdateSecuritySettings)
+
+			// API token management
+			admin.With(canManageUsers).Get("/admin/tokens", h.ListAPITokens)
+			admin.With(canManageUsers).Delete("/admin/tokens/{id}", h.RevokeAPIToken)
+
+			// Session management
+			admin.With(canManageUsers).Get("/admin/users/{id}/sessions", h.ListUserSessions)
+			admin.With(canManageUsers).Delete("/admin/users/{id}/sessions", h.RevokeUserSessions)
+			admin.With(canManageUsers).Delete("/admin/sessions/{id}", h.RevokeSession)
 
 			// Projects CRUD
-			admin.Post("/projects", h.CreateProject)
-			admin.Put("/projects/{id}", h.UpdateProject)
-			admin.Delete("/projects/{id}", h.DeleteProject)
+			admin.With(canWriteProjects).Post("/projects", h.CreateProject)
+			admin.With(canWriteProjects).Put("/projects/{id}", h.UpdateProject)
+			admin.With(canWriteProjects).Delete("/projects/{id}", h.DeleteProject)
 
 			// Posts CRUD
-			admin.Post("/posts", h.CreatePost)
-			admin.Put("/posts/{id}", h.UpdatePost)
-			admin.Delete("/posts/{id}", h.DeletePost)
+			admin.With(canWritePosts).Post("/posts", h.CreatePost)
+			admin.With(canWritePosts).Put("/posts/{id}", h.UpdatePost)
+			admin.With(canWritePosts).Delete("/posts/{id}", h.DeletePost)
+			admin.With(canWritePosts).Get("/admin/posts", h.ListAllPosts)
+			admin.With(canWritePosts).Get("/admin/posts/scheduled", h.ListScheduledPosts)
+			admin.With(canWritePosts).Get("/admin/posts/{id}/revisions", h.ListPostRevisions)
+			admin.With(canWritePosts).Get("/admin/posts/{id}/revisions/diff", h.DiffPostRevisions)
+			admin.With(canWritePosts).Get("/admin/posts/{id}/revisions/{number}", h.GetPostRevision)
+			admin.With(canWritePosts).Post("/admin/posts/{id}/revisions/{number}/restore", h.RestorePostRevision)
+			admin.With(canWritePosts).Get("/admin/posts/{id}/previews", h.ListPostPreviews)
+			admin.With(canWritePosts).Post("/admin/posts/{id}/previews", h.CreatePostPreview)
+			admin.With(canWritePosts).Delete("/admin/posts/{id}/previews/{previewID}", h.RevokePostPreview)
+			admin.With(canWritePosts).Put("/admin/tags/{tag}", h.RenameTag)
+			admin.With(canWritePosts).Post("/admin/tags/merge", h.MergeTags)
+
+			// Issues CRUD
+			admin.With(canWritePosts).Get("/admin/issues", h.ListAllIssues)
+			admin.With(canWritePosts).Post("/issues", h.CreateIssue)
+			admin.With(canWritePosts).Put("/issues/{id}", h.UpdateIssue)
+			admin.With(canWritePosts).Delete("/issues/{id}", h.DeleteIssue)
+
+			// Authors CRUD
+			admin.With(canWritePosts).Get("/admin/authors", h.ListAllAuthors)
+			admin.With(canWritePosts).Post("/authors", h.CreateAuthor)
+			admin.With(canWritePosts).Put("/authors/{id}", h.UpdateAuthor)
+			admin.With(canWritePosts).Delete("/authors/{id}", h.DeleteAuthor)
+
+			// Content export (everything, drafts included)
+			admin.With(canWritePosts, canWriteProjects).Get("/admin/content/export", h.ExportContent)
 
 			// Contacts management
-			admin.Get("/contacts", h.ListContacts)
-			admin.Patch("/contacts/{id}/read", h.MarkContactRead)
-			admin.Delete("/contacts/{id}", h.DeleteContact)
+			admin.With(canReadContacts).Get("/contacts", h.ListContacts)
+			admin.With(canWriteContacts).Patch("/contacts/{id}/read", h.MarkContactRead)
+			admin.With(canWriteContacts).Delete("/contacts/{id}", h.DeleteContact)
+
+			// Comment moderation
+			admin.With(canWriteComments).Get("/admin/comments", h.ListModerationComments)
+			admin.With(canWriteComments).Patch("/admin/comments/{id}", h.ModerateComment)
+			admin.With(canWriteComments).Delete("/admin/comments/{id}", h.RemoveComment)
 
 			// Newsletter management
-			admin.Get("/newsletter/subscribers", h.ListSubscribers)
+			admin.With(canReadSubscribers).Get("/newsletter/subscribers", h.ListSubscribers)
 		})
 	})
 
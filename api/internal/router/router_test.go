@@ -0,0 +1,38 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/subculture-collective/subcult-tv/api/internal/config"
+	"github.com/subculture-collective/subcult-tv/api/internal/handlers"
+)
+
+// TestSignInLimitersAreSeparate tests that using up the password login
+// limit leaves the second factor, single sign-on, and refresh steps usable
+// from the same address.
+func TestSignInLimitersAreSeparate(t *testing.T) {
+	r := New(&config.Config{}, &handlers.Handler{})
+	post := func(path string) int {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, strings.NewReader("{")))
+		return w.Code
+	}
+
+	for i := 0; i < LoginRateLimit; i++ {
+		if code := post("/api/v1/auth/login"); code == http.StatusTooManyRequests {
+			t.Fatalf("login %d rate limited", i+1)
+		}
+	}
+	if code := post("/api/v1/auth/login"); code != http.StatusTooManyRequests {
+		t.Fatalf("login past the limit = %d, expected 429", code)
+	}
+
+	for _, path := range []string{"/api/v1/auth/login/2fa", "/api/v1/auth/oidc/exchange", "/api/v1/auth/refresh"} {
+		if code := post(path); code == http.StatusTooManyRequests {
+			t.Errorf("%s rate limited by password logins", path)
+		}
+	}
+}
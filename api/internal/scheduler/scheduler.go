@@ -0,0 +1,67 @@
+// Package scheduler runs the server's periodic background jobs.
+package scheduler
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// DefaultInterval is how often due posts are published.
+const DefaultInterval = 30 * time.Second
+
+var postsPublished = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "subcult_posts_published_total",
+	Help: "Scheduled posts that went live.",
+})
+
+// PublishDuePosts records the publish event for every published post whose
+// publish_at has passed but has not gone live yet, and returns their slugs.
+// It is safe to run from several servers at once.
+func PublishDuePosts(ctx context.Context, db *pgxpool.Pool) ([]string, error) {
+	rows, err := db.Query(ctx,
+		`UPDATE posts SET published_at = NOW()
+		 WHERE published AND published_at IS NULL AND publish_at <= NOW()
+		 RETURNING slug`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var slugs []string
+	for rows.Next() {
+		var slug string
+		if err := rows.Scan(&slug); err != nil {
+			return slugs, err
+		}
+		slugs = append(slugs, slug)
+	}
+	return slugs, rows.Err()
+}
+
+// Run publishes due posts every interval until ctx is cancelled.
+func Run(ctx context.Context, db *pgxpool.Pool, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		slugs, err := PublishDuePosts(ctx, db)
+		if err != nil && ctx.Err() == nil {
+			slog.Error("publish scheduled posts", "error", err)
+		}
+		for _, slug := range slugs {
+			postsPublished.Inc()
+			slog.Info("scheduled post published", "slug", slug)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package scheduler
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/subculture-collective/subcult-tv/api/internal/database"
+	"github.com/subculture-collective/subcult-tv/api/internal/dbtest"
+)
+
+// TestPublishDuePosts tests which posts go live and that each goes live
+// only once.
+func TestPublishDuePosts(t *testing.T) {
+	pool := dbtest.Pool(t)
+	ctx := context.Background()
+	if err := database.Migrate(ctx, pool); err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+
+	_, err := pool.Exec(ctx, `
+		INSERT INTO posts (slug, title, published, publish_at, published_at) VALUES
+		  ('due',          'Due',          true,  NOW() - INTERVAL '1 minute', NULL),
+		  ('not-due',      'Not due',      true,  NOW() + INTERVAL '1 hour',   NULL),
+		  ('draft',        'Draft',        false, NOW() - INTERVAL '1 minute', NULL),
+		  ('unscheduled',  'Unscheduled',  false, NULL,                        NULL),
+		  ('already-live', 'Already live', true,  NOW() - INTERVAL '1 day',    NOW() - INTERVAL '1 day')`)
+	if err != nil {
+		t.Fatalf("insert posts: %v", err)
+	}
+
+	slugs, err := PublishDuePosts(ctx, pool)
+	if err != nil {
+		t.Fatalf("PublishDuePosts: %v", err)
+	}
+	if !slices.Equal(slugs, []string{"due"}) {
+		t.Errorf("published %v, expected [due]", slugs)
+	}
+
+	var live []string
+	rows, err := pool.Query(ctx, `SELECT slug FROM posts WHERE published_at IS NOT NULL ORDER BY slug`)
+	if err != nil {
+		t.Fatalf("query posts: %v", err)
+	}
+	for rows.Next() {
+		var slug string
+		if err := rows.Scan(&slug); err != nil {
+			t.Fatal(err)
+		}
+		live = append(live, slug)
+	}
+	rows.Close()
+	if !slices.Equal(live, []string{"already-live", "due"}) {
+		t.Errorf("live posts = %v, expected [already-live due]", live)
+	}
+
+	slugs, err = PublishDuePosts(ctx, pool)
+	if err != nil {
+		t.Fatalf("PublishDuePosts again: %v", err)
+	}
+	if len(slugs) != 0 {
+		t.Errorf("second run published %v, expected none", slugs)
+	}
+}
@@ -0,0 +1,219 @@
+// Package textdiff computes line-based diffs between two texts and formats
+// them as unified diffs.
+package textdiff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Kind says whether a line is shared, removed, or added.
+type Kind int
+
+const (
+	Equal Kind = iota
+	Delete
+	Insert
+)
+
+// Line is one line of an edit script.
+type Line struct {
+	Kind Kind
+	Text string
+}
+
+// Lines returns a shortest edit script turning a into b, line by line.
+func Lines(a, b string) []Line {
+	x, y := split(a), split(b)
+
+	// Most edits touch a small part of the text; matching the common
+	// prefix and suffix up front keeps the search small.
+	pre := 0
+	for pre < len(x) && pre < len(y) && x[pre] == y[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(x)-pre && suf < len(y)-pre && x[len(x)-1-suf] == y[len(y)-1-suf] {
+		suf++
+	}
+
+	out := make([]Line, 0, len(x)+len(y))
+	for _, s := range x[:pre] {
+		out = append(out, Line{Equal, s})
+	}
+	out = append(out, myers(x[pre:len(x)-suf], y[pre:len(y)-suf])...)
+	for _, s := range x[len(x)-suf:] {
+		out = append(out, Line{Equal, s})
+	}
+	return out
+}
+
+// split breaks s into lines without their terminators. A trailing newline
+// does not start an extra empty line.
+func split(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.Split(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// frontier is the furthest x reached on each diagonal k in [-d-1, d+1]
+// before round d of the search.
+type frontier struct {
+	d int
+	x []int
+}
+
+func (f frontier) at(k int) int { return f.x[k+f.d+1] }
+
+// myers implements the O(ND) greedy algorithm from Myers' "An O(ND)
+// Difference Algorithm and Its Variations". Only the frontier of each round
+// is kept, so memory grows with the square of the edit distance rather
+// than with the input size.
+func myers(a, b []string) []Line {
+	n, m := len(a), len(b)
+	if n == 0 && m == 0 {
+		return nil
+	}
+	total := n + m
+	off := total + 1
+	v := make([]int, 2*total+3)
+	var trace []frontier
+
+	for d := 0; d <= total; d++ {
+		snap := frontier{d: d, x: make([]int, 2*d+3)}
+		copy(snap.x, v[off-d-1:off+d+2])
+		trace = append(trace, snap)
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[off+k-1] < v[off+k+1]) {
+				x = v[off+k+1]
+			} else {
+				x = v[off+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[off+k] = x
+			if x >= n && y >= m {
+				return backtrack(trace, a, b)
+			}
+		}
+	}
+	return nil // unreachable: d = n+m always reaches the end
+}
+
+// backtrack walks the recorded frontiers from the end back to the start,
+// emitting the edit script in reverse, then flips it.
+func backtrack(trace []frontier, a, b []string) []Line {
+	var out []Line
+	x, y := len(a), len(b)
+	for d := len(trace) - 1; d >= 0; d-- {
+		f := trace[d]
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && f.at(k-1) < f.at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := 0
+		if d > 0 {
+			prevX = f.at(prevK)
+		}
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			out = append(out, Line{Equal, a[x-1]})
+			x--
+			y--
+		}
+		if d > 0 {
+			if x == prevX {
+				out = append(out, Line{Insert, b[y-1]})
+			} else {
+				out = append(out, Line{Delete, a[x-1]})
+			}
+		}
+		x, y = prevX, prevY
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return out
+}
+
+// Unified formats the diff from a to b as a unified diff with the given
+// number of context lines. It returns "" when the texts are equal.
+func Unified(fromName, toName, a, b string, context int) string {
+	lines := Lines(a, b)
+
+	// Line numbers in a and b before each line of the script.
+	aNum := make([]int, len(lines)+1)
+	bNum := make([]int, len(lines)+1)
+	var changes []int
+	for i, l := range lines {
+		aNum[i+1], bNum[i+1] = aNum[i], bNum[i]
+		if l.Kind != Insert {
+			aNum[i+1]++
+		}
+		if l.Kind != Delete {
+			bNum[i+1]++
+		}
+		if l.Kind != Equal {
+			changes = append(changes, i)
+		}
+	}
+	if len(changes) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", fromName, toName)
+
+	for i := 0; i < len(changes); {
+		start := max(changes[i]-context, 0)
+		end := changes[i] + 1
+		// Merge changes whose context would touch or overlap.
+		for i < len(changes) && changes[i] <= end+2*context {
+			end = changes[i] + 1
+			i++
+		}
+		end = min(end+context, len(lines))
+
+		aLen, bLen := aNum[end]-aNum[start], bNum[end]-bNum[start]
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(aNum[start], aLen), hunkRange(bNum[start], bLen))
+		for _, l := range lines[start:end] {
+			switch l.Kind {
+			case Equal:
+				sb.WriteByte(' ')
+			case Delete:
+				sb.WriteByte('-')
+			case Insert:
+				sb.WriteByte('+')
+			}
+			sb.WriteString(l.Text)
+			sb.WriteByte('\n')
+		}
+	}
+	return sb.String()
+}
+
+// hunkRange formats a hunk's start and length the way diff -u does: the
+// start is 1-based, or the line before an empty range.
+func hunkRange(start, length int) string {
+	if length == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	if length == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, length)
+}
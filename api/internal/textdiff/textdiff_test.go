@@ -0,0 +1,90 @@
+package textdiff
+
+import (
+	"strings"
+	"testing"
+)
+
+// apply rebuilds both sides of an edit script.
+func apply(lines []Line) (a, b []string) {
+	for _, l := range lines {
+		if l.Kind != Insert {
+			a = append(a, l.Text)
+		}
+		if l.Kind != Delete {
+			b = append(b, l.Text)
+		}
+	}
+	return a, b
+}
+
+func TestLinesRoundTrip(t *testing.T) {
+	cases := []struct{ a, b string }{
+		{"", ""},
+		{"", "one\ntwo\n"},
+		{"one\ntwo\n", ""},
+		{"a\nb\nc\n", "a\nb\nc\n"},
+		{"a\nb\nc\n", "a\nx\nc\n"},
+		{"a\nb\nc\na\nb\nb\na\n", "c\nb\na\nb\na\nc\n"},
+		{"intro\nbody\noutro", "intro\nnew\nbody\noutro\ncoda"},
+	}
+	for _, c := range cases {
+		lines := Lines(c.a, c.b)
+		a, b := apply(lines)
+		if strings.Join(a, "\n") != strings.Join(split(c.a), "\n") ||
+			strings.Join(b, "\n") != strings.Join(split(c.b), "\n") {
+			t.Errorf("Lines(%q, %q) does not rebuild its inputs: %+v", c.a, c.b, lines)
+		}
+	}
+}
+
+func TestLinesIsMinimal(t *testing.T) {
+	// The classic example from Myers' paper has an edit distance of 5.
+	lines := Lines("a\nb\nc\na\nb\nb\na\n", "c\nb\na\nb\na\nc\n")
+	edits := 0
+	for _, l := range lines {
+		if l.Kind != Equal {
+			edits++
+		}
+	}
+	if edits != 5 {
+		t.Errorf("got %d edits, want 5", edits)
+	}
+}
+
+func TestUnified(t *testing.T) {
+	a := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n"
+	b := "1\n2\nthree\n4\n5\n6\n7\n8\n9\n10\n11\n12\n13\n"
+	want := `--- old
++++ new
+@@ -1,6 +1,6 @@
+ 1
+ 2
+-3
++three
+ 4
+ 5
+ 6
+@@ -10,3 +10,4 @@
+ 10
+ 11
+ 12
++13
+`
+	if got := Unified("old", "new", a, b, 3); got != want {
+		t.Errorf("Unified:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestUnifiedEqual(t *testing.T) {
+	if got := Unified("a", "b", "same\n", "same\n", 3); got != "" {
+		t.Errorf("Unified of equal texts = %q, want empty", got)
+	}
+}
+
+func TestUnifiedFromEmpty(t *testing.T) {
+	want := "--- a\n+++ b\n@@ -0,0 +1,2 @@\n+x\n+y\n"
+	if got := Unified("a", "b", "", "x\ny\n", 3); got != want {
+		t.Errorf("Unified = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,65 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA1 test key from RFC 6238 appendix B.
+var rfcSecret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+// TestCodeRFCVectors tests code generation against the RFC 6238 test vectors
+// (truncated to 6 digits).
+func TestCodeRFCVectors(t *testing.T) {
+	tests := []struct {
+		unix     int64
+		expected string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, tt := range tests {
+		code, err := Code(rfcSecret, Step(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("Code: %v", err)
+		}
+		if code != tt.expected {
+			t.Errorf("Code at %d = %s, expected %s", tt.unix, code, tt.expected)
+		}
+	}
+}
+
+// TestValidate tests acceptance within the skew window and rejection outside it.
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	code, _ := Code(rfcSecret, Step(now))
+
+	if step, ok := Validate(rfcSecret, code, now); !ok || step != Step(now) {
+		t.Errorf("expected current code to validate at step %d, got %d %v", Step(now), step, ok)
+	}
+	if _, ok := Validate(rfcSecret, code, now.Add(Period)); !ok {
+		t.Error("expected code from previous step to validate")
+	}
+	if _, ok := Validate(rfcSecret, code, now.Add(3*Period)); ok {
+		t.Error("expected code outside skew window to be rejected")
+	}
+	if _, ok := Validate(rfcSecret, "12345", now); ok {
+		t.Error("expected malformed code to be rejected")
+	}
+}
+
+// TestURI tests the otpauth URI shape.
+func TestURI(t *testing.T) {
+	uri := URI("SUBCULT", "alice", "ABC")
+	if !strings.HasPrefix(uri, "otpauth://totp/SUBCULT:alice?") {
+		t.Errorf("unexpected URI prefix: %s", uri)
+	}
+	if !strings.Contains(uri, "secret=ABC") || !strings.Contains(uri, "issuer=SUBCULT") {
+		t.Errorf("URI missing parameters: %s", uri)
+	}
+}
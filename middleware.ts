@@ -0,0 +1,9 @@
+// Vercel routing middleware: the API serves the feeds now, so send the old
+// site-hosted feed URLs there. VITE_API_URL is the same project variable
+// the build uses.
+export const config = { matcher: ['/feed.xml', '/atom.xml', '/feed.json'] };
+
+export default function middleware(request: Request) {
+  const url = new URL(request.url);
+  return Response.redirect(`${process.env.VITE_API_URL}${url.pathname}${url.search}`, 301);
+}
@@ -7,6 +7,7 @@ import Patreon from '@/pages/Patreon';
 import About from '@/pages/About';
 import Zine from '@/pages/Zine';
 import PostPage from '@/pages/PostPage';
+import PostPreview from '@/pages/PostPreview';
 import Contact from '@/pages/Contact';
 import PressKit from '@/pages/PressKit';
 import NotFound from '@/pages/NotFound';
@@ -39,6 +40,7 @@ export default function App() {
         <Route path="support" element={<Patreon />} />
         <Route path="about" element={<About />} />
         <Route path="zine" element={<Zine />} />
+        <Route path="zine/preview" element={<PostPreview />} />
         <Route path="zine/:slug" element={<PostPage />} />
         <Route path="contact" element={<Contact />} />
         <Route path="press" element={<PressKit />} />
@@ -17,7 +17,7 @@ const FOOTER_LINKS = [
 const SOCIAL_LINKS = [
   { href: 'https://github.com/subculture-collective', label: 'GitHub', external: true },
   { href: 'https://www.patreon.com/subcult', label: 'Patreon', external: true },
-  { href: '/feed.xml', label: 'RSS Feed', external: false },
+  { href: `${import.meta.env.VITE_API_URL || ''}/feed.xml`, label: 'RSS Feed', external: false },
 ];
 
 export default function Footer() {
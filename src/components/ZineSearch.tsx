@@ -0,0 +1,77 @@
+import { useState, useEffect } from 'react';
+import { Link } from 'react-router-dom';
+import Card from '@/components/ui/Card';
+import { searchPosts, type APIPostSearchResult } from '@/lib/api';
+
+/** Full-text search over published zine posts, debounced as the reader types. */
+export default function ZineSearch() {
+  const [query, setQuery] = useState('');
+  const [results, setResults] = useState<APIPostSearchResult[] | null>(null);
+  const [error, setError] = useState('');
+
+  useEffect(() => {
+    const q = query.trim();
+    if (!q) {
+      setResults(null);
+      setError('');
+      return;
+    }
+    let cancelled = false;
+    const timer = setTimeout(() => {
+      searchPosts(q, { perPage: 10 })
+        .then((res) => {
+          if (!cancelled) {
+            setResults(res.data);
+            setError('');
+          }
+        })
+        .catch((err) => {
+          if (!cancelled) setError(err.message);
+        });
+    }, 300);
+    return () => {
+      cancelled = true;
+      clearTimeout(timer);
+    };
+  }, [query]);
+
+  return (
+    <div className="max-w-3xl mx-auto mb-12">
+      <label htmlFor="zine-search" className="block font-mono text-xs text-dust mb-2">
+        &gt; grep -ri
+      </label>
+      <input
+        id="zine-search"
+        type="search"
+        value={query}
+        onChange={(e) => setQuery(e.target.value)}
+        placeholder="Search the zine…"
+        maxLength={200}
+        className="w-full bg-void border border-fog text-chalk font-mono text-sm px-3 py-2 focus:border-signal outline-none"
+      />
+
+      {error && <p className="mt-3 font-mono text-xs text-signal">ERR: {error}</p>}
+
+      {results && (
+        <div className="mt-4 space-y-3">
+          {results.length === 0 && (
+            <p className="font-mono text-sm text-bone">No transmissions match.</p>
+          )}
+          {results.map((r) => (
+            <Link key={r.id} to={`/zine/${r.slug}`} className="block no-underline">
+              <Card hoverable className="p-4">
+                <h3 className="font-display text-lg text-glow mb-1">{r.title}</h3>
+                {/* The API escapes the snippet and adds only <mark> tags. */}
+                <p
+                  className="text-sm text-bone [&_mark]:bg-transparent [&_mark]:text-signal"
+                  dangerouslySetInnerHTML={{ __html: r.snippet }}
+                />
+                <span className="font-mono text-xs text-dust">{r.date}</span>
+              </Card>
+            </Link>
+          ))}
+        </div>
+      )}
+    </div>
+  );
+}
@@ -0,0 +1,120 @@
+import { useState, useEffect } from 'react';
+import {
+  listPostRevisions,
+  diffPostRevisions,
+  restorePostRevision,
+  type APIPost,
+  type APIPostRevision,
+} from '@/lib/api';
+
+interface PostHistoryProps {
+  post: APIPost;
+  onRestored: (post: APIPost) => void;
+  onClose: () => void;
+}
+
+/** Lists a post's revisions with a diff against the previous one and a restore action. */
+export function PostHistory({ post, onRestored, onClose }: PostHistoryProps) {
+  const [revisions, setRevisions] = useState<APIPostRevision[]>([]);
+  const [diff, setDiff] = useState<{ number: number; text: string } | null>(null);
+  const [error, setError] = useState('');
+
+  const load = () => {
+    listPostRevisions(post.id, { perPage: 50 })
+      .then((res) => setRevisions(res.data))
+      .catch((err) => setError(err.message));
+  };
+
+  useEffect(load, [post.id]);
+
+  const showDiff = async (number: number) => {
+    setError('');
+    try {
+      const res = await diffPostRevisions(post.id, undefined, number);
+      setDiff({ number, text: res.diff || 'No changes.' });
+    } catch (err) {
+      setError(err instanceof Error ? err.message : 'diff failed');
+    }
+  };
+
+  const restore = async (number: number) => {
+    if (!confirm(`Restore revision ${number}? The current text is kept in the history.`)) return;
+    setError('');
+    try {
+      onRestored(await restorePostRevision(post.id, number));
+      setDiff(null);
+      load();
+    } catch (err) {
+      setError(err instanceof Error ? err.message : 'restore failed');
+    }
+  };
+
+  return (
+    <div className="mb-6 bg-soot border border-fog p-6">
+      <div className="flex items-center justify-between mb-4">
+        <h2 className="text-lg">History: {post.title}</h2>
+        <button
+          onClick={onClose}
+          className="font-mono text-xs text-dust hover:text-chalk cursor-pointer"
+        >
+          CLOSE
+        </button>
+      </div>
+
+      {error && <p className="mb-3 font-mono text-xs text-signal">ERR: {error}</p>}
+
+      <ul className="space-y-1 mb-4">
+        {revisions.map((rev, i) => (
+          <li key={rev.number} className="flex items-center gap-3 font-mono text-xs">
+            <span className="text-chalk w-10">#{rev.number}</span>
+            <span className="text-bone">{new Date(rev.created_at).toLocaleString()}</span>
+            <span className="text-dust flex-1">{rev.editor ?? '—'}</span>
+            {i < revisions.length - 1 && (
+              <button
+                onClick={() => showDiff(rev.number)}
+                className="text-cyan hover:text-glow cursor-pointer"
+              >
+                DIFF
+              </button>
+            )}
+            {i > 0 && (
+              <button
+                onClick={() => restore(rev.number)}
+                className="text-flicker hover:text-glow cursor-pointer"
+              >
+                RESTORE
+              </button>
+            )}
+          </li>
+        ))}
+        {revisions.length === 0 && (
+          <li className="font-mono text-xs text-bone">No revisions recorded.</li>
+        )}
+      </ul>
+
+      {diff && (
+        <div>
+          <p className="font-mono text-xs text-dust mb-2">Changes in revision {diff.number}</p>
+          <pre className="bg-void border border-fog p-3 overflow-x-auto font-mono text-xs leading-relaxed">
+            {diff.text.split('\n').map((line, i) => (
+              <div
+                key={i}
+                className={
+                  line.startsWith('+')
+                    ? 'text-static'
+                    : line.startsWith('-')
+                      ? 'text-signal'
+                      : line.startsWith('@@')
+                        ? 'text-cyan'
+                        : 'text-bone'
+                }
+              >
+                {line || ' '}
+              </div>
+            ))}
+          </pre>
+        </div>
+      )}
+    </div>
+  );
+}
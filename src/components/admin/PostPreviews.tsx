@@ -0,0 +1,126 @@
+import { useState, useEffect, type FormEvent } from 'react';
+import {
+  listPostPreviews,
+  createPostPreview,
+  revokePostPreview,
+  type APIPost,
+  type APIPostPreview,
+} from '@/lib/api';
+import { Field } from '@/components/admin/FormFields';
+
+interface PostPreviewsProps {
+  post: APIPost;
+  onClose: () => void;
+}
+
+function previewStatus(p: APIPostPreview) {
+  if (p.revoked_at) return 'REVOKED';
+  if (new Date(p.expires_at) <= new Date()) return 'EXPIRED';
+  return 'ACTIVE';
+}
+
+/** Mints and revokes shareable preview links for one post. */
+export function PostPreviews({ post, onClose }: PostPreviewsProps) {
+  const [previews, setPreviews] = useState<APIPostPreview[]>([]);
+  const [label, setLabel] = useState('');
+  const [hours, setHours] = useState('72');
+  const [link, setLink] = useState('');
+  const [error, setError] = useState('');
+
+  const load = () => {
+    listPostPreviews(post.id)
+      .then(setPreviews)
+      .catch((err) => setError(err.message));
+  };
+
+  useEffect(load, [post.id]);
+
+  const handleCreate = async (e: FormEvent) => {
+    e.preventDefault();
+    setError('');
+    try {
+      const res = await createPostPreview(post.id, {
+        label,
+        expires_in_hours: Number(hours) || undefined,
+      });
+      setLink(res.url);
+      setLabel('');
+      load();
+    } catch (err) {
+      setError(err instanceof Error ? err.message : 'create failed');
+    }
+  };
+
+  const handleRevoke = async (id: string) => {
+    if (!confirm('Revoke this preview link?')) return;
+    try {
+      await revokePostPreview(post.id, id);
+      load();
+    } catch (err) {
+      setError(err instanceof Error ? err.message : 'revoke failed');
+    }
+  };
+
+  return (
+    <div className="mb-6 bg-soot border border-fog p-6">
+      <div className="flex items-center justify-between mb-4">
+        <h2 className="text-lg">Preview links: {post.title}</h2>
+        <button
+          onClick={onClose}
+          className="font-mono text-xs text-dust hover:text-chalk cursor-pointer"
+        >
+          CLOSE
+        </button>
+      </div>
+
+      {error && <p className="mb-3 font-mono text-xs text-signal">ERR: {error}</p>}
+
+      <form onSubmit={handleCreate} className="grid grid-cols-1 md:grid-cols-3 gap-3 items-end mb-4">
+        <Field label="Label (who it's for)" value={label} onChange={setLabel} />
+        <Field label="Expires in (hours)" value={hours} onChange={setHours} type="number" />
+        <button
+          type="submit"
+          className="px-4 py-2 bg-signal text-void font-mono text-sm font-bold hover:bg-signal-dim transition-colors duration-200 cursor-pointer"
+        >
+          CREATE LINK
+        </button>
+      </form>
+
+      {link && (
+        <div className="mb-4 p-3 bg-ash border border-static font-mono text-xs">
+          <p className="text-static mb-1">Copy this link now — it won&apos;t be shown again:</p>
+          <input
+            readOnly
+            value={link}
+            onFocus={(e) => e.target.select()}
+            className="w-full bg-void border border-fog px-2 py-1 text-chalk"
+          />
+        </div>
+      )}
+
+      <ul className="space-y-1">
+        {previews.map((p) => (
+          <li key={p.id} className="flex items-center gap-3 font-mono text-xs">
+            <span className="text-chalk flex-1">{p.label || '(no label)'}</span>
+            <span className="text-dust">{p.created_by ?? '—'}</span>
+            <span className="text-bone">until {new Date(p.expires_at).toLocaleString()}</span>
+            <span className={previewStatus(p) === 'ACTIVE' ? 'text-static' : 'text-dust'}>
+              {previewStatus(p)}
+            </span>
+            {previewStatus(p) === 'ACTIVE' && (
+              <button
+                onClick={() => handleRevoke(p.id)}
+                className="text-signal hover:text-glow cursor-pointer"
+              >
+                REVOKE
+              </button>
+            )}
+          </li>
+        ))}
+        {previews.length === 0 && (
+          <li className="font-mono text-xs text-bone">No preview links yet.</li>
+        )}
+      </ul>
+    </div>
+  );
+}
@@ -0,0 +1,53 @@
+import { useState, useEffect } from 'react';
+import { listTags, renameTag } from '@/lib/api';
+
+interface TagManagerProps {
+  onChanged: () => void;
+}
+
+/** Shows tags in use on public posts and renames or merges them across all posts. */
+export function TagManager({ onChanged }: TagManagerProps) {
+  const [tags, setTags] = useState<{ tag: string; count: number }[]>([]);
+  const [error, setError] = useState('');
+
+  const load = () => {
+    listTags()
+      .then(setTags)
+      .catch((err) => setError(err.message));
+  };
+
+  useEffect(load, []);
+
+  const handleRename = async (tag: string) => {
+    const name = prompt(`Rename "${tag}" on every post to (an existing tag merges them):`, tag);
+    if (!name || name.trim() === tag) return;
+    setError('');
+    try {
+      const res = await renameTag(tag, name.trim());
+      alert(`Updated ${res.posts_updated} post(s).`);
+      load();
+      onChanged();
+    } catch (err) {
+      setError(err instanceof Error ? err.message : 'rename failed');
+    }
+  };
+
+  return (
+    <div className="mb-6">
+      <p className="font-mono text-xs text-dust mb-2">TAGS (click to rename or merge)</p>
+      {error && <p className="mb-2 font-mono text-xs text-signal">ERR: {error}</p>}
+      <div className="flex flex-wrap gap-2">
+        {tags.map((t) => (
+          <button
+            key={t.tag}
+            onClick={() => handleRename(t.tag)}
+            className="px-2 py-1 bg-ash text-bone font-mono text-xs hover:text-glow cursor-pointer"
+          >
+            {t.tag} <span className="text-dust">{t.count}</span>
+          </button>
+        ))}
+        {tags.length === 0 && <span className="font-mono text-xs text-bone">No tags yet.</span>}
+      </div>
+    </div>
+  );
+}
@@ -23,11 +23,25 @@ export function AuthProvider({ children }: { children: ReactNode }) {
 
   const login = useCallback(async (username: string, password: string) => {
     const res = await api.login(username, password);
+    if ('mfa_required' in res) return res;
     setUser(res.user);
+    return null;
+  }, []);
+
+  const loginMFA = useCallback(async (mfaToken: string, code: string) => {
+    const res = await api.loginMFA(mfaToken, code);
+    setUser(res.user);
+  }, []);
+
+  const loginOIDC = useCallback(async (code: string) => {
+    const res = await api.loginOIDC(code);
+    if ('mfa_required' in res) return res;
+    setUser(res.user);
+    return null;
   }, []);
 
   const logout = useCallback(() => {
-    api.logout();
+    void api.logout();
     setUser(null);
   }, []);
 
@@ -37,6 +51,8 @@ export function AuthProvider({ children }: { children: ReactNode }) {
         user,
         loading,
         login,
+        loginMFA,
+        loginOIDC,
         logout,
         isAuthenticated: !!user,
       }}
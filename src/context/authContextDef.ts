@@ -1,10 +1,12 @@
 import { createContext } from 'react';
-import type { APIUser } from '@/lib/api';
+import type { APIUser, MFAChallenge } from '@/lib/api';
 
 export interface AuthContextValue {
   user: APIUser | null;
   loading: boolean;
-  login: (username: string, password: string) => Promise<void>;
+  login: (username: string, password: string) => Promise<MFAChallenge | null>;
+  loginMFA: (mfaToken: string, code: string) => Promise<void>;
+  loginOIDC: (code: string) => Promise<MFAChallenge | null>;
   logout: () => void;
   isAuthenticated: boolean;
 }
@@ -8,6 +8,7 @@ const API_BASE = import.meta.env.VITE_API_URL || '';
 // ── Token management ─────────────────────────────────────────
 
 let authToken: string | null = null;
+let refreshToken: string | null = null;
 
 export function setToken(token: string | null) {
   authToken = token;
@@ -25,14 +26,63 @@ export function getToken(): string | null {
   return authToken;
 }
 
+export function setRefreshToken(token: string | null) {
+  refreshToken = token;
+  if (token) {
+    localStorage.setItem('subcult-refresh-token', token);
+  } else {
+    localStorage.removeItem('subcult-refresh-token');
+  }
+}
+
+export function getRefreshToken(): string | null {
+  if (!refreshToken) {
+    refreshToken = localStorage.getItem('subcult-refresh-token');
+  }
+  return refreshToken;
+}
+
 export function clearToken() {
   authToken = null;
+  refreshToken = null;
   localStorage.removeItem('subcult-token');
+  localStorage.removeItem('subcult-refresh-token');
+}
+
+// Access tokens are short-lived; trade the refresh token for a new pair.
+// Concurrent 401s share one in-flight refresh.
+let refreshing: Promise<boolean> | null = null;
+
+async function refreshSession(): Promise<boolean> {
+  const token = getRefreshToken();
+  if (!token) return false;
+  if (!refreshing) {
+    refreshing = fetch(`${API_BASE}/api/v1/auth/refresh`, {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({ refresh_token: token }),
+    })
+      .then(async (res) => {
+        if (!res.ok) {
+          clearToken();
+          return false;
+        }
+        const body: LoginResponse = await res.json();
+        setToken(body.token);
+        setRefreshToken(body.refresh_token);
+        return true;
+      })
+      .catch(() => false)
+      .finally(() => {
+        refreshing = null;
+      });
+  }
+  return refreshing;
 }
 
 // ── Fetch wrapper ────────────────────────────────────────────
 
-async function apiFetch<T>(path: string, options: RequestInit = {}): Promise<T> {
+async function apiFetch<T>(path: string, options: RequestInit = {}, retry = true): Promise<T> {
   const token = getToken();
   const headers: Record<string, string> = {
     'Content-Type': 'application/json',
@@ -48,6 +98,10 @@ async function apiFetch<T>(path: string, options: RequestInit = {}): Promise<T>
     headers,
   });
 
+  if (res.status === 401 && token && retry && (await refreshSession())) {
+    return apiFetch<T>(path, options, false);
+  }
+
   if (!res.ok) {
     const body = await res.json().catch(() => ({ error: res.statusText }));
     throw new APIError(body.error || res.statusText, res.status);
@@ -77,6 +131,24 @@ export interface APIUser {
   updated_at: string;
 }
 
+export interface LoginResponse {
+  token: string;
+  refresh_token: string;
+  expires_at: string;
+  user: APIUser;
+}
+
+export interface MFAChallenge {
+  mfa_required: true;
+  mfa_token: string;
+  enrollment_required: boolean;
+}
+
+export interface MFASetup {
+  secret: string;
+  otpauth_uri: string;
+}
+
 export interface APIProject {
   id: string;
   slug: string;
@@ -106,14 +178,167 @@ export interface APIPost {
   title: string;
   excerpt: string;
   content: string;
+  /** Sanitized HTML rendered from `content` by the API. */
+  content_html?: string;
+  toc?: APITOCEntry[];
+  word_count?: number;
+  /** Estimated minutes to read. */
+  reading_time?: number;
   tags: string[];
+  /** Byline built from `authors`; set it alone to match or create authors by name. */
   author?: string;
+  authors?: APIAuthorRef[];
   published: boolean;
   date: string;
+  publish_at?: string;
+  published_at?: string;
+  /** Set on single-post responses when the post is in an issue. */
+  issue?: APIPostIssue;
+  /** Set on single-post responses: public posts to read next, best first. */
+  related?: APIRelatedPost[];
+  created_at: string;
+  updated_at: string;
+}
+
+export interface APIAuthorRef {
+  id: string;
+  slug: string;
+  name: string;
+  avatar_url?: string;
+}
+
+export interface APIAuthor {
+  id: string;
+  slug: string;
+  name: string;
+  bio: string;
+  avatar_url?: string;
+  links: { label: string; url: string }[];
+  /** Linked user account; admin responses only. */
+  user_id?: string;
+  post_count: number;
   created_at: string;
   updated_at: string;
 }
 
+export interface APIAuthorDetail extends APIAuthor {
+  posts: APIPost[];
+}
+
+export interface APIPostLink {
+  slug: string;
+  title: string;
+}
+
+/** A post's place in its issue; `position` is 1-based. */
+export interface APIPostIssue {
+  number: number;
+  title: string;
+  position: number;
+  previous: APIPostLink | null;
+  next: APIPostLink | null;
+}
+
+/** `score` is shared tags plus a text similarity below 1. */
+export interface APIRelatedPost {
+  slug: string;
+  title: string;
+  excerpt: string;
+  date: string;
+  tags: string[];
+  reading_time: number;
+  score: number;
+}
+
+export interface APIIssue {
+  id: string;
+  number: number;
+  title: string;
+  description: string;
+  cover_url?: string;
+  post_count: number;
+  /** In reading order; admin responses only. */
+  post_ids?: string[];
+  created_at: string;
+  updated_at: string;
+}
+
+export interface APIIssueDetail extends APIIssue {
+  posts: APIPost[];
+}
+
+export type APICommentStatus = 'pending' | 'approved' | 'rejected' | 'spam';
+
+/**
+ * A reader comment. Deleted comments keep their replies but lose `author_name` and `body`, as do
+ * `hidden` ones, which await moderation (e.g. after an edit) and are listed only for their replies.
+ */
+export interface APIComment {
+  id: string;
+  post_id: string;
+  parent_id?: string;
+  author_name: string;
+  body: string;
+  deleted?: boolean;
+  hidden?: boolean;
+  edited_at?: string;
+  created_at: string;
+  replies?: APIComment[];
+  /** Moderation fields; admin responses only. */
+  author_email?: string;
+  status?: APICommentStatus;
+  post_slug?: string;
+  post_title?: string;
+  moderated_at?: string;
+}
+
+export interface APITOCEntry {
+  level: number;
+  id: string;
+  text: string;
+}
+
+/** A search hit. `snippet` is escaped HTML with matches wrapped in <mark>. */
+export interface APIPostSearchResult {
+  id: string;
+  slug: string;
+  title: string;
+  excerpt: string;
+  tags: string[];
+  author?: string;
+  date: string;
+  rank: number;
+  snippet: string;
+}
+
+export interface APIPostRevision {
+  post_id: string;
+  number: number;
+  slug: string;
+  title: string;
+  excerpt: string;
+  content?: string;
+  tags: string[];
+  author?: string;
+  published: boolean;
+  date: string;
+  publish_at?: string;
+  editor_id?: string;
+  editor?: string;
+  created_at: string;
+}
+
+export interface APIPostPreview {
+  id: string;
+  post_id: string;
+  label: string;
+  created_by?: string;
+  expires_at: string;
+  revoked_at?: string;
+  last_used_at?: string;
+  created_at: string;
+}
+
 export interface APIContact {
   id: string;
   name: string;
@@ -177,11 +402,50 @@ export async function getPatreonCampaign() {
 // ── Auth ─────────────────────────────────────────────────────
 
 export async function login(username: string, password: string) {
-  const res = await apiFetch<{ token: string; user: APIUser }>('/api/v1/auth/login', {
+  const res = await apiFetch<LoginResponse | MFAChallenge>('/api/v1/auth/login', {
     method: 'POST',
     body: JSON.stringify({ username, password }),
   });
+  if ('mfa_required' in res) return res;
   setToken(res.token);
+  setRefreshToken(res.refresh_token);
+  return res;
+}
+
+export async function loginMFA(mfaToken: string, code: string) {
+  const res = await apiFetch<LoginResponse>('/api/v1/auth/login/2fa', {
+    method: 'POST',
+    body: JSON.stringify({ mfa_token: mfaToken, code }),
+  });
+  setToken(res.token);
+  setRefreshToken(res.refresh_token);
+  return res;
+}
+
+export async function loginMFASetup(mfaToken: string) {
+  return apiFetch<MFASetup>('/api/v1/auth/login/2fa/setup', {
+    method: 'POST',
+    body: JSON.stringify({ mfa_token: mfaToken }),
+  });
+}
+
+export async function getOIDCStatus() {
+  return apiFetch<{ enabled: boolean }>('/api/v1/auth/oidc');
+}
+
+/** Full-page redirect that starts single sign-on at the identity provider. */
+export function oidcLoginURL() {
+  return `${API_BASE}/api/v1/auth/oidc/login`;
+}
+
+export async function loginOIDC(code: string) {
+  const res = await apiFetch<LoginResponse | MFAChallenge>('/api/v1/auth/oidc/exchange', {
+    method: 'POST',
+    body: JSON.stringify({ code }),
+  });
+  if ('mfa_required' in res) return res;
+  setToken(res.token);
+  setRefreshToken(res.refresh_token);
   return res;
 }
 
@@ -189,8 +453,16 @@ export async function getMe() {
   return apiFetch<APIUser>('/api/v1/auth/me');
 }
 
-export function logout() {
+export async function logout() {
+  const token = getRefreshToken();
   clearToken();
+  if (token) {
+    await fetch(`${API_BASE}/api/v1/auth/logout`, {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({ refresh_token: token }),
+    }).catch(() => undefined);
+  }
 }
 
 // ── Projects ─────────────────────────────────────────────────
@@ -232,30 +504,94 @@ export async function deleteProject(id: string) {
 
 // ── Posts ─────────────────────────────────────────────────────
 
-export async function listPosts(opts?: { page?: number; perPage?: number; all?: boolean }) {
+/**
+ * Lists posts, newest first. `all` includes drafts and scheduled posts and
+ * needs an admin session. `tags` filters to posts with any of the tags, or
+ * all of them with `match: 'all'`.
+ */
+export async function listPosts(opts?: {
+  page?: number;
+  perPage?: number;
+  all?: boolean;
+  tags?: string[];
+  match?: 'any' | 'all';
+}) {
   const params = new URLSearchParams();
   if (opts?.page) params.set('page', String(opts.page));
   if (opts?.perPage) params.set('per_page', String(opts.perPage));
-  if (opts?.all) params.set('all', 'true');
+  opts?.tags?.forEach((t) => params.append('tag', t));
+  if (opts?.match) params.set('match', opts.match);
   const qs = params.toString();
-  return apiFetch<PaginatedResponse<APIPost>>(`/api/v1/posts${qs ? '?' + qs : ''}`);
+  const path = opts?.all ? '/api/v1/admin/posts' : '/api/v1/posts';
+  return apiFetch<PaginatedResponse<APIPost>>(`${path}${qs ? '?' + qs : ''}`);
+}
+
+export async function listTags() {
+  return apiFetch<{ tag: string; count: number }[]>('/api/v1/tags');
+}
+
+/** Renames a tag on every post; renaming onto an existing tag merges them. */
+export async function renameTag(tag: string, name: string) {
+  return apiFetch<{ posts_updated: number }>(`/api/v1/admin/tags/${encodeURIComponent(tag)}`, {
+    method: 'PUT',
+    body: JSON.stringify({ name }),
+  });
+}
+
+export async function mergeTags(from: string[], to: string) {
+  return apiFetch<{ posts_updated: number }>('/api/v1/admin/tags/merge', {
+    method: 'POST',
+    body: JSON.stringify({ from, to }),
+  });
+}
+
+/** Downloads every post and project as a static bundle (MDX + projects.json). */
+export async function downloadContentExport(format: 'tar.gz' | 'zip' = 'tar.gz', retry = true) {
+  const token = getToken();
+  const res = await fetch(`${API_BASE}/api/v1/admin/content/export?format=${format}`, {
+    headers: token ? { Authorization: `Bearer ${token}` } : {},
+  });
+  if (res.status === 401 && token && retry && (await refreshSession())) {
+    return downloadContentExport(format, false);
+  }
+  if (!res.ok) {
+    const body = await res.json().catch(() => ({ error: res.statusText }));
+    throw new APIError(body.error || res.statusText, res.status);
+  }
+
+  const name =
+    /filename="([^"]+)"/.exec(res.headers.get('Content-Disposition') ?? '')?.[1] ??
+    `subcult-content.${format}`;
+  const url = URL.createObjectURL(await res.blob());
+  const a = document.createElement('a');
+  a.href = url;
+  a.download = name;
+  a.click();
+  URL.revokeObjectURL(url);
+}
+
+export async function searchPosts(q: string, opts?: { page?: number; perPage?: number }) {
+  const params = new URLSearchParams({ q });
+  if (opts?.page) params.set('page', String(opts.page));
+  if (opts?.perPage) params.set('per_page', String(opts.perPage));
+  return apiFetch<PaginatedResponse<APIPostSearchResult>>(`/api/v1/posts/search?${params}`);
 }
 
 export async function getPost(slug: string) {
   return apiFetch<APIPost>(`/api/v1/posts/${slug}`);
 }
 
-export async function createPost(data: Omit<APIPost, 'id' | 'created_at' | 'updated_at'>) {
+/** `author_ids`, in order, take precedence over the `author` byline. */
+type PostInput = Omit<APIPost, 'id' | 'created_at' | 'updated_at'> & { author_ids?: string[] };
+
+export async function createPost(data: PostInput) {
   return apiFetch<APIPost>('/api/v1/posts', {
     method: 'POST',
     body: JSON.stringify(data),
   });
 }
 
-export async function updatePost(
-  id: string,
-  data: Omit<APIPost, 'id' | 'created_at' | 'updated_at'>,
-) {
+export async function updatePost(id: string, data: PostInput) {
   return apiFetch<APIPost>(`/api/v1/posts/${id}`, {
     method: 'PUT',
     body: JSON.stringify(data),
@@ -266,6 +602,182 @@ export async function deletePost(id: string) {
   return apiFetch<void>(`/api/v1/posts/${id}`, { method: 'DELETE' });
 }
 
+export async function listPostRevisions(postId: string, opts?: { page?: number; perPage?: number }) {
+  const params = new URLSearchParams();
+  if (opts?.page) params.set('page', String(opts.page));
+  if (opts?.perPage) params.set('per_page', String(opts.perPage));
+  const qs = params.toString();
+  return apiFetch<PaginatedResponse<APIPostRevision>>(
+    `/api/v1/admin/posts/${postId}/revisions${qs ? '?' + qs : ''}`,
+  );
+}
+
+export async function diffPostRevisions(postId: string, from?: number, to?: number) {
+  const params = new URLSearchParams();
+  if (from) params.set('from', String(from));
+  if (to) params.set('to', String(to));
+  const qs = params.toString();
+  return apiFetch<{ from: number; to: number; diff: string }>(
+    `/api/v1/admin/posts/${postId}/revisions/diff${qs ? '?' + qs : ''}`,
+  );
+}
+
+export async function restorePostRevision(postId: string, number: number) {
+  return apiFetch<APIPost>(`/api/v1/admin/posts/${postId}/revisions/${number}/restore`, {
+    method: 'POST',
+  });
+}
+
+export async function listPostPreviews(postId: string) {
+  return apiFetch<APIPostPreview[]>(`/api/v1/admin/posts/${postId}/previews`);
+}
+
+export async function createPostPreview(
+  postId: string,
+  data: { label?: string; expires_in_hours?: number },
+) {
+  return apiFetch<APIPostPreview & { token: string; url: string }>(
+    `/api/v1/admin/posts/${postId}/previews`,
+    { method: 'POST', body: JSON.stringify(data) },
+  );
+}
+
+export async function revokePostPreview(postId: string, previewId: string) {
+  return apiFetch<void>(`/api/v1/admin/posts/${postId}/previews/${previewId}`, {
+    method: 'DELETE',
+  });
+}
+
+/** Fetches a draft with a preview token; works without an admin session. */
+export async function getPostPreview(token: string) {
+  return apiFetch<APIPost>('/api/v1/preview', { headers: { 'X-Preview-Token': token } });
+}
+
+// ── Authors ──────────────────────────────────────────────────
+
+type AuthorInput = Pick<APIAuthor, 'name' | 'bio' | 'avatar_url' | 'links' | 'user_id'> & {
+  /** Derived from the name when empty. */
+  slug?: string;
+};
+
+/** Lists authors with public posts, or every author with `all` (admin). */
+export async function listAuthors(opts?: { all?: boolean }) {
+  return apiFetch<APIAuthor[]>(opts?.all ? '/api/v1/admin/authors' : '/api/v1/authors');
+}
+
+export async function getAuthor(slug: string) {
+  return apiFetch<APIAuthorDetail>(`/api/v1/authors/${slug}`);
+}
+
+export async function createAuthor(data: AuthorInput) {
+  return apiFetch<APIAuthor>('/api/v1/authors', {
+    method: 'POST',
+    body: JSON.stringify(data),
+  });
+}
+
+export async function updateAuthor(id: string, data: AuthorInput) {
+  return apiFetch<APIAuthor>(`/api/v1/authors/${id}`, {
+    method: 'PUT',
+    body: JSON.stringify(data),
+  });
+}
+
+export async function deleteAuthor(id: string) {
+  return apiFetch<void>(`/api/v1/authors/${id}`, { method: 'DELETE' });
+}
+
+// ── Issues ───────────────────────────────────────────────────
+
+type IssueInput = Pick<APIIssue, 'number' | 'title' | 'description' | 'cover_url'> & {
+  post_ids: string[];
+};
+
+/** Lists issues with public posts, or every issue with `all` (admin). */
+export async function listIssues(opts?: { all?: boolean }) {
+  return apiFetch<APIIssue[]>(opts?.all ? '/api/v1/admin/issues' : '/api/v1/issues');
+}
+
+export async function getIssue(number: number) {
+  return apiFetch<APIIssueDetail>(`/api/v1/issues/${number}`);
+}
+
+export async function createIssue(data: IssueInput) {
+  return apiFetch<APIIssue>('/api/v1/issues', {
+    method: 'POST',
+    body: JSON.stringify(data),
+  });
+}
+
+export async function updateIssue(id: string, data: IssueInput) {
+  return apiFetch<APIIssue>(`/api/v1/issues/${id}`, {
+    method: 'PUT',
+    body: JSON.stringify(data),
+  });
+}
+
+export async function deleteIssue(id: string) {
+  return apiFetch<void>(`/api/v1/issues/${id}`, { method: 'DELETE' });
+}
+
+// ── Comments ─────────────────────────────────────────────────
+
+/** Approved comments on a post, as threads oldest first. */
+export async function listComments(slug: string) {
+  return apiFetch<APIComment[]>(`/api/v1/posts/${slug}/comments`);
+}
+
+/** Queues a comment for moderation. Keep `token` to edit or delete it later. */
+export async function submitComment(
+  slug: string,
+  data: { author_name: string; author_email?: string; body: string; parent_id?: string },
+) {
+  return apiFetch<APIComment & { token: string }>(`/api/v1/posts/${slug}/comments`, {
+    method: 'POST',
+    body: JSON.stringify(data),
+  });
+}
+
+/** Replaces a comment's body; the edit goes back to moderation. */
+export async function updateComment(id: string, token: string, body: string) {
+  return apiFetch<APIComment>(`/api/v1/comments/${id}`, {
+    method: 'PUT',
+    headers: { 'X-Comment-Token': token },
+    body: JSON.stringify({ body }),
+  });
+}
+
+export async function deleteComment(id: string, token: string) {
+  return apiFetch<void>(`/api/v1/comments/${id}`, {
+    method: 'DELETE',
+    headers: { 'X-Comment-Token': token },
+  });
+}
+
+export async function listModerationComments(opts?: {
+  status?: APICommentStatus;
+  page?: number;
+  perPage?: number;
+}) {
+  const params = new URLSearchParams();
+  if (opts?.status) params.set('status', opts.status);
+  if (opts?.page) params.set('page', String(opts.page));
+  if (opts?.perPage) params.set('per_page', String(opts.perPage));
+  const qs = params.toString();
+  return apiFetch<PaginatedResponse<APIComment>>(`/api/v1/admin/comments${qs ? '?' + qs : ''}`);
+}
+
+export async function moderateComment(id: string, status: Exclude<APICommentStatus, 'pending'>) {
+  return apiFetch<APIComment>(`/api/v1/admin/comments/${id}`, {
+    method: 'PATCH',
+    body: JSON.stringify({ status }),
+  });
+}
+
+export async function removeComment(id: string) {
+  return apiFetch<void>(`/api/v1/admin/comments/${id}`, { method: 'DELETE' });
+}
+
 // ── Contacts ─────────────────────────────────────────────────
 
 export async function submitContact(data: {
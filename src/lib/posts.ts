@@ -4,6 +4,8 @@ import type { Post } from '@/types';
 // One entry per post. Order = series order (oldest first).
 // To publish a new post: add its entry here with an `mdx` loader.
 // Posts without `mdx` are listed in metadata but not yet navigable.
+// `subcultctl content import-source` reads this array as well, so keep the
+// entries plain literals (the `mdx` loaders are skipped).
 
 export const posts: Post[] = [
   {
@@ -0,0 +1,88 @@
+import { useState, useEffect } from 'react';
+import { useLocation } from 'react-router-dom';
+import SEOHead from '@/components/SEOHead';
+import Tag from '@/components/ui/Tag';
+import { getPostPreview, type APIPost } from '@/lib/api';
+
+/**
+ * Shows an unpublished post to a reviewer holding a preview link. The token
+ * arrives in the URL fragment (/zine/preview#token=…) so it never reaches
+ * server logs.
+ */
+export default function PostPreview() {
+  const { hash } = useLocation();
+  const token = new URLSearchParams(hash.slice(1)).get('token') ?? '';
+  const [post, setPost] = useState<APIPost | null>(null);
+  const [error, setError] = useState('');
+
+  useEffect(() => {
+    if (!token) {
+      setError('missing preview token');
+      return;
+    }
+    getPostPreview(token)
+      .then(setPost)
+      .catch((err) => setError(err.message));
+  }, [token]);
+
+  useEffect(() => {
+    const meta = document.createElement('meta');
+    meta.name = 'robots';
+    meta.content = 'noindex';
+    document.head.appendChild(meta);
+    return () => meta.remove();
+  }, []);
+
+  if (error) {
+    return (
+      <div className="max-w-3xl mx-auto px-4 sm:px-6 lg:px-8 py-20">
+        <SEOHead title="Preview" path="/zine/preview" />
+        <p className="font-mono text-signal mb-4">&gt; ERROR: {error}</p>
+        <p className="text-bone">Ask the author for a new preview link.</p>
+      </div>
+    );
+  }
+
+  if (!post) {
+    return (
+      <div className="max-w-3xl mx-auto px-4 sm:px-6 lg:px-8 py-20">
+        <p className="font-mono text-dust">
+          &gt; loading preview...
+          <span className="cursor-blink" />
+        </p>
+      </div>
+    );
+  }
+
+  return (
+    <>
+      <SEOHead title={`Preview: ${post.title}`} description={post.excerpt} path="/zine/preview" />
+
+      <article className="max-w-3xl mx-auto px-4 sm:px-6 lg:px-8 py-12 md:py-20">
+        <p className="mb-8 p-3 border border-flicker font-mono text-xs text-flicker">
+          PREVIEW — this post is not public yet. Please don&apos;t share this link.
+        </p>
+
+        <header className="mb-10">
+          <h1 className="text-3xl mb-4">{post.title}</h1>
+          <div className="flex items-center gap-4 mb-4">
+            <span className="font-mono text-xs text-dust">{post.date}</span>
+            {post.author && <span className="font-mono text-xs text-dust">by {post.author}</span>}
+          </div>
+          <div className="flex flex-wrap gap-2 mt-4">
+            {post.tags.map((tag) => (
+              <Tag key={tag}>{tag}</Tag>
+            ))}
+          </div>
+        </header>
+
+        {post.content_html ? (
+          // Rendered and sanitized by the API.
+          <div className="mdx-content" dangerouslySetInnerHTML={{ __html: post.content_html }} />
+        ) : (
+          <div className="mdx-content whitespace-pre-wrap">{post.content}</div>
+        )}
+      </article>
+    </>
+  );
+}
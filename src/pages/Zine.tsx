@@ -2,6 +2,7 @@ import { Link } from 'react-router-dom';
 import SEOHead from '@/components/SEOHead';
 import Card from '@/components/ui/Card';
 import Button from '@/components/ui/Button';
+import ZineSearch from '@/components/ZineSearch';
 import { posts, isPublished } from '@/lib/posts';
 
 const numbered = posts.map((post, index) => ({
@@ -43,6 +44,8 @@ export default function Zine() {
           </p>
         </div>
 
+        <ZineSearch />
+
         {/* Posts Index */}
         <div className="space-y-4 max-w-3xl mx-auto">
           {numbered.map((post) => {
@@ -1,13 +1,46 @@
-import { useState, type FormEvent } from 'react';
+import { useEffect, useState, type FormEvent } from 'react';
 import { Navigate } from 'react-router-dom';
 import { useAuth } from '@/context/useAuth';
+import * as api from '@/lib/api';
+import type { MFAChallenge, MFASetup } from '@/lib/api';
 
 export default function AdminLogin() {
-  const { login, isAuthenticated, loading } = useAuth();
+  const { login, loginMFA, loginOIDC, isAuthenticated, loading } = useAuth();
   const [username, setUsername] = useState('');
   const [password, setPassword] = useState('');
+  const [challenge, setChallenge] = useState<MFAChallenge | null>(null);
+  const [setup, setSetup] = useState<MFASetup | null>(null);
+  const [code, setCode] = useState('');
   const [error, setError] = useState('');
   const [submitting, setSubmitting] = useState(false);
+  const [ssoEnabled, setSSOEnabled] = useState(false);
+
+  // Finish a single sign-on redirect (#oidc_code=… or #oidc_error=…) and
+  // check whether to offer SSO at all.
+  useEffect(() => {
+    const hash = new URLSearchParams(window.location.hash.slice(1));
+    const ssoCode = hash.get('oidc_code');
+    const ssoError = hash.get('oidc_error');
+    if (ssoCode || ssoError) {
+      window.history.replaceState(null, '', window.location.pathname);
+    }
+    if (ssoError) setError(`sso: ${ssoError}`);
+    if (ssoCode) {
+      loginOIDC(ssoCode)
+        .then(async (next) => {
+          if (!next) return;
+          setChallenge(next);
+          if (next.enrollment_required) {
+            setSetup(await api.loginMFASetup(next.mfa_token));
+          }
+        })
+        .catch((err) => setError(err instanceof Error ? err.message : 'sso failed'));
+    }
+    api
+      .getOIDCStatus()
+      .then((s) => setSSOEnabled(s.enabled))
+      .catch(() => undefined);
+  }, [loginOIDC]);
 
   if (loading) return null;
   if (isAuthenticated) return <Navigate to="/admin" replace />;
@@ -17,7 +50,17 @@ export default function AdminLogin() {
     setError('');
     setSubmitting(true);
     try {
-      await login(username, password);
+      if (challenge) {
+        await loginMFA(challenge.mfa_token, code);
+        return;
+      }
+      const next = await login(username, password);
+      if (next) {
+        setChallenge(next);
+        if (next.enrollment_required) {
+          setSetup(await api.loginMFASetup(next.mfa_token));
+        }
+      }
     } catch (err) {
       setError(err instanceof Error ? err.message : 'authentication failed');
     } finally {
@@ -53,36 +96,64 @@ export default function AdminLogin() {
 
             {/* Form */}
             <form onSubmit={handleSubmit} className="space-y-4">
-              <div>
-                <label htmlFor="login-username" className="block font-mono text-xs text-bone uppercase mb-1">
-                  &gt; IDENT
-                </label>
-                <input
-                  type="text"
-                  id="login-username"
-                  value={username}
-                  onChange={(e) => setUsername(e.target.value)}
-                  required
-                  autoFocus
-                  className="w-full bg-void border border-fog text-chalk font-mono text-sm px-3 py-2 focus:border-signal outline-none focus-visible:outline-2 focus-visible:outline-signal focus-visible:outline-offset-2 transition-colors duration-200"
-                  placeholder="username"
-                />
-              </div>
+              {challenge ? (
+                <div>
+                  {setup && (
+                    <div className="mb-4 p-3 bg-ash border border-fog font-mono text-xs text-bone break-all">
+                      2FA REQUIRED — add this key to your authenticator app:
+                      <span className="block mt-2 text-chalk">{setup.secret}</span>
+                    </div>
+                  )}
+                  <label htmlFor="login-code" className="block font-mono text-xs text-bone uppercase mb-1">
+                    &gt; CODE
+                  </label>
+                  <input
+                    type="text"
+                    id="login-code"
+                    inputMode="numeric"
+                    autoComplete="one-time-code"
+                    value={code}
+                    onChange={(e) => setCode(e.target.value)}
+                    required
+                    autoFocus
+                    className="w-full bg-void border border-fog text-chalk font-mono text-sm px-3 py-2 focus:border-signal outline-none focus-visible:outline-2 focus-visible:outline-signal focus-visible:outline-offset-2 transition-colors duration-200"
+                    placeholder="000000"
+                  />
+                </div>
+              ) : (
+                <>
+                  <div>
+                    <label htmlFor="login-username" className="block font-mono text-xs text-bone uppercase mb-1">
+                      &gt; IDENT
+                    </label>
+                    <input
+                      type="text"
+                      id="login-username"
+                      value={username}
+                      onChange={(e) => setUsername(e.target.value)}
+                      required
+                      autoFocus
+                      className="w-full bg-void border border-fog text-chalk font-mono text-sm px-3 py-2 focus:border-signal outline-none focus-visible:outline-2 focus-visible:outline-signal focus-visible:outline-offset-2 transition-colors duration-200"
+                      placeholder="username"
+                    />
+                  </div>
 
-              <div>
-                <label htmlFor="login-password" className="block font-mono text-xs text-bone uppercase mb-1">
-                  &gt; PASSKEY
-                </label>
-                <input
-                  type="password"
-                  id="login-password"
-                  value={password}
-                  onChange={(e) => setPassword(e.target.value)}
-                  required
-                  className="w-full bg-void border border-fog text-chalk font-mono text-sm px-3 py-2 focus:border-signal outline-none focus-visible:outline-2 focus-visible:outline-signal focus-visible:outline-offset-2 transition-colors duration-200"
-                  placeholder="••••••••"
-                />
-              </div>
+                  <div>
+                    <label htmlFor="login-password" className="block font-mono text-xs text-bone uppercase mb-1">
+                      &gt; PASSKEY
+                    </label>
+                    <input
+                      type="password"
+                      id="login-password"
+                      value={password}
+                      onChange={(e) => setPassword(e.target.value)}
+                      required
+                      className="w-full bg-void border border-fog text-chalk font-mono text-sm px-3 py-2 focus:border-signal outline-none focus-visible:outline-2 focus-visible:outline-signal focus-visible:outline-offset-2 transition-colors duration-200"
+                      placeholder="••••••••"
+                    />
+                  </div>
+                </>
+              )}
 
               <button
                 type="submit"
@@ -94,6 +165,16 @@ export default function AdminLogin() {
               </button>
             </form>
 
+            {ssoEnabled && !challenge && (
+              <a
+                href={api.oidcLoginURL()}
+                className="mt-3 block w-full py-2.5 border border-fog text-center text-bone font-mono text-sm tracking-wider
+                           hover:border-signal hover:text-signal transition-colors duration-200"
+              >
+                SINGLE SIGN-ON
+              </a>
+            )}
+
             {/* Bottom text */}
             <div className="mt-6 text-center">
               <a href="/" className="font-mono text-xs text-dust hover:text-bone transition-colors duration-200">
@@ -1,8 +1,29 @@
 import { useState, useEffect, type FormEvent } from 'react';
-import { listPosts, createPost, updatePost, deletePost, type APIPost } from '@/lib/api';
+import {
+  listPosts,
+  createPost,
+  updatePost,
+  deletePost,
+  downloadContentExport,
+  type APIPost,
+} from '@/lib/api';
 import { Field } from '@/components/admin/FormFields';
+import { PostHistory } from '@/components/admin/PostHistory';
+import { PostPreviews } from '@/components/admin/PostPreviews';
+import { TagManager } from '@/components/admin/TagManager';
 
-type PostForm = Omit<APIPost, 'id' | 'created_at' | 'updated_at'>;
+type PostForm = Omit<APIPost, 'id' | 'created_at' | 'updated_at' | 'published_at'>;
+
+/** Converts an ISO timestamp to a datetime-local input value in local time. */
+function toLocalInput(iso?: string) {
+  if (!iso) return '';
+  const d = new Date(iso);
+  return new Date(d.getTime() - d.getTimezoneOffset() * 60000).toISOString().slice(0, 16);
+}
+
+function isScheduled(p: APIPost) {
+  return p.published && !!p.publish_at && new Date(p.publish_at) > new Date();
+}
 
 const emptyForm: PostForm = {
   slug: '',
@@ -21,6 +42,8 @@ export default function AdminPosts() {
   const [form, setForm] = useState<PostForm>(emptyForm);
   const [error, setError] = useState('');
   const [saving, setSaving] = useState(false);
+  const [history, setHistory] = useState<APIPost | null>(null);
+  const [sharing, setSharing] = useState<APIPost | null>(null);
 
   const load = () => {
     listPosts({ all: true, perPage: 100 })
@@ -47,6 +70,7 @@ export default function AdminPosts() {
       author: p.author,
       published: p.published,
       date: p.date,
+      publish_at: p.publish_at,
     });
     setShowForm(true);
   };
@@ -87,13 +111,27 @@ export default function AdminPosts() {
           <p className="font-mono text-xs text-dust mb-1">&gt; TRANSMISSION LOG</p>
           <h1 className="text-2xl">Posts</h1>
         </div>
-        <button
-          onClick={openNew}
-          className="px-4 py-2 bg-signal text-void font-mono text-sm font-bold tracking-wider
-                     hover:bg-signal-dim transition-colors duration-200 cursor-pointer"
-        >
-          + NEW POST
-        </button>
+        <div className="flex gap-2">
+          <button
+            onClick={() =>
+              downloadContentExport().catch((err) =>
+                setError(err instanceof Error ? err.message : 'export failed'),
+              )
+            }
+            className="px-4 py-2 bg-ash border border-fog text-chalk font-mono text-sm tracking-wider
+                       hover:border-dust transition-colors duration-200 cursor-pointer"
+            title="Download all posts and projects as MDX + projects.json"
+          >
+            EXPORT
+          </button>
+          <button
+            onClick={openNew}
+            className="px-4 py-2 bg-signal text-void font-mono text-sm font-bold tracking-wider
+                       hover:bg-signal-dim transition-colors duration-200 cursor-pointer"
+          >
+            + NEW POST
+          </button>
+        </div>
       </div>
 
       {error && (
@@ -173,6 +211,12 @@ export default function AdminPosts() {
                 Published
               </label>
             </div>
+            <Field
+              label="Publish at (optional, local time)"
+              value={toLocalInput(form.publish_at)}
+              onChange={(v) => setForm({ ...form, publish_at: v ? new Date(v).toISOString() : undefined })}
+              type="datetime-local"
+            />
             <div className="flex gap-3 pt-2">
               <button
                 type="submit"
@@ -193,6 +237,21 @@ export default function AdminPosts() {
         </div>
       )}
 
+      {history && (
+        <PostHistory
+          post={history}
+          onRestored={(p) => {
+            setHistory(p);
+            load();
+          }}
+          onClose={() => setHistory(null)}
+        />
+      )}
+
+      <TagManager onChanged={load} />
+
+      {sharing && <PostPreviews post={sharing} onClose={() => setSharing(null)} />}
+
       {/* ── Table ───────────────────────────────────────────── */}
       <div className="overflow-x-auto">
         <table className="w-full text-sm">
@@ -215,9 +274,10 @@ export default function AdminPosts() {
                 <td className="py-3 px-3 font-mono text-xs text-bone">{p.date}</td>
                 <td className="py-3 px-3">
                   <span
-                    className={`font-mono text-xs ${p.published ? 'text-static' : 'text-dust'}`}
+                    className={`font-mono text-xs ${isScheduled(p) ? 'text-flicker' : p.published ? 'text-static' : 'text-dust'}`}
+                    title={isScheduled(p) ? new Date(p.publish_at!).toLocaleString() : undefined}
                   >
-                    {p.published ? 'LIVE' : 'DRAFT'}
+                    {isScheduled(p) ? 'SCHEDULED' : p.published ? 'LIVE' : 'DRAFT'}
                   </span>
                 </td>
                 <td className="py-3 px-3">
@@ -237,6 +297,18 @@ export default function AdminPosts() {
                     >
                       EDIT
                     </button>
+                    <button
+                      onClick={() => setHistory(p)}
+                      className="font-mono text-xs text-dust hover:text-glow cursor-pointer"
+                    >
+                      HISTORY
+                    </button>
+                    <button
+                      onClick={() => setSharing(p)}
+                      className="font-mono text-xs text-dust hover:text-glow cursor-pointer"
+                    >
+                      SHARE
+                    </button>
                     <button
                       onClick={() => handleDelete(p.id)}
                       className="font-mono text-xs text-signal hover:text-glow cursor-pointer"
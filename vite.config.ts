@@ -1,4 +1,4 @@
-import { defineConfig } from 'vite';
+import { defineConfig, loadEnv, type Plugin } from 'vite';
 import react from '@vitejs/plugin-react';
 import tailwindcss from '@tailwindcss/vite';
 import mdx from '@mdx-js/rollup';
@@ -8,32 +8,67 @@ import rehypeSlug from 'rehype-slug';
 import rehypeAutolinkHeadings from 'rehype-autolink-headings';
 import path from 'path';
 
-export default defineConfig({
-  plugins: [
-    mdx({
-      remarkPlugins: [remarkGfm, remarkFrontmatter],
-      rehypePlugins: [rehypeSlug, rehypeAutolinkHeadings],
-    }),
-    react(),
-    tailwindcss(),
-  ],
-  resolve: {
-    alias: {
-      '@': path.resolve(__dirname, './src'),
-      '@content': path.resolve(__dirname, './content'),
+// Feeds used to be static files on the site; the API serves them now.
+const feedPaths = ['/feed.xml', '/atom.xml', '/feed.json'];
+
+// feedRedirects writes dist/_redirects (Cloudflare Pages, Netlify), sending
+// the old site-hosted feed URLs to the API before the SPA fallback.
+function feedRedirects(apiURL: string): Plugin {
+  return {
+    name: 'feed-redirects',
+    apply: 'build',
+    generateBundle() {
+      const rules = feedPaths.map((p) => `${p} ${apiURL}${p} 301`);
+      this.emitFile({
+        type: 'asset',
+        fileName: '_redirects',
+        source: [...rules, '/* /index.html 200', ''].join('\n'),
+      });
     },
-  },
-  server: {
-    proxy: {
-      '/api': {
-        target: 'http://localhost:8080',
-        changeOrigin: true,
+  };
+}
+
+export default defineConfig(({ command, mode }) => {
+  const apiURL = loadEnv(mode, process.cwd(), 'VITE_').VITE_API_URL ?? '';
+  if (command === 'build' && !/^https?:\/\//.test(apiURL)) {
+    // index.html's feed links and the feed redirects need an absolute origin
+    throw new Error('VITE_API_URL must be set to the API origin, e.g. https://api.subcult.tv');
+  }
+  // In dev the feed links stay on this origin and go through the proxy below
+  process.env.VITE_API_URL ??= '';
+
+  return {
+    plugins: [
+      mdx({
+        remarkPlugins: [remarkGfm, remarkFrontmatter],
+        rehypePlugins: [rehypeSlug, rehypeAutolinkHeadings],
+      }),
+      react(),
+      tailwindcss(),
+      feedRedirects(apiURL),
+    ],
+    resolve: {
+      alias: {
+        '@': path.resolve(__dirname, './src'),
+        '@content': path.resolve(__dirname, './content'),
       },
-      '/umami': {
-        target: 'http://localhost:3001',
-        changeOrigin: true,
-        rewrite: (path) => path.replace(/^\/umami/, ''),
+    },
+    server: {
+      proxy: {
+        '/api': {
+          target: 'http://localhost:8080',
+          changeOrigin: true,
+        },
+        '^/(feed\\.xml|atom\\.xml|feed\\.json)(\\?|$)': {
+          target: 'http://localhost:8080',
+          changeOrigin: true,
+        },
+        '/umami': {
+          target: 'http://localhost:3001',
+          changeOrigin: true,
+          rewrite: (path) => path.replace(/^\/umami/, ''),
+        },
       },
     },
-  },
+  };
 });